@@ -0,0 +1,215 @@
+// Command clearnode-sim runs a local Clearnode simulator for developing
+// against the faucet without network access or funded keys.
+//
+// It serves the WebSocket RPC protocol spoken by clearnode.Client on top of
+// the fake in internal/clearnodetest: authentication, get_assets,
+// get_ledger_balances, transfer and get_ledger_transactions against an
+// in-memory ledger. Every wallet that authenticates is funded with the
+// default balances, so any pair of keys works out of the box.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/erc7824/nitrolite/clearnode/pkg/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"faucet-server/internal/clearnodetest"
+	"faucet-server/internal/logger"
+)
+
+type options struct {
+	addr     string
+	balances []string
+	funds    []string
+	assets   []string
+	chainID  uint32
+	latency  time.Duration
+	logLevel string
+}
+
+func main() {
+	if err := newCommand().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func newCommand() *cobra.Command {
+	var opts options
+
+	cmd := &cobra.Command{
+		Use:   "clearnode-sim",
+		Short: "Run a local Clearnode simulator",
+		Long: `Run a local Clearnode simulator with an in-memory ledger.
+
+Point the faucet at it with CLEARNODE_URL=ws://localhost:8000/ws. Any owner and
+signer keys work: wallets are funded with the --balance amounts when they first
+authenticate.`,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return run(opts)
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.addr, "addr", "localhost:8000", "Address to listen on")
+	flags.StringArrayVar(&opts.balances, "balance", []string{"usdc=1000000", "eth=100"}, "Balance every wallet starts with, as ASSET=AMOUNT (repeatable)")
+	flags.StringArrayVar(&opts.funds, "fund", nil, "Additional balance for one wallet, as ADDRESS:ASSET=AMOUNT (repeatable)")
+	flags.StringArrayVar(&opts.assets, "asset", nil, "Supported asset as SYMBOL:DECIMALS (repeatable, defaults to usdc and eth)")
+	flags.Uint32Var(&opts.chainID, "chain-id", 1, "Chain ID reported for --asset assets")
+	flags.DurationVar(&opts.latency, "latency", 0, "Delay before every response")
+	flags.StringVar(&opts.logLevel, "log-level", "info", "Log level (debug, info, warn, error)")
+
+	return cmd
+}
+
+func run(opts options) error {
+	if err := logger.Initialize(opts.logLevel); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	node, err := newNode(opts)
+	if err != nil {
+		return err
+	}
+	node.Observe(logExchange)
+
+	server := &http.Server{
+		Addr:              opts.addr,
+		Handler:           node,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	logger.Infof("Clearnode simulator listening on ws://%s/ws", opts.addr)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("failed to serve: %w", err)
+	case <-quit:
+	}
+
+	logger.Info("Shutting down Clearnode simulator")
+	node.DisconnectAll()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+	return nil
+}
+
+// newNode builds the simulated Clearnode described by opts.
+func newNode(opts options) (*clearnodetest.Node, error) {
+	node := clearnodetest.NewNode()
+	node.SetLatency(opts.latency)
+
+	if len(opts.assets) > 0 {
+		assets, err := parseAssets(opts.assets, opts.chainID)
+		if err != nil {
+			return nil, err
+		}
+		node.SetAssets(assets)
+	}
+
+	balances := make(map[string]decimal.Decimal)
+	for _, value := range opts.balances {
+		asset, amount, err := parseBalance(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --balance %q: %w", value, err)
+		}
+		balances[asset] = amount
+	}
+	node.SetDefaultBalances(balances)
+
+	for _, value := range opts.funds {
+		address, balance, ok := strings.Cut(value, ":")
+		if !ok || !common.IsHexAddress(address) {
+			return nil, fmt.Errorf("invalid --fund %q: expected ADDRESS:ASSET=AMOUNT", value)
+		}
+		asset, amount, err := parseBalance(balance)
+		if err != nil {
+			return nil, fmt.Errorf("invalid --fund %q: %w", value, err)
+		}
+		node.Fund(common.HexToAddress(address), asset, amount)
+	}
+
+	return node, nil
+}
+
+// parseBalance parses ASSET=AMOUNT.
+func parseBalance(value string) (string, decimal.Decimal, error) {
+	asset, raw, ok := strings.Cut(value, "=")
+	asset = strings.ToLower(strings.TrimSpace(asset))
+	if !ok || asset == "" {
+		return "", decimal.Decimal{}, errors.New("expected ASSET=AMOUNT")
+	}
+
+	amount, err := decimal.NewFromString(strings.TrimSpace(raw))
+	if err != nil || amount.IsNegative() {
+		return "", decimal.Decimal{}, fmt.Errorf("amount must be a non-negative number, got %q", raw)
+	}
+	return asset, amount, nil
+}
+
+// parseAssets parses SYMBOL:DECIMALS values. Token addresses are made up, as
+// the simulator has no chain behind it.
+func parseAssets(values []string, chainID uint32) ([]rpc.Asset, error) {
+	assets := make([]rpc.Asset, 0, len(values))
+	for i, value := range values {
+		symbol, raw, ok := strings.Cut(value, ":")
+		symbol = strings.ToLower(strings.TrimSpace(symbol))
+		decimals, err := strconv.ParseUint(strings.TrimSpace(raw), 10, 8)
+		if !ok || symbol == "" || err != nil {
+			return nil, fmt.Errorf("invalid --asset %q: expected SYMBOL:DECIMALS", value)
+		}
+
+		assets = append(assets, rpc.Asset{
+			Token:    common.BytesToAddress([]byte{byte(i + 1)}).Hex(),
+			ChainID:  chainID,
+			Symbol:   symbol,
+			Decimals: uint8(decimals),
+		})
+	}
+	return assets, nil
+}
+
+// logExchange logs a request and the response sent for it.
+func logExchange(exchange clearnodetest.Exchange) {
+	req := exchange.Request
+
+	response := "no response"
+	if exchange.Method != "" {
+		data, _ := json.Marshal(exchange.Data)
+		response = exchange.Method + " " + string(data)
+	}
+
+	if exchange.Method == "error" {
+		logger.Warnf("RPC %d %s %s -> %s", req.ID, req.Method, req.Params, response)
+		return
+	}
+	logger.Infof("RPC %d %s %s -> %s", req.ID, req.Method, req.Params, response)
+}
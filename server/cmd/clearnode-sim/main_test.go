@@ -0,0 +1,77 @@
+package main
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/logger"
+)
+
+func TestNewNode(t *testing.T) {
+	require.NoError(t, logger.Initialize("error"))
+
+	funded := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+	node, err := newNode(options{
+		balances: []string{"USDC=500"},
+		funds:    []string{funded + ":usdc=7"},
+		assets:   []string{"usdc:6", "weth:18"},
+		chainID:  11155111,
+	})
+	require.NoError(t, err)
+
+	server := httptest.NewServer(node)
+	defer server.Close()
+
+	// The faucet works against the simulator with arbitrary keys
+	client, err := clearnode.NewClient(
+		"abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
+		"fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321",
+		"ws"+strings.TrimPrefix(server.URL, "http")+"/ws", "usdc", decimal.NewFromInt(10), 50)
+	require.NoError(t, err)
+	defer client.Close()
+	require.NoError(t, client.Connect())
+	require.NoError(t, client.Authenticate())
+	require.NoError(t, client.EnsureOperational())
+
+	assets, err := client.GetAssets()
+	require.NoError(t, err)
+	require.Len(t, assets, 2)
+	assert.Equal(t, "weth", assets[1].Symbol)
+	assert.Equal(t, uint8(18), assets[1].Decimals)
+	assert.Equal(t, uint32(11155111), assets[1].ChainID)
+	assert.NotEqual(t, assets[0].Token, assets[1].Token)
+
+	_, err = client.Transfer(funded, "usdc", decimal.NewFromInt(10))
+	require.NoError(t, err)
+	node.AssertBalance(t, client.GetOwnerAddress(), "usdc", decimal.NewFromInt(490))
+	node.AssertBalance(t, common.HexToAddress(funded), "usdc", decimal.NewFromInt(17))
+}
+
+func TestNewNodeRejectsInvalidFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		opts options
+		err  string
+	}{
+		{"balance without amount", options{balances: []string{"usdc"}}, "invalid --balance"},
+		{"negative balance", options{balances: []string{"usdc=-1"}}, "non-negative"},
+		{"fund without address", options{funds: []string{"usdc=1"}}, "invalid --fund"},
+		{"fund with bad amount", options{funds: []string{"0x742D35CC6634c0532925a3B8c17D18fBe3b78890:usdc=lots"}}, "invalid --fund"},
+		{"asset without decimals", options{assets: []string{"usdc"}}, "invalid --asset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newNode(tt.opts)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package activity
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many entries a slow subscriber may lag behind
+// before entries are dropped for it.
+const subscriberBuffer = 16
+
+// ErrTooManySubscribers is returned by Subscribe when the feed already has
+// the maximum number of subscribers.
+var ErrTooManySubscribers = errors.New("too many subscribers")
+
+// Entry is an anonymised record of a dispensation.
+//
+// IDs increase by one per entry, starting from the feed's creation time in
+// Unix milliseconds. The feed lives in memory only, so this keeps the IDs of
+// a restarted process above those a client saw before the restart (unless
+// the previous process averaged more than one entry per millisecond).
+type Entry struct {
+	ID        uint64    `json:"id"`
+	Address   string    `json:"address"`
+	Amount    string    `json:"amount"`
+	Asset     string    `json:"asset"`
+	TxID      string    `json:"txId,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// Feed keeps the most recent entries in a ring buffer and fans new entries
+// out to subscribers.
+type Feed struct {
+	mu          sync.Mutex
+	entries     []Entry
+	next        int
+	full        bool
+	lastID      uint64
+	subscribers map[chan Entry]struct{}
+}
+
+// NewFeed returns a feed remembering the last size entries.
+func NewFeed(size int) *Feed {
+	return &Feed{
+		entries:     make([]Entry, size),
+		lastID:      uint64(time.Now().UnixMilli()),
+		subscribers: make(map[chan Entry]struct{}),
+	}
+}
+
+// Publish records a dispensation to address, truncating the address so the
+// feed can be shown publicly, and delivers it to every subscriber.
+// Subscribers that can't keep up miss the entry rather than block the caller.
+func (f *Feed) Publish(address, amount, asset, txID string) Entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.lastID++
+	entry := Entry{
+		ID:        f.lastID,
+		Address:   TruncateAddress(address),
+		Amount:    amount,
+		Asset:     asset,
+		TxID:      txID,
+		Timestamp: time.Now().UTC(),
+	}
+
+	if len(f.entries) > 0 {
+		f.entries[f.next] = entry
+		f.next = (f.next + 1) % len(f.entries)
+		if f.next == 0 {
+			f.full = true
+		}
+	}
+
+	for ch := range f.subscribers {
+		select {
+		case ch <- entry:
+		default:
+		}
+	}
+
+	return entry
+}
+
+// Recent returns the buffered entries, oldest first.
+func (f *Feed) Recent() []Entry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.recent()
+}
+
+// Subscribe returns the buffered entries together with a channel receiving
+// every later entry, so subscribers see no gap between the two. Call cancel
+// to unsubscribe. It returns ErrTooManySubscribers if the feed already has
+// max subscribers.
+func (f *Feed) Subscribe(max int) (recent []Entry, entries <-chan Entry, cancel func(), err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if len(f.subscribers) >= max {
+		return nil, nil, nil, ErrTooManySubscribers
+	}
+
+	ch := make(chan Entry, subscriberBuffer)
+	f.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	cancel = func() {
+		once.Do(func() {
+			f.mu.Lock()
+			defer f.mu.Unlock()
+			delete(f.subscribers, ch)
+		})
+	}
+
+	return f.recent(), ch, cancel, nil
+}
+
+// Subscribers returns the number of active subscriptions.
+func (f *Feed) Subscribers() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.subscribers)
+}
+
+// recent copies the ring buffer in chronological order. The caller must hold f.mu.
+func (f *Feed) recent() []Entry {
+	if !f.full {
+		return append([]Entry(nil), f.entries[:f.next]...)
+	}
+
+	recent := make([]Entry, 0, len(f.entries))
+	recent = append(recent, f.entries[f.next:]...)
+	return append(recent, f.entries[:f.next]...)
+}
+
+// TruncateAddress shortens an address to its first and last four hex digits,
+// e.g. 0x742d…8890.
+func TruncateAddress(address string) string {
+	if len(address) <= 12 {
+		return address
+	}
+	return address[:6] + "…" + address[len(address)-4:]
+}
@@ -0,0 +1,84 @@
+package activity
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+func TestFeed(t *testing.T) {
+	feed := NewFeed(3)
+	assert.Empty(t, feed.Recent())
+
+	start := uint64(time.Now().UnixMilli())
+	var first Entry
+
+	t.Run("anonymises addresses", func(t *testing.T) {
+		first = feed.Publish(testAddress, "10", "usdc", "1")
+		assert.Equal(t, "0x742D…8890", first.Address)
+	})
+
+	t.Run("IDs continue from the feed's creation time", func(t *testing.T) {
+		assert.GreaterOrEqual(t, first.ID, start)
+		assert.Equal(t, first.ID+1, feed.Publish(testAddress, "10", "usdc", "2").ID)
+	})
+
+	t.Run("keeps the last entries in order", func(t *testing.T) {
+		for _, txID := range []string{"3", "4", "5"} {
+			feed.Publish(testAddress, "10", "usdc", txID)
+		}
+
+		recent := feed.Recent()
+		require.Len(t, recent, 3)
+		assert.Equal(t, "3", recent[0].TxID)
+		assert.Equal(t, "5", recent[2].TxID)
+	})
+
+	t.Run("subscribers get backlog and new entries", func(t *testing.T) {
+		recent, entries, cancel, err := feed.Subscribe(1)
+		require.NoError(t, err)
+		defer cancel()
+
+		require.Len(t, recent, 3)
+		assert.Equal(t, 1, feed.Subscribers())
+
+		feed.Publish(testAddress, "5", "weth", "6")
+		select {
+		case entry := <-entries:
+			assert.Equal(t, "6", entry.TxID)
+			assert.Equal(t, "weth", entry.Asset)
+		case <-time.After(time.Second):
+			t.Fatal("subscriber did not receive entry")
+		}
+
+		cancel()
+		assert.Zero(t, feed.Subscribers())
+	})
+
+	t.Run("refuses subscribers above the maximum", func(t *testing.T) {
+		_, _, cancel, err := feed.Subscribe(1)
+		require.NoError(t, err)
+
+		_, _, _, err = feed.Subscribe(1)
+		assert.ErrorIs(t, err, ErrTooManySubscribers)
+
+		cancel()
+		_, _, cancel, err = feed.Subscribe(1)
+		require.NoError(t, err)
+		cancel()
+	})
+
+	t.Run("slow subscribers don't block publishing", func(t *testing.T) {
+		_, _, cancel, err := feed.Subscribe(1)
+		require.NoError(t, err)
+		defer cancel()
+
+		for i := 0; i < subscriberBuffer*2; i++ {
+			feed.Publish(testAddress, "1", "usdc", "")
+		}
+	})
+}
@@ -0,0 +1,152 @@
+package alert
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Level classifies the faucet balance by the number of tips it still covers.
+type Level string
+
+const (
+	LevelOK       Level = "ok"
+	LevelWarning  Level = "warning"
+	LevelCritical Level = "critical"
+)
+
+// Thresholds are expressed in tips remaining; a balance covering at most
+// that many tips raises the level.
+type Thresholds struct {
+	WarningTips  int
+	CriticalTips int
+}
+
+// Alert is the notification sent when the balance level of an asset changes.
+type Alert struct {
+	Level         Level     `json:"level"`
+	Recovered     bool      `json:"recovered"`
+	Asset         string    `json:"asset"`
+	Balance       string    `json:"balance"`
+	TipAmount     string    `json:"tipAmount"`
+	TipsRemaining int64     `json:"tipsRemaining"`
+	Threshold     int       `json:"threshold,omitempty"`
+	FaucetAddress string    `json:"faucetAddress"`
+	Message       string    `json:"message"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// Notifier delivers alerts to an external system.
+type Notifier interface {
+	Notify(ctx context.Context, alert Alert) error
+}
+
+// Monitor turns balance readings into alerts. It only notifies when an
+// asset's level changes, so a balance that stays low is reported once and
+// a recovery is reported when it is topped up again.
+type Monitor struct {
+	faucetAddress string
+	notifiers     []Notifier
+
+	mu     sync.Mutex
+	levels map[string]Level
+}
+
+func NewMonitor(faucetAddress string, notifiers []Notifier) *Monitor {
+	return &Monitor{
+		faucetAddress: faucetAddress,
+		notifiers:     notifiers,
+		levels:        make(map[string]Level),
+	}
+}
+
+// Level returns the last level observed for asset.
+func (m *Monitor) Level(asset string) Level {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if level, ok := m.levels[strings.ToLower(asset)]; ok {
+		return level
+	}
+	return LevelOK
+}
+
+// Check classifies balance and notifies every notifier if the level of asset
+// changed since the last check. The new level is only remembered once at
+// least one notifier accepted the alert, so failed deliveries are retried on
+// the next check. It returns the alert that was sent, if any.
+func (m *Monitor) Check(ctx context.Context, asset string, balance, tipAmount decimal.Decimal, thresholds Thresholds) (*Alert, error) {
+	key := strings.ToLower(asset)
+	tips := balance.Div(tipAmount).Floor().IntPart()
+	level, threshold := classify(tips, thresholds)
+
+	m.mu.Lock()
+	previous, ok := m.levels[key]
+	if !ok {
+		previous = LevelOK
+	}
+	m.mu.Unlock()
+
+	if level == previous {
+		return nil, nil
+	}
+
+	alert := Alert{
+		Level:         level,
+		Recovered:     level == LevelOK,
+		Asset:         asset,
+		Balance:       balance.String(),
+		TipAmount:     tipAmount.String(),
+		TipsRemaining: tips,
+		Threshold:     threshold,
+		FaucetAddress: m.faucetAddress,
+		Timestamp:     time.Now().UTC(),
+	}
+	alert.Message = message(alert)
+
+	var errs []string
+	for _, notifier := range m.notifiers {
+		if err := notifier.Notify(ctx, alert); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	if len(m.notifiers) > 0 && len(errs) == len(m.notifiers) {
+		return nil, fmt.Errorf("failed to deliver %s alert: %s", level, strings.Join(errs, "; "))
+	}
+
+	m.mu.Lock()
+	m.levels[key] = level
+	m.mu.Unlock()
+
+	if len(errs) > 0 {
+		return &alert, fmt.Errorf("failed to deliver %s alert to some webhooks: %s", level, strings.Join(errs, "; "))
+	}
+
+	return &alert, nil
+}
+
+func classify(tips int64, thresholds Thresholds) (Level, int) {
+	switch {
+	case tips <= int64(thresholds.CriticalTips):
+		return LevelCritical, thresholds.CriticalTips
+	case tips <= int64(thresholds.WarningTips):
+		return LevelWarning, thresholds.WarningTips
+	default:
+		return LevelOK, 0
+	}
+}
+
+func message(alert Alert) string {
+	if alert.Recovered {
+		return fmt.Sprintf("[RECOVERED] Faucet %s balance is back to %s (%d tips remaining).",
+			alert.Asset, alert.Balance, alert.TipsRemaining)
+	}
+
+	return fmt.Sprintf("[%s] Faucet %s balance is low: %s (%d tips remaining, threshold %d). Top up %s.",
+		strings.ToUpper(string(alert.Level)), alert.Asset, alert.Balance, alert.TipsRemaining, alert.Threshold, alert.FaucetAddress)
+}
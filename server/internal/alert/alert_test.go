@@ -0,0 +1,137 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []Alert
+	err    error
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, alert Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.err != nil {
+		return n.err
+	}
+	n.alerts = append(n.alerts, alert)
+	return nil
+}
+
+func TestMonitor(t *testing.T) {
+	notifier := &recordingNotifier{}
+	monitor := NewMonitor("0xFaucet", []Notifier{notifier})
+	thresholds := Thresholds{WarningTips: 50, CriticalTips: 10}
+	tip := decimal.NewFromInt(10)
+	ctx := context.Background()
+
+	check := func(balance int64) *Alert {
+		t.Helper()
+		sent, err := monitor.Check(ctx, "usdc", decimal.NewFromInt(balance), tip, thresholds)
+		require.NoError(t, err)
+		return sent
+	}
+
+	assert.Nil(t, check(1000), "healthy balance is not reported")
+
+	sent := check(495)
+	require.NotNil(t, sent)
+	assert.Equal(t, LevelWarning, sent.Level)
+	assert.Equal(t, int64(49), sent.TipsRemaining)
+	assert.Equal(t, 50, sent.Threshold)
+	assert.Contains(t, sent.Message, "Top up 0xFaucet")
+
+	assert.Nil(t, check(400), "unchanged level is de-duplicated")
+
+	sent = check(100)
+	require.NotNil(t, sent)
+	assert.Equal(t, LevelCritical, sent.Level)
+	assert.Equal(t, LevelCritical, monitor.Level("USDC"))
+
+	sent = check(5000)
+	require.NotNil(t, sent)
+	assert.Equal(t, LevelOK, sent.Level)
+	assert.True(t, sent.Recovered)
+	assert.Contains(t, sent.Message, "RECOVERED")
+
+	assert.Len(t, notifier.alerts, 3)
+}
+
+func TestMonitorRetriesFailedDelivery(t *testing.T) {
+	notifier := &recordingNotifier{err: errors.New("webhook down")}
+	monitor := NewMonitor("0xFaucet", []Notifier{notifier})
+	thresholds := Thresholds{WarningTips: 50, CriticalTips: 10}
+	tip := decimal.NewFromInt(1)
+
+	_, err := monitor.Check(context.Background(), "usdc", decimal.NewFromInt(5), tip, thresholds)
+	require.Error(t, err)
+	assert.Equal(t, LevelOK, monitor.Level("usdc"))
+
+	notifier.err = nil
+	sent, err := monitor.Check(context.Background(), "usdc", decimal.NewFromInt(5), tip, thresholds)
+	require.NoError(t, err)
+	require.NotNil(t, sent)
+	assert.Equal(t, LevelCritical, sent.Level)
+}
+
+func TestWebhookNotifierFormats(t *testing.T) {
+	alert := Alert{Level: LevelWarning, Asset: "usdc", Balance: "42", Message: "balance is low"}
+
+	tests := []struct {
+		format Format
+		check  func(t *testing.T, payload map[string]interface{})
+	}{
+		{FormatGeneric, func(t *testing.T, payload map[string]interface{}) {
+			assert.Equal(t, "warning", payload["level"])
+			assert.Equal(t, "42", payload["balance"])
+			assert.Equal(t, "balance is low", payload["message"])
+		}},
+		{FormatSlack, func(t *testing.T, payload map[string]interface{}) {
+			assert.Equal(t, map[string]interface{}{"text": "balance is low"}, payload)
+		}},
+		{FormatDiscord, func(t *testing.T, payload map[string]interface{}) {
+			assert.Equal(t, map[string]interface{}{"content": "balance is low"}, payload)
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.format), func(t *testing.T) {
+			var payload map[string]interface{}
+			webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+				body, _ := io.ReadAll(r.Body)
+				assert.NoError(t, json.Unmarshal(body, &payload))
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer webhook.Close()
+
+			require.NoError(t, NewWebhookNotifier(webhook.URL, tt.format).Notify(context.Background(), alert))
+			tt.check(t, payload)
+		})
+	}
+
+	t.Run("non-2xx status is an error", func(t *testing.T) {
+		webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}))
+		defer webhook.Close()
+
+		err := NewWebhookNotifier(webhook.URL, FormatSlack).Notify(context.Background(), alert)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "502")
+	})
+}
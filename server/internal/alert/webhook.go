@@ -0,0 +1,76 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+const webhookTimeout = 10 * time.Second
+
+// Format selects the payload shape expected by the receiving webhook.
+type Format string
+
+const (
+	// FormatGeneric posts the Alert itself as JSON.
+	FormatGeneric Format = "generic"
+	// FormatSlack posts {"text": ...} as accepted by Slack incoming webhooks.
+	FormatSlack Format = "slack"
+	// FormatDiscord posts {"content": ...} as accepted by Discord webhooks.
+	FormatDiscord Format = "discord"
+)
+
+// WebhookNotifier POSTs alerts as JSON to a URL.
+type WebhookNotifier struct {
+	url        string
+	format     Format
+	httpClient *http.Client
+}
+
+func NewWebhookNotifier(url string, format Format) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		format:     format,
+		httpClient: &http.Client{Timeout: webhookTimeout},
+	}
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, alert Alert) error {
+	var payload interface{}
+	switch n.format {
+	case FormatSlack:
+		payload = map[string]string{"text": alert.Message}
+	case FormatDiscord:
+		payload = map[string]string{"content": alert.Message}
+	default:
+		payload = alert
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create %s webhook request: %w", n.format, err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s webhook request failed: %w", n.format, err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s webhook returned status %d", n.format, resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,85 @@
+package captcha
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const verifyTimeout = 10 * time.Second
+
+var (
+	// ErrVerificationFailed is returned when the provider rejects the token.
+	ErrVerificationFailed = errors.New("captcha verification failed")
+	// ErrUnavailable is returned when the provider could not be asked.
+	ErrUnavailable = errors.New("captcha verification unavailable")
+)
+
+// Verifier checks CAPTCHA response tokens submitted by clients.
+type Verifier interface {
+	Verify(ctx context.Context, token, remoteIP string) error
+}
+
+// SiteVerifier verifies tokens against a siteverify-style API as offered by
+// hCaptcha, Cloudflare Turnstile and reCAPTCHA.
+type SiteVerifier struct {
+	verifyURL  string
+	secret     string
+	httpClient *http.Client
+}
+
+type siteVerifyResponse struct {
+	Success    bool     `json:"success"`
+	ErrorCodes []string `json:"error-codes"`
+}
+
+func NewSiteVerifier(verifyURL, secret string) *SiteVerifier {
+	return &SiteVerifier{
+		verifyURL:  verifyURL,
+		secret:     secret,
+		httpClient: &http.Client{Timeout: verifyTimeout},
+	}
+}
+
+func (v *SiteVerifier) Verify(ctx context.Context, token, remoteIP string) error {
+	form := url.Values{
+		"secret":   {v.secret},
+		"response": {token},
+	}
+	if remoteIP != "" {
+		form.Set("remoteip", remoteIP)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.verifyURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: siteverify returned status %d", ErrUnavailable, resp.StatusCode)
+	}
+
+	var result siteVerifyResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&result); err != nil {
+		return fmt.Errorf("%w: invalid siteverify response: %v", ErrUnavailable, err)
+	}
+
+	if !result.Success {
+		return fmt.Errorf("%w: %s", ErrVerificationFailed, strings.Join(result.ErrorCodes, ", "))
+	}
+
+	return nil
+}
@@ -0,0 +1,64 @@
+package captcha
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newFakeSiteVerify(t *testing.T, status int, body string) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		require.NoError(t, r.ParseForm())
+		assert.Equal(t, "test-secret", r.PostForm.Get("secret"))
+		assert.Equal(t, "test-token", r.PostForm.Get("response"))
+		assert.Equal(t, "203.0.113.7", r.PostForm.Get("remoteip"))
+
+		w.WriteHeader(status)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestSiteVerifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr error
+	}{
+		{"accepts valid token", http.StatusOK, `{"success": true, "hostname": "faucet.example.com"}`, nil},
+		{"rejects invalid token", http.StatusOK, `{"success": false, "error-codes": ["invalid-input-response"]}`, ErrVerificationFailed},
+		{"reports provider errors", http.StatusInternalServerError, ``, ErrUnavailable},
+		{"reports malformed responses", http.StatusOK, `<html>`, ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeSiteVerify(t, tt.status, tt.body)
+			verifier := NewSiteVerifier(fake.URL, "test-secret")
+
+			err := verifier.Verify(context.Background(), "test-token", "203.0.113.7")
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+
+	t.Run("reports unreachable provider", func(t *testing.T) {
+		verifier := NewSiteVerifier("http://127.0.0.1:1/siteverify", "test-secret")
+
+		err := verifier.Verify(context.Background(), "test-token", "")
+		assert.ErrorIs(t, err, ErrUnavailable)
+	})
+}
@@ -0,0 +1,94 @@
+package certreload
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"faucet-server/internal/logger"
+)
+
+// Reloader serves a TLS key pair from disk and picks up replaced files,
+// e.g. after a certificate renewal, without restarting the server.
+type Reloader struct {
+	certFile string
+	keyFile  string
+
+	cert atomic.Pointer[tls.Certificate]
+
+	// Modification times of the loaded files
+	mu          sync.Mutex
+	certModTime time.Time
+	keyModTime  time.Time
+}
+
+// New loads the key pair from certFile and keyFile.
+func New(certFile, keyFile string) (*Reloader, error) {
+	r := &Reloader{certFile: certFile, keyFile: keyFile}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// GetCertificate returns the current certificate; use it as
+// tls.Config.GetCertificate.
+func (r *Reloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.cert.Load(), nil
+}
+
+// Reload loads the key pair again if either file changed since the last
+// load and reports whether it did. If the new pair can't be loaded, e.g.
+// because only one file has been replaced so far, the current certificate
+// is kept and the next call tries again.
+func (r *Reloader) Reload() (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	certInfo, err := os.Stat(r.certFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read TLS certificate: %w", err)
+	}
+	keyInfo, err := os.Stat(r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read TLS key: %w", err)
+	}
+
+	if r.cert.Load() != nil && certInfo.ModTime().Equal(r.certModTime) && keyInfo.ModTime().Equal(r.keyModTime) {
+		return false, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TLS key pair: %w", err)
+	}
+
+	r.cert.Store(&cert)
+	r.certModTime = certInfo.ModTime()
+	r.keyModTime = keyInfo.ModTime()
+	return true, nil
+}
+
+// Watch checks the files for changes every interval until ctx is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			reloaded, err := r.Reload()
+			if err != nil {
+				logger.Errorf("TLS certificate reload failed, keeping the current certificate: %v", err)
+			} else if reloaded {
+				logger.Infof("Reloaded TLS certificate from %s", r.certFile)
+			}
+		}
+	}
+}
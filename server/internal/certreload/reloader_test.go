@@ -0,0 +1,96 @@
+package certreload
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeKeyPair writes a self-signed certificate for commonName and sets the
+// files' modification time to modTime.
+func writeKeyPair(t *testing.T, certFile, keyFile, commonName string, modTime time.Time) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		DNSNames:     []string{commonName},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func commonName(t *testing.T, r *Reloader) string {
+	t.Helper()
+
+	cert, err := r.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.Subject.CommonName
+}
+
+func TestReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	start := time.Now().Add(-time.Hour)
+
+	writeKeyPair(t, certFile, keyFile, "first.example.com", start)
+
+	r, err := New(certFile, keyFile)
+	require.NoError(t, err)
+	assert.Equal(t, "first.example.com", commonName(t, r))
+
+	t.Run("skips unchanged files", func(t *testing.T) {
+		reloaded, err := r.Reload()
+		require.NoError(t, err)
+		assert.False(t, reloaded)
+	})
+
+	t.Run("picks up replaced files", func(t *testing.T) {
+		writeKeyPair(t, certFile, keyFile, "second.example.com", start.Add(time.Minute))
+
+		reloaded, err := r.Reload()
+		require.NoError(t, err)
+		assert.True(t, reloaded)
+		assert.Equal(t, "second.example.com", commonName(t, r))
+	})
+
+	t.Run("keeps the current certificate when the new pair is broken", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(keyFile, []byte("not a key"), 0o600))
+
+		_, err := r.Reload()
+		require.Error(t, err)
+		assert.Equal(t, "second.example.com", commonName(t, r))
+	})
+
+	t.Run("fails on missing files", func(t *testing.T) {
+		_, err := New(filepath.Join(dir, "missing.crt"), keyFile)
+		require.Error(t, err)
+	})
+}
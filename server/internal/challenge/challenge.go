@@ -0,0 +1,185 @@
+package challenge
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/crypto"
+)
+
+const nonceSize = 16
+
+var (
+	// ErrInvalidChallenge is returned for tokens that are malformed or not signed by the issuer.
+	ErrInvalidChallenge = errors.New("invalid challenge")
+	// ErrExpiredChallenge is returned for tokens past their expiry.
+	ErrExpiredChallenge = errors.New("challenge expired")
+	// ErrInvalidSolution is returned when a solution doesn't meet the challenge difficulty.
+	ErrInvalidSolution = errors.New("invalid proof-of-work solution")
+	// ErrChallengeUsed is returned when spending a challenge a second time.
+	ErrChallengeUsed = errors.New("challenge already used")
+	// ErrTooManyChallenges is returned when a client has spent too many
+	// challenges that haven't expired yet.
+	ErrTooManyChallenges = errors.New("too many spent challenges")
+)
+
+// Challenge is a signed, expiring nonce handed out to clients. The Token is
+// self-contained, so the issuer can verify it later without keeping state.
+type Challenge struct {
+	Token      string
+	Nonce      string
+	Difficulty int
+	ExpiresAt  time.Time
+}
+
+type claims struct {
+	Nonce      string `json:"n"`
+	Difficulty int    `json:"d"`
+	ExpiresAt  int64  `json:"e"`
+}
+
+// Issuer creates and verifies challenge tokens signed with an HMAC secret.
+// Replicas sharing the secret accept each other's tokens. Spent challenges
+// are remembered in memory only, so each replica enforces single use itself.
+// Each client may have at most spentPerClient unexpired challenges spent.
+type Issuer struct {
+	secret []byte
+	ttl    time.Duration
+	spent  *SpentSet
+}
+
+func NewIssuer(secret []byte, ttl time.Duration, spentPerClient int) *Issuer {
+	return &Issuer{
+		secret: secret,
+		ttl:    ttl,
+		spent:  NewSpentSet(spentPerClient),
+	}
+}
+
+// Issue creates a new challenge with the given difficulty in leading zero bits.
+func (i *Issuer) Issue(difficulty int) (*Challenge, error) {
+	nonce := make([]byte, nonceSize)
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	expiresAt := time.Now().Add(i.ttl)
+	payload, err := json.Marshal(claims{
+		Nonce:      hex.EncodeToString(nonce),
+		Difficulty: difficulty,
+		ExpiresAt:  expiresAt.Unix(),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode challenge: %w", err)
+	}
+
+	encodedPayload := base64.RawURLEncoding.EncodeToString(payload)
+	token := encodedPayload + "." + base64.RawURLEncoding.EncodeToString(i.sign(encodedPayload))
+
+	return &Challenge{
+		Token:      token,
+		Nonce:      hex.EncodeToString(nonce),
+		Difficulty: difficulty,
+		ExpiresAt:  time.Unix(expiresAt.Unix(), 0),
+	}, nil
+}
+
+// Verify checks the token's signature and expiry and returns the challenge it encodes.
+func (i *Issuer) Verify(token string) (*Challenge, error) {
+	encodedPayload, encodedSignature, ok := strings.Cut(token, ".")
+	if !ok {
+		return nil, ErrInvalidChallenge
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(encodedSignature)
+	if err != nil || !hmac.Equal(signature, i.sign(encodedPayload)) {
+		return nil, ErrInvalidChallenge
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	var c claims
+	if err := json.Unmarshal(payload, &c); err != nil {
+		return nil, ErrInvalidChallenge
+	}
+
+	expiresAt := time.Unix(c.ExpiresAt, 0)
+	if time.Now().After(expiresAt) {
+		return nil, ErrExpiredChallenge
+	}
+
+	return &Challenge{
+		Token:      token,
+		Nonce:      c.Nonce,
+		Difficulty: c.Difficulty,
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// VerifySolution checks the token and that solution is a valid proof of work
+// for it and the destination address. It does not spend the challenge.
+func (i *Issuer) VerifySolution(token, address, solution string) (*Challenge, error) {
+	challenge, err := i.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if solution == "" || leadingZeroBits(solutionHash(challenge.Nonce, address, solution)) < challenge.Difficulty {
+		return nil, ErrInvalidSolution
+	}
+
+	return challenge, nil
+}
+
+// Spend marks a verified challenge as used by client, so it is accepted only
+// once until it expires. It returns ErrChallengeUsed if it was spent before
+// and ErrTooManyChallenges if client has spent too many recently.
+func (i *Issuer) Spend(c *Challenge, client string) error {
+	return i.spent.Spend(c, client, time.Now())
+}
+
+func (i *Issuer) sign(payload string) []byte {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// Solve searches for a solution to a challenge by counting up from zero.
+// It is meant for clients and tests; the expected work is 2^difficulty hashes.
+func Solve(nonce, address string, difficulty int) string {
+	for counter := uint64(0); ; counter++ {
+		solution := strconv.FormatUint(counter, 10)
+		if leadingZeroBits(solutionHash(nonce, address, solution)) >= difficulty {
+			return solution
+		}
+	}
+}
+
+// solutionHash is keccak256("<nonce>:<lowercase address>:<solution>").
+func solutionHash(nonce, address, solution string) []byte {
+	return crypto.Keccak256([]byte(nonce + ":" + strings.ToLower(address) + ":" + solution))
+}
+
+func leadingZeroBits(hash []byte) int {
+	count := 0
+	for _, b := range hash {
+		if b != 0 {
+			return count + bits.LeadingZeros8(b)
+		}
+		count += 8
+	}
+	return count
+}
@@ -0,0 +1,161 @@
+package challenge
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+func TestIssuer(t *testing.T) {
+	issuer := NewIssuer([]byte("test-secret"), time.Minute, 10)
+
+	t.Run("verifies issued challenge", func(t *testing.T) {
+		issued, err := issuer.Issue(8)
+		require.NoError(t, err)
+		assert.Len(t, issued.Nonce, 2*nonceSize)
+
+		verified, err := issuer.Verify(issued.Token)
+		require.NoError(t, err)
+		assert.Equal(t, issued.Nonce, verified.Nonce)
+		assert.Equal(t, 8, verified.Difficulty)
+		assert.Equal(t, issued.ExpiresAt, verified.ExpiresAt)
+	})
+
+	t.Run("rejects tampered challenge", func(t *testing.T) {
+		issued, err := issuer.Issue(8)
+		require.NoError(t, err)
+
+		lowered, err := NewIssuer([]byte("test-secret"), time.Minute, 10).Issue(0)
+		require.NoError(t, err)
+
+		// Swap in the payload of an easier challenge while keeping the original signature
+		payload, _, _ := strings.Cut(lowered.Token, ".")
+		_, signature, _ := strings.Cut(issued.Token, ".")
+
+		_, err = issuer.Verify(payload + "." + signature)
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+
+		_, err = issuer.Verify("garbage")
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+
+	t.Run("rejects challenge signed with another secret", func(t *testing.T) {
+		issued, err := NewIssuer([]byte("other-secret"), time.Minute, 10).Issue(8)
+		require.NoError(t, err)
+
+		_, err = issuer.Verify(issued.Token)
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+
+	t.Run("rejects expired challenge", func(t *testing.T) {
+		issued, err := NewIssuer([]byte("test-secret"), -time.Second, 10).Issue(8)
+		require.NoError(t, err)
+
+		_, err = issuer.Verify(issued.Token)
+		assert.ErrorIs(t, err, ErrExpiredChallenge)
+	})
+}
+
+func TestVerifySolution(t *testing.T) {
+	issuer := NewIssuer([]byte("test-secret"), time.Minute, 10)
+
+	issued, err := issuer.Issue(10)
+	require.NoError(t, err)
+
+	solution := Solve(issued.Nonce, testAddress, issued.Difficulty)
+
+	t.Run("accepts valid solution", func(t *testing.T) {
+		_, err := issuer.VerifySolution(issued.Token, testAddress, solution)
+		assert.NoError(t, err)
+
+		// Address comparison is case-insensitive
+		_, err = issuer.VerifySolution(issued.Token, strings.ToLower(testAddress), solution)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects solution for another address", func(t *testing.T) {
+		other := "0x9fc51BEE23Fb53569c46CcF013400f0E19524bd2"
+		if leadingZeroBits(solutionHash(issued.Nonce, other, solution)) >= issued.Difficulty {
+			t.Skip("solution happens to be valid for the other address too")
+		}
+
+		_, err := issuer.VerifySolution(issued.Token, other, solution)
+		assert.ErrorIs(t, err, ErrInvalidSolution)
+	})
+
+	t.Run("rejects empty solution", func(t *testing.T) {
+		_, err := issuer.VerifySolution(issued.Token, testAddress, "")
+		assert.ErrorIs(t, err, ErrInvalidSolution)
+	})
+}
+
+func TestLeadingZeroBits(t *testing.T) {
+	assert.Equal(t, 0, leadingZeroBits([]byte{0x80, 0x00}))
+	assert.Equal(t, 7, leadingZeroBits([]byte{0x01, 0xff}))
+	assert.Equal(t, 12, leadingZeroBits([]byte{0x00, 0x08}))
+	assert.Equal(t, 16, leadingZeroBits([]byte{0x00, 0x00}))
+}
+
+func TestAdjustDifficulty(t *testing.T) {
+	tests := []struct {
+		name  string
+		count int
+		want  int
+	}{
+		{"below target", 50, 16},
+		{"at target", 100, 16},
+		{"above target", 101, 17},
+		{"double target", 200, 17},
+		{"above double target", 201, 18},
+		{"far above target is capped", 100000, 20},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, AdjustDifficulty(16, 20, tt.count, 100))
+		})
+	}
+
+	assert.Equal(t, 16, AdjustDifficulty(16, 20, 1000, 0), "zero target disables adjustment")
+}
+
+func TestRateTracker(t *testing.T) {
+	tracker := NewRateTracker(time.Minute)
+	start := time.Now()
+
+	tracker.Record(start)
+	tracker.Record(start.Add(30 * time.Second))
+	tracker.Record(start.Add(50 * time.Second))
+
+	assert.Equal(t, 3, tracker.Count(start.Add(55*time.Second)))
+	assert.Equal(t, 2, tracker.Count(start.Add(61*time.Second)))
+	assert.Equal(t, 0, tracker.Count(start.Add(2*time.Minute)))
+}
+
+func TestSpentSet(t *testing.T) {
+	spent := NewSpentSet(2)
+	now := time.Now()
+	first := &Challenge{Nonce: "a", ExpiresAt: now.Add(time.Minute)}
+	second := &Challenge{Nonce: "b", ExpiresAt: now.Add(2 * time.Minute)}
+
+	require.NoError(t, spent.Spend(first, "client", now))
+	assert.ErrorIs(t, spent.Spend(first, "client", now), ErrChallengeUsed)
+	assert.ErrorIs(t, spent.Spend(first, "other", now), ErrChallengeUsed)
+
+	require.NoError(t, spent.Spend(second, "client", now))
+	assert.ErrorIs(t, spent.Spend(&Challenge{Nonce: "c", ExpiresAt: now.Add(time.Minute)}, "client", now), ErrTooManyChallenges)
+
+	// Other clients are unaffected by a client at its limit
+	require.NoError(t, spent.Spend(&Challenge{Nonce: "d", ExpiresAt: now.Add(time.Minute)}, "other", now))
+
+	// Expired nonces are forgotten, making room for new ones
+	later := now.Add(90 * time.Second)
+	require.NoError(t, spent.Spend(&Challenge{Nonce: "c", ExpiresAt: later.Add(time.Minute)}, "client", later))
+	assert.Equal(t, 2, spent.Len())
+	assert.ErrorIs(t, spent.Spend(second, "client", later), ErrChallengeUsed)
+}
@@ -0,0 +1,62 @@
+package challenge
+
+import (
+	"sync"
+	"time"
+)
+
+// RateTracker counts events within a sliding time window.
+type RateTracker struct {
+	window time.Duration
+
+	mu     sync.Mutex
+	events []time.Time
+}
+
+func NewRateTracker(window time.Duration) *RateTracker {
+	return &RateTracker{window: window}
+}
+
+// Record registers an event at now.
+func (r *RateTracker) Record(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(now)
+	r.events = append(r.events, now)
+}
+
+// Count returns the number of events within the window ending at now.
+func (r *RateTracker) Count(now time.Time) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.prune(now)
+	return len(r.events)
+}
+
+// prune drops events older than the window. The caller must hold r.mu.
+func (r *RateTracker) prune(now time.Time) {
+	cutoff := now.Add(-r.window)
+	drop := 0
+	for drop < len(r.events) && r.events[drop].Before(cutoff) {
+		drop++
+	}
+	r.events = r.events[drop:]
+}
+
+// AdjustDifficulty raises the base difficulty by one bit, doubling the
+// expected work, for every doubling of count above target, capped at max.
+func AdjustDifficulty(base, max, count, target int) int {
+	difficulty := base
+	if target > 0 {
+		for threshold := target; count > threshold && difficulty < max; threshold *= 2 {
+			difficulty++
+		}
+	}
+
+	if difficulty > max {
+		return max
+	}
+	return difficulty
+}
@@ -0,0 +1,94 @@
+package challenge
+
+import (
+	"container/heap"
+	"sync"
+	"time"
+)
+
+// SpentSet remembers spent challenge nonces until their challenge expires, so
+// a challenge can't be redeemed twice. Each client may hold at most
+// perClient unexpired nonces; a client over the limit is refused without
+// forgetting its nonces or affecting other clients.
+type SpentSet struct {
+	perClient int
+
+	mu      sync.Mutex
+	nonces  map[string]struct{}
+	clients map[string]int
+	expiry  expiryHeap
+}
+
+func NewSpentSet(perClient int) *SpentSet {
+	return &SpentSet{
+		perClient: perClient,
+		nonces:    make(map[string]struct{}),
+		clients:   make(map[string]int),
+	}
+}
+
+// Spend records the challenge's nonce as spent by client. It returns
+// ErrChallengeUsed if the nonce was spent before and ErrTooManyChallenges if
+// client already holds perClient unexpired nonces.
+func (s *SpentSet) Spend(c *Challenge, client string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.prune(now)
+
+	if _, ok := s.nonces[c.Nonce]; ok {
+		return ErrChallengeUsed
+	}
+	if s.clients[client] >= s.perClient {
+		return ErrTooManyChallenges
+	}
+
+	s.nonces[c.Nonce] = struct{}{}
+	s.clients[client]++
+	heap.Push(&s.expiry, spentNonce{nonce: c.Nonce, client: client, expiresAt: c.ExpiresAt})
+	return nil
+}
+
+// Len returns the number of nonces currently remembered.
+func (s *SpentSet) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.nonces)
+}
+
+// prune forgets nonces whose challenge has expired; Verify rejects those
+// anyway. The caller must hold s.mu.
+func (s *SpentSet) prune(now time.Time) {
+	for len(s.expiry) > 0 && now.After(s.expiry[0].expiresAt) {
+		spent := heap.Pop(&s.expiry).(spentNonce)
+		delete(s.nonces, spent.nonce)
+		if s.clients[spent.client]--; s.clients[spent.client] <= 0 {
+			delete(s.clients, spent.client)
+		}
+	}
+}
+
+type spentNonce struct {
+	nonce     string
+	client    string
+	expiresAt time.Time
+}
+
+// expiryHeap orders spent nonces by expiry, earliest first.
+type expiryHeap []spentNonce
+
+func (h expiryHeap) Len() int           { return len(h) }
+func (h expiryHeap) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h expiryHeap) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+
+func (h *expiryHeap) Push(x any) {
+	*h = append(*h, x.(spentNonce))
+}
+
+func (h *expiryHeap) Pop() any {
+	old := *h
+	last := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return last
+}
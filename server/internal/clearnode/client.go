@@ -3,7 +3,9 @@ package clearnode
 import (
 	"crypto/ecdsa"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -31,10 +33,13 @@ type Client struct {
 	standardTipAmount decimal.Decimal
 	minTransferCount  int
 
-	conn      *websocket.Conn
-	jwtToken  string
-	lastReqID atomic.Uint64
-	mu        sync.RWMutex
+	conn            *websocket.Conn
+	jwtToken        string
+	authenticated   bool
+	connectedAt     time.Time
+	authenticatedAt time.Time
+	lastReqID       atomic.Uint64
+	mu              sync.RWMutex
 
 	// EIP-712 signer for authentication
 	eip712Signer *EIP712Signer
@@ -42,6 +47,10 @@ type Client struct {
 	// Response handling
 	pendingRequests map[uint64]chan *RPCResponse
 	responseMu      sync.RWMutex
+
+	// Result of the last successful GetAssets, for cheap status reporting
+	lastAssets []rpc.Asset
+	cacheMu    sync.RWMutex
 }
 
 type RPCMessage struct {
@@ -51,6 +60,18 @@ type RPCMessage struct {
 	Sig []string      `json:"sig"`
 }
 
+// ConnectionStatus is a snapshot of the Clearnode connection and session state.
+type ConnectionStatus struct {
+	URL               string     `json:"url"`
+	Connected         bool       `json:"connected"`
+	Authenticated     bool       `json:"authenticated"`
+	ConnectedAt       *time.Time `json:"connectedAt,omitempty"`
+	AuthenticatedAt   *time.Time `json:"authenticatedAt,omitempty"`
+	OwnerAddress      string     `json:"ownerAddress"`
+	SessionKeyAddress string     `json:"sessionKeyAddress"`
+	PendingRequests   int        `json:"pendingRequests"`
+}
+
 type RPCResponse struct {
 	RequestID uint64                 `json:"request_id"`
 	Method    string                 `json:"method"`
@@ -110,13 +131,17 @@ func (c *Client) Connect() error {
 
 	conn, _, err := websocket.DefaultDialer.Dial(c.url, nil)
 	if err != nil {
-		return fmt.Errorf("failed to connect to WebSocket: %w", err)
+		return fmt.Errorf("%w: failed to connect to WebSocket: %w", ErrNotConnected, err)
 	}
 
+	c.mu.Lock()
 	c.conn = conn
+	c.authenticated = false
+	c.connectedAt = time.Now()
+	c.mu.Unlock()
 
 	// Start listening for responses
-	go c.listenForResponses()
+	go c.listenForResponses(conn)
 
 	logger.Info("WebSocket connection established")
 	return nil
@@ -200,7 +225,7 @@ func (c *Client) Authenticate() error {
 		c.responseMu.Lock()
 		delete(c.pendingRequests, requestID)
 		c.responseMu.Unlock()
-		return fmt.Errorf("connection is not available for Authentication and request %d", requestID)
+		return fmt.Errorf("%w: connection is not available for Authentication and request %d", ErrNotConnected, requestID)
 	}
 	err = c.conn.WriteJSON(message)
 	c.mu.Unlock()
@@ -209,7 +234,7 @@ func (c *Client) Authenticate() error {
 		c.responseMu.Lock()
 		delete(c.pendingRequests, requestID)
 		c.responseMu.Unlock()
-		return fmt.Errorf("failed to send auth_verify: %w", err)
+		return fmt.Errorf("%w: failed to send auth_verify: %w", ErrNotConnected, err)
 	}
 
 	logger.Debugf("Sent auth_verify with EIP-712 signature. Waiting for response...")
@@ -219,19 +244,22 @@ func (c *Client) Authenticate() error {
 	case verifyResponse := <-responseChan:
 		if verifyResponse.Method == "error" {
 			errorMsg, _ := verifyResponse.Data["error"].(string)
-			return fmt.Errorf("auth_verify error: %s", errorMsg)
+			return fmt.Errorf("%w: auth_verify error: %s", ErrAuthenticationFailed, errorMsg)
 		}
 
 		success, ok := verifyResponse.Data["success"].(bool)
 		if !ok || !success {
-			return fmt.Errorf("authentication failed. Response does not include success: %v", verifyResponse.Data)
+			return fmt.Errorf("%w. Response does not include success: %v", ErrAuthenticationFailed, verifyResponse.Data)
 		}
 
-		jwtToken, ok := verifyResponse.Data["jwt_token"].(string)
-		if ok {
+		c.mu.Lock()
+		if jwtToken, ok := verifyResponse.Data["jwt_token"].(string); ok {
 			c.jwtToken = jwtToken
 			logger.Debug("JWT token received and stored")
 		}
+		c.authenticated = true
+		c.authenticatedAt = time.Now()
+		c.mu.Unlock()
 
 		logger.Info("Authentication successful")
 		return nil
@@ -240,7 +268,7 @@ func (c *Client) Authenticate() error {
 		c.responseMu.Lock()
 		delete(c.pendingRequests, requestID)
 		c.responseMu.Unlock()
-		return fmt.Errorf("auth_verify timeout")
+		return fmt.Errorf("auth_verify %w", ErrTimeout)
 	}
 }
 
@@ -264,6 +292,10 @@ func (c *Client) GetAssets() ([]rpc.Asset, error) {
 		return nil, fmt.Errorf("failed to parse assets: %w", err)
 	}
 
+	c.cacheMu.Lock()
+	c.lastAssets = assets
+	c.cacheMu.Unlock()
+
 	return assets, nil
 }
 
@@ -290,6 +322,15 @@ func (c *Client) GetFaucetBalance(tokenSymbol string) (*rpc.LedgerBalance, error
 	return balance, nil
 }
 
+// LastAssets returns the assets from the most recent successful GetAssets
+// call without querying Clearnode.
+func (c *Client) LastAssets() []rpc.Asset {
+	c.cacheMu.RLock()
+	defer c.cacheMu.RUnlock()
+
+	return append([]rpc.Asset(nil), c.lastAssets...)
+}
+
 func (c *Client) Transfer(destination, asset string, amount decimal.Decimal) (*rpc.TransferResponse, error) {
 	transferData := rpc.TransferRequest{
 		Destination: destination,
@@ -346,7 +387,7 @@ func (c *Client) sendRequest(method string, params interface{}) (*RPCResponse, e
 		c.responseMu.Lock()
 		delete(c.pendingRequests, requestID)
 		c.responseMu.Unlock()
-		return nil, fmt.Errorf("connection is not available for Transfer and request %d", requestID)
+		return nil, fmt.Errorf("%w: connection is not available for request %d", ErrNotConnected, requestID)
 	}
 	err = c.conn.WriteJSON(message)
 	c.mu.Unlock()
@@ -355,35 +396,49 @@ func (c *Client) sendRequest(method string, params interface{}) (*RPCResponse, e
 		c.responseMu.Lock()
 		delete(c.pendingRequests, requestID)
 		c.responseMu.Unlock()
-		return nil, fmt.Errorf("failed to send message: %w", err)
+		return nil, fmt.Errorf("%w: failed to send message: %w", ErrNotConnected, err)
 	}
 
 	logger.Debugf("Sent request %d: %s", requestID, method)
 
 	select {
 	case response := <-responseChan:
+		if response.Method == "error" {
+			message, _ := response.Data["error"].(string)
+			return nil, &RPCError{Method: method, Message: message}
+		}
 		return response, nil
 	case <-time.After(RESPONSE_TIMEOUT_SEC * time.Second):
 		c.responseMu.Lock()
 		delete(c.pendingRequests, requestID)
 		c.responseMu.Unlock()
-		return nil, fmt.Errorf("request timeout")
+		return nil, ErrTimeout
 	}
 }
 
-func (c *Client) listenForResponses() {
+func (c *Client) listenForResponses(conn *websocket.Conn) {
 	defer func() {
-		if c.conn != nil {
-			c.conn.Close()
+		conn.Close()
+
+		// Only clear the connection if it hasn't already been replaced by a reconnect
+		c.mu.Lock()
+		if c.conn == conn {
 			c.conn = nil
+			c.authenticated = false
 		}
+		c.mu.Unlock()
 	}()
 
 	for {
 		var message RPCMessage
-		err := c.conn.ReadJSON(&message)
+		err := conn.ReadJSON(&message)
 		if err != nil {
-			logger.Errorf("Failed to read WebSocket message: %v", err)
+			// Close and Reconnect close the connection on purpose
+			if errors.Is(err, net.ErrClosed) {
+				logger.Debug("WebSocket connection closed")
+			} else {
+				logger.Errorf("Failed to read WebSocket message: %v", err)
+			}
 			break
 		}
 
@@ -566,11 +621,51 @@ func (c *Client) Close() error {
 	if c.conn != nil {
 		err := c.conn.Close()
 		c.conn = nil
+		c.authenticated = false
 		return err
 	}
 	return nil
 }
 
+// Reconnect drops the current connection, if any, and establishes a new
+// authenticated session.
+func (c *Client) Reconnect() error {
+	logger.Info("Forcing reconnect to Clearnode")
+
+	if err := c.Close(); err != nil {
+		logger.Warnf("Error closing Clearnode connection before reconnect: %v", err)
+	}
+
+	return c.EnsureConnected()
+}
+
+// Status returns a snapshot of the connection and session state.
+func (c *Client) Status() ConnectionStatus {
+	c.mu.RLock()
+	status := ConnectionStatus{
+		URL:               c.url,
+		Connected:         c.conn != nil,
+		Authenticated:     c.conn != nil && c.authenticated,
+		OwnerAddress:      c.ownerAddress.Hex(),
+		SessionKeyAddress: c.signerAddress.Hex(),
+	}
+	if status.Connected {
+		connectedAt := c.connectedAt
+		status.ConnectedAt = &connectedAt
+	}
+	if status.Authenticated {
+		authenticatedAt := c.authenticatedAt
+		status.AuthenticatedAt = &authenticatedAt
+	}
+	c.mu.RUnlock()
+
+	c.responseMu.RLock()
+	status.PendingRequests = len(c.pendingRequests)
+	c.responseMu.RUnlock()
+
+	return status
+}
+
 // IsConnected checks if the WebSocket connection is active
 func (c *Client) IsConnected() bool {
 	c.mu.RLock()
@@ -614,7 +709,7 @@ func (c *Client) ValidateTokenSupport(tokenSymbol string) error {
 		}
 	}
 
-	return fmt.Errorf("token '%s' is not supported by Clearnode", tokenSymbol)
+	return fmt.Errorf("%w: %s", ErrTokenNotSupported, tokenSymbol)
 }
 
 func (c *Client) ValidateFaucetBalance(tokenSymbol string, standardTipAmount decimal.Decimal, minTransferCount int) error {
@@ -628,8 +723,8 @@ func (c *Client) ValidateFaucetBalance(tokenSymbol string, standardTipAmount dec
 	minRequiredBalance := standardTipAmount.Mul(decimal.NewFromInt(int64(minTransferCount)))
 
 	if balance.Amount.LessThan(minRequiredBalance) {
-		return fmt.Errorf("insufficient %s balance: %s (required: %s for %d transfers)",
-			tokenSymbol, balance.Amount.String(), minRequiredBalance.String(), minTransferCount)
+		return fmt.Errorf("%w: %s %s (required: %s for %d transfers)",
+			ErrInsufficientBalance, balance.Amount.String(), tokenSymbol, minRequiredBalance.String(), minTransferCount)
 	}
 
 	logger.Infof("✓ Sufficient %s balance: %s",
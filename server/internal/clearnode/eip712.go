@@ -34,6 +34,21 @@ func (s *EIP712Signer) SignChallenge(
 	application common.Address,
 	expiresAt uint64,
 ) ([]byte, error) {
+	typedData := AuthPolicyTypedData(challengeToken, s.address, sessionKey, appName, allowances, scope, expiresAt)
+	return s.SignTypedData(typedData)
+}
+
+// AuthPolicyTypedData builds the EIP-712 policy the wallet signs in response
+// to an auth_request challenge. The application name is the domain name.
+func AuthPolicyTypedData(
+	challengeToken string,
+	wallet common.Address,
+	sessionKey common.Address,
+	appName string,
+	allowances []rpc.Allowance,
+	scope string,
+	expiresAt uint64,
+) apitypes.TypedData {
 	// Convert allowances to the format expected by TypedData
 	convertedAllowances := make([]map[string]interface{}, len(allowances))
 	for i, allowance := range allowances {
@@ -44,7 +59,7 @@ func (s *EIP712Signer) SignChallenge(
 	}
 
 	// Create the EIP-712 typed data structure
-	typedData := apitypes.TypedData{
+	return apitypes.TypedData{
 		Types: apitypes.Types{
 			"EIP712Domain": {
 				{Name: "name", Type: "string"},
@@ -69,13 +84,16 @@ func (s *EIP712Signer) SignChallenge(
 		Message: map[string]interface{}{
 			"challenge":   challengeToken,
 			"scope":       scope,
-			"wallet":      s.address.Hex(),
+			"wallet":      wallet.Hex(),
 			"session_key": sessionKey.Hex(),
 			"expires_at":  new(big.Int).SetUint64(expiresAt),
 			"allowances":  convertedAllowances,
 		},
 	}
+}
 
+// SignTypedData signs arbitrary EIP-712 typed data with the signer's key.
+func (s *EIP712Signer) SignTypedData(typedData apitypes.TypedData) ([]byte, error) {
 	typedDataHash, _, err := apitypes.TypedDataAndHash(typedData)
 	if err != nil {
 		return nil, fmt.Errorf("failed to hash typed data: %w", err)
@@ -95,6 +113,63 @@ func (s *EIP712Signer) SignChallenge(
 	return signature, nil
 }
 
+// FaucetClaimTypedData builds the EIP-712 message a user signs to prove
+// control of the address they request tokens for.
+func FaucetClaimTypedData(address common.Address, nonce string) apitypes.TypedData {
+	return apitypes.TypedData{
+		Types: apitypes.Types{
+			"EIP712Domain": {
+				{Name: "name", Type: "string"},
+				{Name: "version", Type: "string"},
+			},
+			"FaucetClaim": {
+				{Name: "address", Type: "address"},
+				{Name: "nonce", Type: "string"},
+			},
+		},
+		PrimaryType: "FaucetClaim",
+		Domain: apitypes.TypedDataDomain{
+			Name:    "Nitrolite Faucet",
+			Version: "1",
+		},
+		Message: map[string]interface{}{
+			"address": address.Hex(),
+			"nonce":   nonce,
+		},
+	}
+}
+
+// RecoverTypedDataSigner returns the address whose key produced signature over typedData.
+func RecoverTypedDataSigner(typedData apitypes.TypedData, signature []byte) (common.Address, error) {
+	typedDataHash, _, err := apitypes.TypedDataAndHash(typedData)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to hash typed data: %w", err)
+	}
+
+	return RecoverSigner(typedDataHash, signature)
+}
+
+// RecoverSigner returns the address whose key produced signature over hash.
+// Both 0/1 and 27/28 recovery IDs are accepted.
+func RecoverSigner(hash []byte, signature []byte) (common.Address, error) {
+	if len(signature) != crypto.SignatureLength {
+		return common.Address{}, fmt.Errorf("invalid signature length %d", len(signature))
+	}
+
+	sig := make([]byte, crypto.SignatureLength)
+	copy(sig, signature)
+	if sig[64] >= 27 {
+		sig[64] -= 27
+	}
+
+	publicKey, err := crypto.SigToPub(hash, sig)
+	if err != nil {
+		return common.Address{}, fmt.Errorf("failed to recover public key: %w", err)
+	}
+
+	return crypto.PubkeyToAddress(*publicKey), nil
+}
+
 func (s *EIP712Signer) GetAddress() common.Address {
 	return s.address
 }
@@ -80,3 +80,37 @@ func TestEIP712Signer_GetAddress(t *testing.T) {
 		t.Errorf("Address mismatch: expected %s, got %s", expectedAddress.Hex(), actualAddress.Hex())
 	}
 }
+
+func TestRecoverTypedDataSigner(t *testing.T) {
+	privateKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("Failed to generate private key: %v", err)
+	}
+
+	signer := NewEIP712Signer(privateKey)
+	typedData := FaucetClaimTypedData(signer.GetAddress(), "test-nonce")
+
+	signature, err := signer.SignTypedData(typedData)
+	if err != nil {
+		t.Fatalf("Failed to sign typed data: %v", err)
+	}
+
+	recovered, err := RecoverTypedDataSigner(typedData, signature)
+	if err != nil {
+		t.Fatalf("Failed to recover signer: %v", err)
+	}
+
+	if recovered != signer.GetAddress() {
+		t.Errorf("Recovered address mismatch: expected %s, got %s", signer.GetAddress().Hex(), recovered.Hex())
+	}
+
+	// A signature over a different nonce must not recover to the same address
+	recovered, err = RecoverTypedDataSigner(FaucetClaimTypedData(signer.GetAddress(), "other-nonce"), signature)
+	if err == nil && recovered == signer.GetAddress() {
+		t.Errorf("Signature unexpectedly valid for a different nonce")
+	}
+
+	if _, err := RecoverTypedDataSigner(typedData, signature[:64]); err == nil {
+		t.Errorf("Expected error for truncated signature")
+	}
+}
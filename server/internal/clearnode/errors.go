@@ -0,0 +1,59 @@
+package clearnode
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Sentinel errors returned (wrapped) by Client so callers can classify
+// failures with errors.Is instead of matching messages.
+var (
+	// ErrNotConnected means the WebSocket connection is down or could not be used.
+	ErrNotConnected = errors.New("not connected to Clearnode")
+	// ErrAuthenticationFailed means Clearnode rejected the authentication flow.
+	ErrAuthenticationFailed = errors.New("authentication failed")
+	// ErrTimeout means Clearnode did not answer within RESPONSE_TIMEOUT_SEC.
+	ErrTimeout = errors.New("request timeout")
+	// ErrTokenNotSupported means Clearnode does not list the configured token.
+	ErrTokenNotSupported = errors.New("token is not supported by Clearnode")
+	// ErrInsufficientBalance means the faucet cannot cover the requested transfers.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+)
+
+// RPCError is an error response returned by Clearnode for a request.
+type RPCError struct {
+	Method  string
+	Message string
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("%s rejected by Clearnode: %s", e.Method, e.Message)
+}
+
+// Unwrap classifies well-known rejections, so that a transfer refused for
+// lack of funds matches ErrInsufficientBalance.
+func (e *RPCError) Unwrap() error {
+	if insufficientBalanceMessage(e.Message) {
+		return ErrInsufficientBalance
+	}
+	return nil
+}
+
+// insufficientBalanceMessages are the phrases Clearnode uses when an account
+// can't cover a transfer.
+var insufficientBalanceMessages = []string{"insufficient funds", "insufficient balance"}
+
+// insufficientBalanceMessage reports whether an error message from Clearnode
+// rejects a request for lack of funds. Clearnode's error responses carry only
+// a message, no error code, so this is the one place matching their text;
+// extend insufficientBalanceMessages when Clearnode changes its wording.
+func insufficientBalanceMessage(message string) bool {
+	message = strings.ToLower(message)
+	for _, phrase := range insufficientBalanceMessages {
+		if strings.Contains(message, phrase) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,44 @@
+package clearnode
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRPCError(t *testing.T) {
+	err := fmt.Errorf("transfer failed: %w", &RPCError{Method: "transfer", Message: "Insufficient funds for usdc"})
+	assert.ErrorIs(t, err, ErrInsufficientBalance)
+	assert.EqualError(t, err, "transfer failed: transfer rejected by Clearnode: Insufficient funds for usdc")
+
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr))
+	assert.Equal(t, "transfer", rpcErr.Method)
+
+	assert.NotErrorIs(t, &RPCError{Method: "transfer", Message: "invalid destination"}, ErrInsufficientBalance)
+}
+
+func TestInsufficientBalanceMessage(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"insufficient funds: usdc", true},
+		{"insufficient funds: 0x742D35CC6634c0532925a3B8c17D18fBe3b78890 for asset usdc", true},
+		{"Insufficient funds for usdc", true},
+		{"insufficient balance", true},
+		{"invalid destination", false},
+		{"asset usdc is not supported", false},
+		{"insufficient signatures", false},
+		{"", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			assert.Equal(t, tt.want, insufficientBalanceMessage(tt.message))
+			assert.Equal(t, tt.want, errors.Is(&RPCError{Method: "transfer", Message: tt.message}, ErrInsufficientBalance))
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package clearnodetest
+
+import (
+	"testing"
+
+	"github.com/erc7824/nitrolite/clearnode/pkg/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+)
+
+// AssertTransfer checks that the most recent transfer sent amount of asset to
+// destination and returns it. The test fails immediately if there was none.
+func (n *Node) AssertTransfer(t testing.TB, destination common.Address, asset string, amount decimal.Decimal) rpc.LedgerTransaction {
+	t.Helper()
+
+	tx := n.LastTransfer()
+	if tx == nil {
+		t.Fatalf("expected a transfer of %s %s to %s, got none", amount, asset, destination.Hex())
+	}
+
+	if tx.ToAccount != destination.Hex() || tx.Asset != asset || !tx.Amount.Equal(amount) {
+		t.Errorf("expected a transfer of %s %s to %s, got %s %s to %s",
+			amount, asset, destination.Hex(), tx.Amount, tx.Asset, tx.ToAccount)
+	}
+	return *tx
+}
+
+// AssertTransferCount checks how many transfers were booked.
+func (n *Node) AssertTransferCount(t testing.TB, expected int) {
+	t.Helper()
+
+	if count := len(n.Transfers()); count != expected {
+		t.Errorf("expected %d transfers, got %d", expected, count)
+	}
+}
+
+// AssertBalance checks the ledger balance of account in asset.
+func (n *Node) AssertBalance(t testing.TB, account common.Address, asset string, expected decimal.Decimal) {
+	t.Helper()
+
+	if balance := n.Balance(account, asset); !balance.Equal(expected) {
+		t.Errorf("expected %s balance of %s to be %s, got %s", asset, account.Hex(), expected, balance)
+	}
+}
+
+// AssertRequestCount checks how many requests of method were received.
+func (n *Node) AssertRequestCount(t testing.TB, method string, expected int) {
+	t.Helper()
+
+	if count := len(n.Requests(method)); count != expected {
+		t.Errorf("expected %d %s requests, got %d", expected, method, count)
+	}
+}
+
+// AssertSignedBy checks that every request of method was signed by signer.
+func (n *Node) AssertSignedBy(t testing.TB, method string, signer common.Address) {
+	t.Helper()
+
+	for _, req := range n.Requests(method) {
+		if req.Signer != signer {
+			t.Errorf("%s request %d was signed by %s, expected %s", method, req.ID, req.Signer.Hex(), signer.Hex())
+		}
+	}
+}
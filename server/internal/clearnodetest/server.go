@@ -0,0 +1,688 @@
+// Package clearnodetest provides an in-process fake Clearnode for tests.
+//
+// The fake speaks the same WebSocket RPC as Clearnode for the methods the
+// faucet uses: the auth_request/auth_verify flow, get_assets,
+// get_ledger_balances, transfer and get_ledger_transactions. It keeps a real
+// in-memory ledger, verifies the EIP-712 policy signature and the session key
+// signature of every private request, and can be told to misbehave: respond
+// slowly, answer with errors, drop the connection or never reply.
+package clearnodetest
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/erc7824/nitrolite/clearnode/pkg/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/clearnode"
+)
+
+// RPC methods served by the fake.
+const (
+	MethodAuthRequest           = "auth_request"
+	MethodAuthVerify            = "auth_verify"
+	MethodGetAssets             = "get_assets"
+	MethodGetLedgerBalances     = "get_ledger_balances"
+	MethodTransfer              = "transfer"
+	MethodGetLedgerTransactions = "get_ledger_transactions"
+)
+
+// Error messages returned by the fake, modelled on Clearnode's.
+const (
+	ErrMsgAuthRequired       = "authentication required"
+	ErrMsgInvalidSignature   = "invalid signature"
+	ErrMsgInvalidChallenge   = "invalid challenge"
+	ErrMsgUnsupportedAsset   = "unsupported asset"
+	ErrMsgInvalidDestination = "invalid destination"
+	ErrMsgInvalidAmount      = "invalid amount"
+	ErrMsgMethodNotFound     = "method not found"
+)
+
+// DefaultAssets returns the assets a new Server supports.
+func DefaultAssets() []rpc.Asset {
+	return []rpc.Asset{
+		{Token: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", ChainID: 1, Symbol: "usdc", Decimals: 6},
+		{Token: "0x0000000000000000000000000000000000000000", ChainID: 1, Symbol: "eth", Decimals: 18},
+	}
+}
+
+// Fault makes the server misbehave for requests of a method.
+type Fault struct {
+	// Error answers the request with an error response carrying this message.
+	Error string
+	// Drop closes the connection instead of answering.
+	Drop bool
+	// NoReply swallows the request; the client waits until it times out.
+	NoReply bool
+	// Times is the number of requests affected; 0 means until ClearFaults.
+	Times int
+}
+
+// Request is a request received by the server.
+type Request struct {
+	ID     uint64
+	Method string
+	Params json.RawMessage
+	// Signer is the address recovered from the first signature, if any.
+	Signer     common.Address
+	ReceivedAt time.Time
+}
+
+// session is the authentication state of one connection.
+type session struct {
+	wallet     common.Address
+	sessionKey common.Address
+}
+
+// pendingAuth is an auth_request waiting for its auth_verify.
+type pendingAuth struct {
+	wallet      common.Address
+	sessionKey  common.Address
+	application string
+	scope       string
+	expiresAt   uint64
+	allowances  []rpc.Allowance
+}
+
+// conn is one client connection. Writes are serialized by mu.
+type conn struct {
+	ws *websocket.Conn
+	mu sync.Mutex
+
+	sessionMu sync.Mutex
+	session   *session
+}
+
+// Node is the fake Clearnode's state and RPC logic. It serves the WebSocket
+// protocol as an http.Handler; tests normally use it through Server.
+type Node struct {
+	upgrader websocket.Upgrader
+
+	mu              sync.Mutex
+	assets          []rpc.Asset
+	balances        map[common.Address]map[string]decimal.Decimal
+	defaultBalances map[string]decimal.Decimal
+	transactions    []rpc.LedgerTransaction
+	challenges      map[string]pendingAuth
+	faults          map[string]*Fault
+	requests        []Request
+	latency         time.Duration
+	observer        func(Exchange)
+	conns           map[*conn]struct{}
+	nextID          uint
+}
+
+// Server is a Node listening on a local WebSocket URL.
+type Server struct {
+	*Node
+	httpServer *httptest.Server
+}
+
+// Exchange is a request together with the response sent for it.
+type Exchange struct {
+	Request Request
+	// Method is the response method, "error" for error responses and empty
+	// if no response was sent because of a fault.
+	Method string
+	Data   interface{}
+}
+
+// NewNode returns a fake Clearnode supporting DefaultAssets with an empty
+// ledger, ready to be served with an http.Server.
+func NewNode() *Node {
+	return &Node{
+		upgrader: websocket.Upgrader{
+			CheckOrigin: func(r *http.Request) bool { return true },
+		},
+		assets:     DefaultAssets(),
+		balances:   make(map[common.Address]map[string]decimal.Decimal),
+		challenges: make(map[string]pendingAuth),
+		faults:     make(map[string]*Fault),
+		conns:      make(map[*conn]struct{}),
+		nextID:     1,
+	}
+}
+
+// NewServer starts a new Node on a local port. The caller must Close it.
+func NewServer() *Server {
+	node := NewNode()
+	return &Server{Node: node, httpServer: httptest.NewServer(node)}
+}
+
+// URL returns the WebSocket URL to pass to clearnode.NewClient.
+func (s *Server) URL() string {
+	return "ws" + strings.TrimPrefix(s.httpServer.URL, "http")
+}
+
+// Close drops all connections and shuts the server down.
+func (s *Server) Close() {
+	s.DisconnectAll()
+	s.httpServer.Close()
+}
+
+// DisconnectAll closes every client connection, as if Clearnode restarted.
+func (n *Node) DisconnectAll() {
+	n.mu.Lock()
+	conns := make([]*conn, 0, len(n.conns))
+	for c := range n.conns {
+		conns = append(conns, c)
+	}
+	n.mu.Unlock()
+
+	for _, c := range conns {
+		c.ws.Close()
+	}
+}
+
+// SetAssets replaces the supported assets.
+func (n *Node) SetAssets(assets []rpc.Asset) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.assets = append([]rpc.Asset(nil), assets...)
+}
+
+// SetLatency delays every response by d.
+func (n *Node) SetLatency(d time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.latency = d
+}
+
+// SetDefaultBalances funds every wallet with balances when it first
+// authenticates, unless the wallet already has ledger entries.
+func (n *Node) SetDefaultBalances(balances map[string]decimal.Decimal) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.defaultBalances = balances
+}
+
+// Observe calls fn for every request after it was answered. fn must not block.
+func (n *Node) Observe(fn func(Exchange)) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.observer = fn
+}
+
+// InjectFault makes requests of method misbehave as described by fault,
+// replacing any fault set before for the method.
+func (n *Node) InjectFault(method string, fault Fault) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.faults[method] = &fault
+}
+
+// ClearFaults makes all methods behave normally again.
+func (n *Node) ClearFaults() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.faults = make(map[string]*Fault)
+}
+
+// Fund credits amount of asset to account, e.g. the faucet owner address.
+func (n *Node) Fund(account common.Address, asset string, amount decimal.Decimal) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.credit(account, asset, amount)
+}
+
+// Balance returns the ledger balance of account in asset.
+func (n *Node) Balance(account common.Address, asset string) decimal.Decimal {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.balances[account][asset]
+}
+
+// Requests returns the requests received for method, oldest first. An empty
+// method returns all requests.
+func (n *Node) Requests(method string) []Request {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var requests []Request
+	for _, req := range n.requests {
+		if method == "" || req.Method == method {
+			requests = append(requests, req)
+		}
+	}
+	return requests
+}
+
+// Transfers returns the transfers booked on the ledger, oldest first.
+func (n *Node) Transfers() []rpc.LedgerTransaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]rpc.LedgerTransaction(nil), n.transactions...)
+}
+
+// LastTransfer returns the most recent transfer, or nil if there was none.
+func (n *Node) LastTransfer() *rpc.LedgerTransaction {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if len(n.transactions) == 0 {
+		return nil
+	}
+	tx := n.transactions[len(n.transactions)-1]
+	return &tx
+}
+
+func (n *Node) credit(account common.Address, asset string, amount decimal.Decimal) {
+	if n.balances[account] == nil {
+		n.balances[account] = make(map[string]decimal.Decimal)
+	}
+	n.balances[account][asset] = n.balances[account][asset].Add(amount)
+}
+
+// ServeHTTP upgrades the request to a WebSocket connection and serves RPC
+// requests on it until the client disconnects.
+func (n *Node) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := n.upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+
+	c := &conn{ws: ws}
+	n.mu.Lock()
+	n.conns[c] = struct{}{}
+	n.mu.Unlock()
+
+	defer func() {
+		n.mu.Lock()
+		delete(n.conns, c)
+		n.mu.Unlock()
+		ws.Close()
+	}()
+
+	for {
+		var message struct {
+			Req json.RawMessage `json:"req"`
+			Sig []string        `json:"sig"`
+		}
+		if err := ws.ReadJSON(&message); err != nil {
+			return
+		}
+
+		// Requests are handled concurrently, like Clearnode does, so that
+		// latency applies per request rather than per connection
+		go n.handleRequest(c, message.Req, message.Sig)
+	}
+}
+
+func (n *Node) handleRequest(c *conn, raw json.RawMessage, sigs []string) {
+	var fields []json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil || len(fields) < 4 {
+		return
+	}
+
+	var req Request
+	var timestamp uint64
+	if json.Unmarshal(fields[0], &req.ID) != nil ||
+		json.Unmarshal(fields[1], &req.Method) != nil ||
+		json.Unmarshal(fields[3], &timestamp) != nil {
+		return
+	}
+	req.Params = fields[2]
+	req.ReceivedAt = time.Now()
+
+	// Requests are signed over the Keccak-256 hash of the raw req array;
+	// auth_verify carries the EIP-712 policy signature instead
+	var signature []byte
+	if len(sigs) > 0 {
+		signature, _ = hexutil.Decode(sigs[0])
+		if req.Method != MethodAuthVerify {
+			if signer, err := clearnode.RecoverSigner(crypto.Keccak256(raw), signature); err == nil {
+				req.Signer = signer
+			}
+		}
+	}
+
+	n.mu.Lock()
+	n.requests = append(n.requests, req)
+	fault := n.takeFault(req.Method)
+	latency := n.latency
+	n.mu.Unlock()
+
+	if latency > 0 {
+		time.Sleep(latency)
+	}
+
+	var method string
+	var data interface{}
+	switch {
+	case fault != nil && fault.Drop:
+		c.ws.Close()
+	case fault != nil && fault.NoReply:
+	case fault != nil && fault.Error != "":
+		method, data = "error", map[string]string{"error": fault.Error}
+	default:
+		var err error
+		if method, data, err = n.dispatch(c, req, signature); err != nil {
+			method, data = "error", map[string]string{"error": err.Error()}
+		}
+	}
+
+	if method != "" {
+		n.reply(c, req.ID, method, data, timestamp)
+	}
+
+	n.mu.Lock()
+	observer := n.observer
+	n.mu.Unlock()
+	if observer != nil {
+		observer(Exchange{Request: req, Method: method, Data: data})
+	}
+}
+
+// takeFault returns the fault for method, if any, consuming one use.
+// n.mu must be held.
+func (n *Node) takeFault(method string) *Fault {
+	fault, ok := n.faults[method]
+	if !ok {
+		return nil
+	}
+
+	if fault.Times > 0 {
+		fault.Times--
+		if fault.Times == 0 {
+			delete(n.faults, method)
+		}
+	}
+	return fault
+}
+
+func (n *Node) reply(c *conn, requestID uint64, method string, data interface{}, timestamp uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.ws.WriteJSON(clearnode.RPCMessage{
+		Res: []interface{}{requestID, method, data, timestamp},
+	})
+}
+
+func (n *Node) dispatch(c *conn, req Request, signature []byte) (string, interface{}, error) {
+	switch req.Method {
+	case MethodAuthRequest:
+		return n.authRequest(req)
+	case MethodAuthVerify:
+		return n.authVerify(c, req, signature)
+	case MethodGetAssets:
+		return n.getAssets()
+	}
+
+	// Everything else needs a session and must be signed with its key
+	c.sessionMu.Lock()
+	sess := c.session
+	c.sessionMu.Unlock()
+	if sess == nil {
+		return "", nil, fmt.Errorf("%s", ErrMsgAuthRequired)
+	}
+	if req.Signer != sess.sessionKey {
+		return "", nil, fmt.Errorf("%s", ErrMsgInvalidSignature)
+	}
+
+	switch req.Method {
+	case MethodGetLedgerBalances:
+		return n.getLedgerBalances(sess, req)
+	case MethodTransfer:
+		return n.transfer(sess, req)
+	case MethodGetLedgerTransactions:
+		return n.getLedgerTransactions(sess, req)
+	default:
+		return "", nil, fmt.Errorf("%s: %s", ErrMsgMethodNotFound, req.Method)
+	}
+}
+
+func (n *Node) authRequest(req Request) (string, interface{}, error) {
+	var params struct {
+		Address     string          `json:"address"`
+		SessionKey  string          `json:"session_key"`
+		Application string          `json:"application"`
+		Scope       string          `json:"scope"`
+		ExpiresAt   uint64          `json:"expires_at"`
+		Allowances  []rpc.Allowance `json:"allowances"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return "", nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if !common.IsHexAddress(params.Address) || !common.IsHexAddress(params.SessionKey) {
+		return "", nil, fmt.Errorf("invalid address or session key")
+	}
+
+	challenge := randomChallenge()
+
+	n.mu.Lock()
+	n.challenges[challenge] = pendingAuth{
+		wallet:      common.HexToAddress(params.Address),
+		sessionKey:  common.HexToAddress(params.SessionKey),
+		application: params.Application,
+		scope:       params.Scope,
+		expiresAt:   params.ExpiresAt,
+		allowances:  params.Allowances,
+	}
+	n.mu.Unlock()
+
+	return "auth_challenge", map[string]string{"challenge_message": challenge}, nil
+}
+
+func (n *Node) authVerify(c *conn, req Request, signature []byte) (string, interface{}, error) {
+	var params struct {
+		Challenge string `json:"challenge"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return "", nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+
+	n.mu.Lock()
+	auth, ok := n.challenges[params.Challenge]
+	delete(n.challenges, params.Challenge)
+	n.mu.Unlock()
+	if !ok {
+		return "", nil, fmt.Errorf("%s", ErrMsgInvalidChallenge)
+	}
+
+	typedData := clearnode.AuthPolicyTypedData(params.Challenge, auth.wallet, auth.sessionKey, auth.application, auth.allowances, auth.scope, auth.expiresAt)
+	signer, err := clearnode.RecoverTypedDataSigner(typedData, signature)
+	if err != nil || signer != auth.wallet {
+		return "", nil, fmt.Errorf("%s", ErrMsgInvalidSignature)
+	}
+
+	c.sessionMu.Lock()
+	c.session = &session{wallet: auth.wallet, sessionKey: auth.sessionKey}
+	c.sessionMu.Unlock()
+
+	n.mu.Lock()
+	if _, known := n.balances[auth.wallet]; !known {
+		for asset, amount := range n.defaultBalances {
+			n.credit(auth.wallet, asset, amount)
+		}
+	}
+	n.mu.Unlock()
+
+	return MethodAuthVerify, map[string]interface{}{
+		"success":     true,
+		"address":     auth.wallet.Hex(),
+		"session_key": auth.sessionKey.Hex(),
+		"jwt_token":   "clearnodetest." + params.Challenge,
+	}, nil
+}
+
+func (n *Node) getAssets() (string, interface{}, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// Always encode a list, never null
+	assets := append([]rpc.Asset{}, n.assets...)
+	return MethodGetAssets, rpc.GetAssetsResponse{Assets: assets}, nil
+}
+
+func (n *Node) getLedgerBalances(sess *session, req Request) (string, interface{}, error) {
+	var params rpc.GetLedgerBalancesRequest
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return "", nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	account := sess.wallet
+	if params.AccountID != "" {
+		account = common.HexToAddress(params.AccountID)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	balances := []rpc.LedgerBalance{}
+	for _, asset := range n.assetSymbols() {
+		if amount, ok := n.balances[account][asset]; ok {
+			balances = append(balances, rpc.LedgerBalance{Asset: asset, Amount: amount})
+		}
+	}
+	return MethodGetLedgerBalances, rpc.GetLedgerBalancesResponse{LedgerBalances: balances}, nil
+}
+
+func (n *Node) transfer(sess *session, req Request) (string, interface{}, error) {
+	var params rpc.TransferRequest
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return "", nil, fmt.Errorf("invalid parameters: %w", err)
+	}
+	if !common.IsHexAddress(params.Destination) {
+		return "", nil, fmt.Errorf("%s: %q", ErrMsgInvalidDestination, params.Destination)
+	}
+	destination := common.HexToAddress(params.Destination)
+	if destination == sess.wallet {
+		return "", nil, fmt.Errorf("%s: cannot transfer to self", ErrMsgInvalidDestination)
+	}
+	if len(params.Allocations) == 0 {
+		return "", nil, fmt.Errorf("%s: no allocations", ErrMsgInvalidAmount)
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// Validate all allocations before booking any, so a transfer is atomic
+	required := make(map[string]decimal.Decimal)
+	for _, allocation := range params.Allocations {
+		if !allocation.Amount.IsPositive() {
+			return "", nil, fmt.Errorf("%s: %s", ErrMsgInvalidAmount, allocation.Amount)
+		}
+		if !n.supports(allocation.AssetSymbol) {
+			return "", nil, fmt.Errorf("%s: %s", ErrMsgUnsupportedAsset, allocation.AssetSymbol)
+		}
+		required[allocation.AssetSymbol] = required[allocation.AssetSymbol].Add(allocation.Amount)
+	}
+	for asset, amount := range required {
+		if n.balances[sess.wallet][asset].LessThan(amount) {
+			return "", nil, fmt.Errorf("insufficient funds: %s", asset)
+		}
+	}
+
+	response := rpc.TransferResponse{}
+	for _, allocation := range params.Allocations {
+		n.credit(sess.wallet, allocation.AssetSymbol, allocation.Amount.Neg())
+		n.credit(destination, allocation.AssetSymbol, allocation.Amount)
+
+		tx := rpc.LedgerTransaction{
+			Id:          n.nextID,
+			TxType:      "transfer",
+			FromAccount: sess.wallet.Hex(),
+			ToAccount:   destination.Hex(),
+			Asset:       allocation.AssetSymbol,
+			Amount:      allocation.Amount,
+			CreatedAt:   time.Now().UTC(),
+		}
+		n.nextID++
+		n.transactions = append(n.transactions, tx)
+		response.Transactions = append(response.Transactions, tx)
+	}
+
+	return MethodTransfer, response, nil
+}
+
+func (n *Node) getLedgerTransactions(sess *session, req Request) (string, interface{}, error) {
+	var params rpc.GetLedgerTransactionsRequest
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return "", nil, fmt.Errorf("invalid parameters: %w", err)
+		}
+	}
+
+	account := sess.wallet.Hex()
+	if params.AccountID != "" {
+		account = common.HexToAddress(params.AccountID).Hex()
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	var matching []rpc.LedgerTransaction
+	for _, tx := range n.transactions {
+		if tx.FromAccount != account && tx.ToAccount != account {
+			continue
+		}
+		if params.Asset != "" && tx.Asset != params.Asset {
+			continue
+		}
+		if params.TxType != "" && tx.TxType != params.TxType {
+			continue
+		}
+		matching = append(matching, tx)
+	}
+
+	// Newest first unless ascending order is requested, like Clearnode
+	if params.Sort == nil || *params.Sort != rpc.SortTypeAscending {
+		for i, j := 0, len(matching)-1; i < j; i, j = i+1, j-1 {
+			matching[i], matching[j] = matching[j], matching[i]
+		}
+	}
+
+	start := min(int(params.Offset), len(matching))
+	end := len(matching)
+	if params.Limit > 0 {
+		end = min(start+int(params.Limit), len(matching))
+	}
+
+	transactions := append([]rpc.LedgerTransaction{}, matching[start:end]...)
+	return MethodGetLedgerTransactions, rpc.GetLedgerTransactionsResponse{LedgerTransactions: transactions}, nil
+}
+
+// supports reports whether asset is listed. n.mu must be held.
+func (n *Node) supports(asset string) bool {
+	for _, a := range n.assets {
+		if a.Symbol == asset {
+			return true
+		}
+	}
+	return false
+}
+
+// assetSymbols returns the distinct symbols of the listed assets in order.
+// n.mu must be held.
+func (n *Node) assetSymbols() []string {
+	var symbols []string
+	seen := make(map[string]bool)
+	for _, a := range n.assets {
+		if !seen[a.Symbol] {
+			seen[a.Symbol] = true
+			symbols = append(symbols, a.Symbol)
+		}
+	}
+	return symbols
+}
+
+func randomChallenge() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,387 @@
+package clearnodetest
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/erc7824/nitrolite/clearnode/pkg/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/gorilla/websocket"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/logger"
+)
+
+const (
+	testOwnerKey  = "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	testSignerKey = "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321"
+)
+
+var recipient = common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890")
+
+// newClient returns a client for fake that is not yet connected.
+func newClient(t *testing.T, fake *Server, ownerKey, signerKey string) *clearnode.Client {
+	t.Helper()
+
+	if logger.Log == nil {
+		require.NoError(t, logger.Initialize("error"))
+	}
+
+	client, err := clearnode.NewClient(ownerKey, signerKey, fake.URL(), "usdc", decimal.NewFromInt(10), 1)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+// setup returns a fake whose faucet account holds 100 usdc and a client
+// authenticated against it.
+func setup(t *testing.T) (*Server, *clearnode.Client) {
+	t.Helper()
+
+	fake := NewServer()
+	t.Cleanup(fake.Close)
+
+	client := newClient(t, fake, testOwnerKey, testSignerKey)
+	fake.Fund(client.GetOwnerAddress(), "usdc", decimal.NewFromInt(100))
+	require.NoError(t, client.Connect())
+	require.NoError(t, client.Authenticate())
+	return fake, client
+}
+
+func TestAuthentication(t *testing.T) {
+	t.Run("verifies the policy signature", func(t *testing.T) {
+		fake, client := setup(t)
+
+		fake.AssertRequestCount(t, MethodAuthRequest, 1)
+		fake.AssertRequestCount(t, MethodAuthVerify, 1)
+		assert.True(t, client.Status().Authenticated)
+	})
+
+	t.Run("rejects private requests before authentication", func(t *testing.T) {
+		fake := NewServer()
+		defer fake.Close()
+
+		client := newClient(t, fake, testOwnerKey, testSignerKey)
+		require.NoError(t, client.Connect())
+
+		_, err := client.GetFaucetBalance("usdc")
+		var rpcErr *clearnode.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Equal(t, ErrMsgAuthRequired, rpcErr.Message)
+
+		assets, err := client.GetAssets()
+		require.NoError(t, err, "get_assets is public")
+		assert.Equal(t, DefaultAssets(), assets)
+	})
+
+	t.Run("rejects a policy signed by another key", func(t *testing.T) {
+		fake := NewServer()
+		defer fake.Close()
+
+		other, err := crypto.GenerateKey()
+		require.NoError(t, err)
+
+		raw := dial(t, fake)
+		_, err = raw.authenticate(t, mustKey(t, testOwnerKey), other, other)
+		assert.EqualError(t, err, ErrMsgInvalidSignature)
+	})
+}
+
+func TestLedger(t *testing.T) {
+	fake, client := setup(t)
+	owner := client.GetOwnerAddress()
+
+	t.Run("transfers move funds", func(t *testing.T) {
+		result, err := client.Transfer(recipient.Hex(), "usdc", decimal.NewFromInt(30))
+		require.NoError(t, err)
+		require.Len(t, result.Transactions, 1)
+
+		tx := fake.AssertTransfer(t, recipient, "usdc", decimal.NewFromInt(30))
+		assert.Equal(t, result.Transactions[0].Id, tx.Id)
+		assert.Equal(t, owner.Hex(), tx.FromAccount)
+		fake.AssertBalance(t, owner, "usdc", decimal.NewFromInt(70))
+		fake.AssertBalance(t, recipient, "usdc", decimal.NewFromInt(30))
+		fake.AssertSignedBy(t, MethodTransfer, client.GetSessionKeyAddress())
+
+		balance, err := client.GetFaucetBalance("usdc")
+		require.NoError(t, err)
+		assert.True(t, balance.Amount.Equal(decimal.NewFromInt(70)))
+	})
+
+	t.Run("rejects overdrafts and unsupported assets", func(t *testing.T) {
+		_, err := client.Transfer(recipient.Hex(), "usdc", decimal.NewFromInt(71))
+		assert.ErrorIs(t, err, clearnode.ErrInsufficientBalance)
+
+		_, err = client.Transfer(recipient.Hex(), "doge", decimal.NewFromInt(1))
+		var rpcErr *clearnode.RPCError
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Contains(t, rpcErr.Message, ErrMsgUnsupportedAsset)
+
+		_, err = client.Transfer("0x1234", "usdc", decimal.NewFromInt(1))
+		require.ErrorAs(t, err, &rpcErr)
+		assert.Contains(t, rpcErr.Message, ErrMsgInvalidDestination)
+
+		fake.AssertTransferCount(t, 1)
+		fake.AssertBalance(t, owner, "usdc", decimal.NewFromInt(70))
+	})
+
+	t.Run("lists transactions newest first", func(t *testing.T) {
+		_, err := client.Transfer(recipient.Hex(), "usdc", decimal.NewFromInt(5))
+		require.NoError(t, err)
+
+		owner := mustKey(t, testOwnerKey)
+		signer := mustKey(t, testSignerKey)
+
+		raw := dial(t, fake)
+		_, err = raw.authenticate(t, owner, signer, owner)
+		require.NoError(t, err)
+
+		var response rpc.GetLedgerTransactionsResponse
+		require.NoError(t, raw.call(t, signer, MethodGetLedgerTransactions, rpc.GetLedgerTransactionsRequest{Asset: "usdc"}, &response))
+		require.Len(t, response.LedgerTransactions, 2)
+		assert.True(t, response.LedgerTransactions[0].Amount.Equal(decimal.NewFromInt(5)))
+		assert.True(t, response.LedgerTransactions[1].Amount.Equal(decimal.NewFromInt(30)))
+
+		ascending := rpc.SortTypeAscending
+		require.NoError(t, raw.call(t, signer, MethodGetLedgerTransactions, rpc.GetLedgerTransactionsRequest{ListOptions: rpc.ListOptions{Limit: 1, Sort: &ascending}}, &response))
+		require.Len(t, response.LedgerTransactions, 1)
+		assert.True(t, response.LedgerTransactions[0].Amount.Equal(decimal.NewFromInt(30)))
+
+		err = raw.call(t, owner, MethodGetLedgerTransactions, rpc.GetLedgerTransactionsRequest{}, &response)
+		assert.EqualError(t, err, ErrMsgInvalidSignature, "private requests must be signed with the session key")
+	})
+}
+
+func TestFaults(t *testing.T) {
+	t.Run("error responses", func(t *testing.T) {
+		fake, client := setup(t)
+		fake.InjectFault(MethodTransfer, Fault{Error: "insufficient funds: usdc", Times: 1})
+
+		_, err := client.Transfer(recipient.Hex(), "usdc", decimal.NewFromInt(1))
+		assert.ErrorIs(t, err, clearnode.ErrInsufficientBalance)
+		fake.AssertTransferCount(t, 0)
+
+		_, err = client.Transfer(recipient.Hex(), "usdc", decimal.NewFromInt(1))
+		require.NoError(t, err, "the fault applied once")
+	})
+
+	t.Run("dropped connection", func(t *testing.T) {
+		fake := NewServer()
+		defer fake.Close()
+		fake.InjectFault(MethodGetAssets, Fault{Drop: true})
+
+		raw := dial(t, fake)
+		require.NoError(t, raw.ws.WriteJSON(map[string]interface{}{
+			"req": []interface{}{1, MethodGetAssets, map[string]string{}, 0},
+		}))
+
+		var response json.RawMessage
+		err := raw.ws.ReadJSON(&response)
+		assert.True(t, websocket.IsUnexpectedCloseError(err) || errors.Is(err, io.ErrUnexpectedEOF), "expected the connection to be closed, got %v", err)
+
+		fake.ClearFaults()
+		raw = dial(t, fake)
+		var assets rpc.GetAssetsResponse
+		require.NoError(t, raw.call(t, mustKey(t, testSignerKey), MethodGetAssets, map[string]string{}, &assets))
+		assert.Len(t, assets.Assets, 2)
+	})
+
+	t.Run("server side disconnect", func(t *testing.T) {
+		fake, client := setup(t)
+
+		fake.DisconnectAll()
+		assert.Eventually(t, func() bool { return !client.IsConnected() }, time.Second, 10*time.Millisecond)
+
+		require.NoError(t, client.EnsureConnected())
+		fake.AssertRequestCount(t, MethodAuthVerify, 2)
+	})
+
+	t.Run("no reply", func(t *testing.T) {
+		fake := NewServer()
+		defer fake.Close()
+		fake.InjectFault(MethodGetAssets, Fault{NoReply: true})
+
+		ws, _, err := websocket.DefaultDialer.Dial(fake.URL(), nil)
+		require.NoError(t, err)
+		defer ws.Close()
+
+		require.NoError(t, ws.WriteJSON(map[string]interface{}{
+			"req": []interface{}{1, MethodGetAssets, map[string]string{}, 0},
+		}))
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+
+		var response json.RawMessage
+		err = ws.ReadJSON(&response)
+		var netErr interface{ Timeout() bool }
+		require.True(t, errors.As(err, &netErr) && netErr.Timeout(), "expected a read timeout, got %v", err)
+		fake.AssertRequestCount(t, MethodGetAssets, 1)
+	})
+
+	t.Run("latency", func(t *testing.T) {
+		fake, client := setup(t)
+		fake.SetLatency(50 * time.Millisecond)
+
+		start := time.Now()
+		_, err := client.GetAssets()
+		require.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), 50*time.Millisecond)
+	})
+
+	t.Run("empty asset list", func(t *testing.T) {
+		fake, client := setup(t)
+		fake.SetAssets(nil)
+
+		err := client.EnsureOperational()
+		assert.ErrorIs(t, err, clearnode.ErrTokenNotSupported)
+
+		assets, err := client.GetAssets()
+		require.NoError(t, err)
+		assert.Empty(t, assets)
+		assert.Equal(t, []rpc.Asset(nil), assets)
+	})
+}
+
+func mustKey(t *testing.T, hexKey string) *ecdsa.PrivateKey {
+	t.Helper()
+	key, err := crypto.HexToECDSA(hexKey)
+	require.NoError(t, err)
+	return key
+}
+
+// rawConn speaks the RPC protocol directly, for requests the faucet client
+// doesn't make.
+type rawConn struct {
+	ws     *websocket.Conn
+	nextID uint64
+}
+
+func dial(t *testing.T, fake *Server) *rawConn {
+	t.Helper()
+
+	ws, _, err := websocket.DefaultDialer.Dial(fake.URL(), nil)
+	require.NoError(t, err)
+	t.Cleanup(func() { ws.Close() })
+	return &rawConn{ws: ws}
+}
+
+// send writes a request signed with sig and decodes the response data into
+// result. Error responses are returned as errors.
+func (r *rawConn) send(t *testing.T, method string, params interface{}, sig func(req []byte) string, result interface{}) error {
+	t.Helper()
+
+	r.nextID++
+	req, err := json.Marshal([]interface{}{r.nextID, method, params, time.Now().UnixMilli()})
+	require.NoError(t, err)
+
+	require.NoError(t, r.ws.WriteJSON(map[string]interface{}{
+		"req": json.RawMessage(req),
+		"sig": []string{sig(req)},
+	}))
+
+	var response struct {
+		Res []json.RawMessage `json:"res"`
+	}
+	require.NoError(t, r.ws.ReadJSON(&response))
+	require.Len(t, response.Res, 4)
+
+	var responseMethod string
+	require.NoError(t, json.Unmarshal(response.Res[1], &responseMethod))
+	if responseMethod == "error" {
+		var data struct {
+			Error string `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(response.Res[2], &data))
+		return errors.New(data.Error)
+	}
+	return json.Unmarshal(response.Res[2], result)
+}
+
+// call sends a request signed with key over the Keccak-256 hash of req.
+func (r *rawConn) call(t *testing.T, key *ecdsa.PrivateKey, method string, params interface{}, result interface{}) error {
+	t.Helper()
+
+	return r.send(t, method, params, func(req []byte) string {
+		signature, err := crypto.Sign(crypto.Keccak256(req), key)
+		require.NoError(t, err)
+		return hexutil.Encode(signature)
+	}, result)
+}
+
+// authenticate runs the auth flow for owner's wallet and sessionKey, signing
+// the policy with policySigner.
+func (r *rawConn) authenticate(t *testing.T, owner, sessionKey, policySigner *ecdsa.PrivateKey) (map[string]interface{}, error) {
+	t.Helper()
+
+	wallet := crypto.PubkeyToAddress(owner.PublicKey)
+	session := crypto.PubkeyToAddress(sessionKey.PublicKey)
+	expiresAt := uint64(time.Now().Add(time.Hour).Unix())
+
+	var challenge map[string]string
+	err := r.call(t, sessionKey, MethodAuthRequest, map[string]interface{}{
+		"address":     wallet.Hex(),
+		"session_key": session.Hex(),
+		"application": "clearnode",
+		"scope":       "app.transfer",
+		"expires_at":  expiresAt,
+		"allowances":  []rpc.Allowance{},
+	}, &challenge)
+	require.NoError(t, err)
+
+	typedData := clearnode.AuthPolicyTypedData(challenge["challenge_message"], wallet, session, "clearnode", nil, "app.transfer", expiresAt)
+	signature, err := clearnode.NewEIP712Signer(policySigner).SignTypedData(typedData)
+	require.NoError(t, err)
+
+	var result map[string]interface{}
+	err = r.send(t, MethodAuthVerify, map[string]string{"challenge": challenge["challenge_message"]}, func([]byte) string {
+		return hexutil.Encode(signature)
+	}, &result)
+	return result, err
+}
+
+func TestDefaultBalancesAndObserver(t *testing.T) {
+	fake := NewServer()
+	defer fake.Close()
+	fake.SetDefaultBalances(map[string]decimal.Decimal{"usdc": decimal.NewFromInt(50)})
+
+	exchanges := make(chan Exchange, 10)
+	fake.Observe(func(exchange Exchange) { exchanges <- exchange })
+
+	client := newClient(t, fake, testOwnerKey, testSignerKey)
+	require.NoError(t, client.Connect())
+	require.NoError(t, client.Authenticate())
+
+	fake.AssertBalance(t, client.GetOwnerAddress(), "usdc", decimal.NewFromInt(50))
+
+	_, err := client.Transfer(recipient.Hex(), "usdc", decimal.NewFromInt(20))
+	require.NoError(t, err)
+
+	// Reauthenticating doesn't top the wallet up again
+	require.NoError(t, client.Reconnect())
+	fake.AssertBalance(t, client.GetOwnerAddress(), "usdc", decimal.NewFromInt(30))
+
+	var methods []string
+	for len(methods) < 5 {
+		select {
+		case exchange := <-exchanges:
+			methods = append(methods, exchange.Request.Method+"->"+exchange.Method)
+		case <-time.After(time.Second):
+			t.Fatalf("observed only %v", methods)
+		}
+	}
+	assert.Equal(t, []string{
+		"auth_request->auth_challenge",
+		"auth_verify->auth_verify",
+		"transfer->transfer",
+		"auth_request->auth_challenge",
+		"auth_verify->auth_verify",
+	}, methods)
+}
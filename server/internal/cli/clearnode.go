@@ -0,0 +1,224 @@
+package cli
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/config"
+)
+
+// newClient creates a Clearnode client for cfg without connecting.
+func newClient(cfg *config.Config) (*clearnode.Client, error) {
+	client, err := clearnode.NewClient(cfg.OwnerPrivateKey, cfg.SignerPrivateKey, cfg.ClearnodeURL, cfg.TokenSymbol, cfg.StandardTipAmountDecimal, cfg.MinTransferCount)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Clearnode client: %w", err)
+	}
+	return client, nil
+}
+
+// connect returns an authenticated Clearnode client. The caller must close it.
+func connect(cfg *config.Config) (*clearnode.Client, error) {
+	client, err := newClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.Connect(); err != nil {
+		return nil, fmt.Errorf("failed to connect to Clearnode: %w", err)
+	}
+
+	if err := client.Authenticate(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to authenticate with Clearnode: %w", err)
+	}
+
+	return client, nil
+}
+
+func newBalanceCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "balance",
+		Short: "Print the faucet's ledger balance of every supported asset",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(*configPath)
+			if err != nil {
+				return err
+			}
+
+			client, err := connect(cfg)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			assets, err := client.GetAssets()
+			if err != nil {
+				return fmt.Errorf("failed to fetch assets: %w", err)
+			}
+
+			// Assets are listed once per chain, balances once per symbol
+			var symbols []string
+			for _, asset := range assets {
+				if !slices.Contains(symbols, asset.Symbol) {
+					symbols = append(symbols, asset.Symbol)
+				}
+			}
+			slices.Sort(symbols)
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "ASSET\tBALANCE")
+			for _, symbol := range symbols {
+				balance, err := client.GetFaucetBalance(symbol)
+				if err != nil {
+					return fmt.Errorf("failed to fetch %s balance: %w", symbol, err)
+				}
+				fmt.Fprintf(w, "%s\t%s\n", symbol, balance.Amount)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newAssetsCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "assets",
+		Short: "Print the assets supported by Clearnode",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(*configPath)
+			if err != nil {
+				return err
+			}
+
+			client, err := connect(cfg)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			assets, err := client.GetAssets()
+			if err != nil {
+				return fmt.Errorf("failed to fetch assets: %w", err)
+			}
+
+			w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+			fmt.Fprintln(w, "SYMBOL\tCHAIN ID\tTOKEN\tDECIMALS")
+			for _, asset := range assets {
+				fmt.Fprintf(w, "%s\t%d\t%s\t%d\n", asset.Symbol, asset.ChainID, asset.Token, asset.Decimals)
+			}
+			return w.Flush()
+		},
+	}
+}
+
+func newSendCommand(configPath *string) *cobra.Command {
+	var asset string
+
+	cmd := &cobra.Command{
+		Use:   "send <address> <amount>",
+		Short: "Transfer tokens from the faucet to an address",
+		Long:  "Transfer tokens from the faucet to an address. Unlike requests to the server, no budgets or address lists apply.",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			destination := strings.TrimSpace(args[0])
+			if !common.IsHexAddress(destination) {
+				return fmt.Errorf("invalid address %q", destination)
+			}
+
+			amount, err := decimal.NewFromString(args[1])
+			if err != nil || !amount.IsPositive() {
+				return fmt.Errorf("amount must be a positive number, got %q", args[1])
+			}
+
+			cfg, err := loadConfig(*configPath)
+			if err != nil {
+				return err
+			}
+			if asset == "" {
+				asset = cfg.TokenSymbol
+			}
+
+			client, err := connect(cfg)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			destination = common.HexToAddress(destination).Hex()
+			result, err := client.Transfer(destination, asset, amount)
+			if err != nil {
+				return fmt.Errorf("transfer failed: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Sent %s %s to %s", amount, asset, destination)
+			if len(result.Transactions) > 0 {
+				fmt.Fprintf(cmd.OutOrStdout(), " (txID: %d)", result.Transactions[0].Id)
+			}
+			fmt.Fprintln(cmd.OutOrStdout())
+			return nil
+		},
+	}
+	cmd.Flags().StringVar(&asset, "asset", "", "Asset to send (defaults to TOKEN_SYMBOL)")
+
+	return cmd
+}
+
+func newCheckCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "check",
+		Short: "Verify that the faucet can connect, authenticate and serve requests",
+		Long:  "Connect and authenticate with Clearnode and run the operational check the server runs on startup: TOKEN_SYMBOL must be supported and the balance must cover MIN_TRANSFER_COUNT tips. Exits non-zero on failure.",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(*configPath)
+			if err != nil {
+				return err
+			}
+
+			client, err := connect(cfg)
+			if err != nil {
+				return err
+			}
+			defer client.Close()
+
+			if err := client.EnsureOperational(); err != nil {
+				return fmt.Errorf("operational check failed: %w", err)
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "OK: connected to %s, %s supported and balance covers %d tips of %s\n",
+				cfg.ClearnodeURL, cfg.TokenSymbol, cfg.MinTransferCount, cfg.StandardTipAmountDecimal)
+			return nil
+		},
+	}
+}
+
+func newKeysCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "keys",
+		Short: "Print the faucet owner and session key addresses",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cfg, err := loadConfig(*configPath)
+			if err != nil {
+				return err
+			}
+
+			client, err := newClient(cfg)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "Owner:       %s\n", client.GetOwnerAddress())
+			fmt.Fprintf(cmd.OutOrStdout(), "Session key: %s\n", client.GetSessionKeyAddress())
+			return nil
+		},
+	}
+}
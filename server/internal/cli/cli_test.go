@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOwnerKey  = "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	testSignerKey = "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321"
+)
+
+// writeConfig writes a config file pointing at an unreachable Clearnode and
+// returns its path.
+func writeConfig(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	path := filepath.Join(dir, "faucet.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+owner_private_key: `+testOwnerKey+`
+signer_private_key: `+testSignerKey+`
+clearnode_url: ws://127.0.0.1:1/ws
+token_symbol: usdc
+standard_tip_amount: "1"
+min_transfer_count: 1
+`), 0o600))
+	return path
+}
+
+func run(t *testing.T, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	cmd := NewRootCommand()
+	cmd.SetOut(&out)
+	cmd.SetErr(&out)
+	cmd.SetArgs(args)
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+func addressOf(t *testing.T, hexKey string) string {
+	t.Helper()
+	key, err := crypto.HexToECDSA(hexKey)
+	require.NoError(t, err)
+	return crypto.PubkeyToAddress(key.PublicKey).Hex()
+}
+
+func TestKeys(t *testing.T) {
+	out, err := run(t, "keys", "--config", writeConfig(t))
+	require.NoError(t, err)
+
+	assert.Contains(t, out, "Owner:       "+addressOf(t, testOwnerKey))
+	assert.Contains(t, out, "Session key: "+addressOf(t, testSignerKey))
+}
+
+func TestSendValidatesArguments(t *testing.T) {
+	path := writeConfig(t)
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"missing amount", []string{address}, "accepts 2 arg(s)"},
+		{"invalid address", []string{"0x1234", "1"}, "invalid address"},
+		{"invalid amount", []string{address, "lots"}, "amount must be a positive number"},
+		{"zero amount", []string{address, "0"}, "amount must be a positive number"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, append([]string{"send", "--config", path}, tt.args...)...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+}
+
+func TestCheckFailsWhenClearnodeIsUnreachable(t *testing.T) {
+	_, err := run(t, "check", "--config", writeConfig(t))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to connect to Clearnode")
+}
+
+func TestConfigErrors(t *testing.T) {
+	_, err := run(t, "balance", "--config", filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load configuration")
+}
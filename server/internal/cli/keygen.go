@@ -0,0 +1,264 @@
+package cli
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/logger"
+)
+
+const (
+	ownerKeyEnv  = "OWNER_PRIVATE_KEY"
+	signerKeyEnv = "SIGNER_PRIVATE_KEY"
+)
+
+// Scrypt parameters of the keystore files; tests use lighter ones
+var (
+	keystoreScryptN = keystore.StandardScryptN
+	keystoreScryptP = keystore.StandardScryptP
+)
+
+type keygenOptions struct {
+	envFile      string
+	keystoreDir  string
+	passwordFile string
+	force        bool
+	verify       bool
+	clearnodeURL string
+}
+
+func newKeygenCommand() *cobra.Command {
+	var opts keygenOptions
+
+	cmd := &cobra.Command{
+		Use:   "keygen",
+		Short: "Generate a new owner and session key pair",
+		Long: `Generate a new owner and session key pair and print the addresses.
+
+The keys are written as OWNER_PRIVATE_KEY and SIGNER_PRIVATE_KEY to --env-file
+and/or as encrypted keystore files to --keystore. With --verify the pair is
+registered with Clearnode (auth_request/auth_verify) to confirm that it works.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runKeygen(cmd, opts)
+		},
+	}
+	cmd.Flags().StringVar(&opts.envFile, "env-file", "", "Write the keys to this .env file, keeping its other settings")
+	cmd.Flags().StringVar(&opts.keystoreDir, "keystore", "", "Write the keys as encrypted keystore files to this directory")
+	cmd.Flags().StringVar(&opts.passwordFile, "password-file", "", "File containing the keystore password (required with --keystore)")
+	cmd.Flags().BoolVar(&opts.force, "force", false, "Replace keys already present in --env-file")
+	cmd.Flags().BoolVar(&opts.verify, "verify", false, "Authenticate with Clearnode using the new keys")
+	cmd.Flags().StringVar(&opts.clearnodeURL, "clearnode-url", "", "Clearnode WebSocket URL for --verify (defaults to $CLEARNODE_URL)")
+
+	return cmd
+}
+
+func runKeygen(cmd *cobra.Command, opts keygenOptions) error {
+	if opts.envFile == "" && opts.keystoreDir == "" {
+		return errors.New("nowhere to write the keys: set --env-file and/or --keystore")
+	}
+
+	var password string
+	if opts.keystoreDir != "" {
+		if opts.passwordFile == "" {
+			return errors.New("--password-file is required with --keystore")
+		}
+		data, err := os.ReadFile(opts.passwordFile)
+		if err != nil {
+			return fmt.Errorf("failed to read password file: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return errors.New("password file is empty")
+		}
+	}
+
+	clearnodeURL := opts.clearnodeURL
+	if clearnodeURL == "" {
+		clearnodeURL = os.Getenv("CLEARNODE_URL")
+	}
+	if opts.verify && clearnodeURL == "" {
+		return errors.New("--verify requires --clearnode-url or CLEARNODE_URL")
+	}
+
+	owner, signer, err := generateKeyPair()
+	if err != nil {
+		return err
+	}
+	ownerHex := hex.EncodeToString(crypto.FromECDSA(owner))
+	signerHex := hex.EncodeToString(crypto.FromECDSA(signer))
+
+	// Verify before writing anything, so a failed check leaves no keys behind
+	// that the operator could mistake for working ones
+	if opts.verify {
+		if err := verifyKeys(ownerHex, signerHex, clearnodeURL); err != nil {
+			return err
+		}
+	}
+
+	out := cmd.OutOrStdout()
+
+	if opts.envFile != "" {
+		if err := writeEnvKeys(opts.envFile, ownerHex, signerHex, opts.force); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "Wrote %s and %s to %s\n", ownerKeyEnv, signerKeyEnv, opts.envFile)
+	}
+
+	if opts.keystoreDir != "" {
+		for _, file := range []struct {
+			role string
+			key  *ecdsa.PrivateKey
+		}{{"owner", owner}, {"session signer", signer}} {
+			path, err := writeKeystoreFile(opts.keystoreDir, file.role, file.key, password)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(out, "Wrote %s keystore file %s\n", file.role, path)
+		}
+	}
+
+	fmt.Fprintf(out, "Owner:       %s (fund this address on Clearnode)\n", crypto.PubkeyToAddress(owner.PublicKey).Hex())
+	fmt.Fprintf(out, "Session key: %s\n", crypto.PubkeyToAddress(signer.PublicKey).Hex())
+	if opts.verify {
+		fmt.Fprintf(out, "Verified: authenticated with %s\n", clearnodeURL)
+	}
+
+	return nil
+}
+
+// generateKeyPair returns two distinct secp256k1 keys.
+func generateKeyPair() (*ecdsa.PrivateKey, *ecdsa.PrivateKey, error) {
+	owner, err := crypto.GenerateKey()
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate owner key: %w", err)
+	}
+
+	for {
+		signer, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate signer key: %w", err)
+		}
+		if !signer.Equal(owner) {
+			return owner, signer, nil
+		}
+	}
+}
+
+// writeKeystoreFile encrypts key into dir as <role>-<address>.json, so that
+// the owner and session signer files can be told apart. go-ethereum reads
+// keystore files under any name. Existing files are never replaced.
+func writeKeystoreFile(dir, role string, key *ecdsa.PrivateKey, password string) (string, error) {
+	address := crypto.PubkeyToAddress(key.PublicKey)
+	data, err := keystore.EncryptKey(&keystore.Key{Id: uuid.New(), Address: address, PrivateKey: key}, password, keystoreScryptN, keystoreScryptP)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt %s key: %w", role, err)
+	}
+
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("failed to create keystore directory: %w", err)
+	}
+
+	path := filepath.Join(dir, strings.ReplaceAll(role, " ", "-")+"-"+address.Hex()+".json")
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to write %s keystore file: %w", role, err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return "", fmt.Errorf("failed to write %s keystore file: %w", role, err)
+	}
+	if err := file.Close(); err != nil {
+		return "", fmt.Errorf("failed to write %s keystore file: %w", role, err)
+	}
+
+	return path, nil
+}
+
+// verifyKeys registers a session for the key pair with Clearnode.
+func verifyKeys(ownerHex, signerHex, clearnodeURL string) error {
+	if err := logger.Initialize("warn"); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	logger.SetOutput(os.Stderr)
+
+	// Token and tip settings only matter for the operational check
+	client, err := clearnode.NewClient(ownerHex, signerHex, clearnodeURL, "", decimal.Zero, 0)
+	if err != nil {
+		return fmt.Errorf("failed to create Clearnode client: %w", err)
+	}
+
+	if err := client.Connect(); err != nil {
+		return fmt.Errorf("failed to connect to Clearnode: %w", err)
+	}
+	defer client.Close()
+
+	if err := client.Authenticate(); err != nil {
+		return fmt.Errorf("failed to authenticate with Clearnode: %w", err)
+	}
+
+	return nil
+}
+
+// writeEnvKeys sets the owner and signer keys in the .env file at path,
+// creating it if needed. Other lines are kept. Existing keys are only
+// replaced with force.
+func writeEnvKeys(path, ownerHex, signerHex string, force bool) error {
+	values := map[string]string{ownerKeyEnv: ownerHex, signerKeyEnv: signerHex}
+
+	var lines []string
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		scanner := bufio.NewScanner(strings.NewReader(string(data)))
+		for scanner.Scan() {
+			lines = append(lines, scanner.Text())
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read %s: %w", path, err)
+	}
+
+	written := make(map[string]bool)
+	for i, line := range lines {
+		name, _, ok := strings.Cut(strings.TrimSpace(line), "=")
+		name = strings.TrimSpace(strings.TrimPrefix(name, "export "))
+		value, known := values[name]
+		if !ok || !known {
+			continue
+		}
+		if !force {
+			return fmt.Errorf("%s already sets %s; use --force to replace it", path, name)
+		}
+		lines[i] = name + "=" + value
+		written[name] = true
+	}
+
+	for _, name := range []string{ownerKeyEnv, signerKeyEnv} {
+		if !written[name] {
+			lines = append(lines, name+"="+values[name])
+		}
+	}
+
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o600); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	// WriteFile keeps the mode of an existing file; keys must not be world-readable
+	if err := os.Chmod(path, 0o600); err != nil {
+		return fmt.Errorf("failed to restrict permissions of %s: %w", path, err)
+	}
+
+	return nil
+}
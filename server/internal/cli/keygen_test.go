@@ -0,0 +1,143 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/accounts/keystore"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnode"
+)
+
+// readEnv returns the variables set in the .env file at path.
+func readEnv(t *testing.T, path string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+
+	values := make(map[string]string)
+	for _, line := range strings.Split(string(data), "\n") {
+		if name, value, ok := strings.Cut(line, "="); ok && !strings.HasPrefix(line, "#") {
+			values[name] = value
+		}
+	}
+	return values
+}
+
+func TestKeygenEnvFile(t *testing.T) {
+	t.Run("writes a usable key pair", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+
+		out, err := run(t, "keygen", "--env-file", path)
+		require.NoError(t, err)
+
+		env := readEnv(t, path)
+		require.Len(t, env, 2)
+		assert.NotEqual(t, env[ownerKeyEnv], env[signerKeyEnv])
+
+		client, err := clearnode.NewClient(env[ownerKeyEnv], env[signerKeyEnv], "ws://localhost/ws", "usdc", decimal.NewFromInt(1), 1)
+		require.NoError(t, err, "the server accepts the generated keys")
+		assert.Contains(t, out, "Owner:       "+client.GetOwnerAddress().Hex())
+		assert.Contains(t, out, "Session key: "+client.GetSessionKeyAddress().Hex())
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	})
+
+	t.Run("keeps other settings and refuses to replace keys", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), ".env")
+		original := "# Faucet\nTOKEN_SYMBOL=usdc\nOWNER_PRIVATE_KEY=old\n"
+		require.NoError(t, os.WriteFile(path, []byte(original), 0o644))
+
+		_, err := run(t, "keygen", "--env-file", path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "use --force")
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, original, string(data), "file is untouched")
+
+		_, err = run(t, "keygen", "--env-file", path, "--force")
+		require.NoError(t, err)
+
+		env := readEnv(t, path)
+		assert.Equal(t, "usdc", env["TOKEN_SYMBOL"])
+		assert.NotEqual(t, "old", env[ownerKeyEnv])
+		assert.Len(t, env[signerKeyEnv], 64)
+
+		data, err = os.ReadFile(path)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "# Faucet\nTOKEN_SYMBOL=usdc\nOWNER_PRIVATE_KEY="), "keys are replaced in place")
+	})
+}
+
+func TestKeygenKeystore(t *testing.T) {
+	scryptN, scryptP := keystoreScryptN, keystoreScryptP
+	keystoreScryptN, keystoreScryptP = keystore.LightScryptN, keystore.LightScryptP
+	t.Cleanup(func() { keystoreScryptN, keystoreScryptP = scryptN, scryptP })
+
+	dir := t.TempDir()
+	passwordFile := filepath.Join(dir, "password")
+	require.NoError(t, os.WriteFile(passwordFile, []byte("secret\n"), 0o600))
+	keystoreDir := filepath.Join(dir, "keys")
+
+	out, err := run(t, "keygen", "--keystore", keystoreDir, "--password-file", passwordFile)
+	require.NoError(t, err)
+
+	files, err := os.ReadDir(keystoreDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	labels := map[string]string{"owner": "Owner:       ", "session-signer": "Session key: "}
+	for _, file := range files {
+		path := filepath.Join(keystoreDir, file.Name())
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		key, err := keystore.DecryptKey(data, "secret")
+		require.NoError(t, err, "password file's trailing newline is not part of the password")
+		address := crypto.PubkeyToAddress(key.PrivateKey.PublicKey).Hex()
+
+		role, ok := strings.CutSuffix(file.Name(), "-"+address+".json")
+		require.True(t, ok, "file %s is named after its address", file.Name())
+		require.Contains(t, labels, role)
+		assert.Contains(t, out, labels[role]+address, "file name matches the printed role")
+		assert.Contains(t, out, "Wrote "+strings.ReplaceAll(role, "-", " ")+" keystore file "+path)
+		delete(labels, role)
+	}
+	assert.Empty(t, labels, "one file per role")
+}
+
+func TestKeygenErrors(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, ".env")
+	t.Setenv("CLEARNODE_URL", "")
+
+	tests := []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"no destination", nil, "nowhere to write the keys"},
+		{"keystore without password", []string{"--keystore", dir}, "--password-file is required"},
+		{"verify without URL", []string{"--env-file", path, "--verify"}, "requires --clearnode-url"},
+		{"verify fails", []string{"--env-file", path, "--verify", "--clearnode-url", "ws://127.0.0.1:1/ws"}, "failed to connect to Clearnode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := run(t, append([]string{"keygen"}, tt.args...)...)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.err)
+		})
+	}
+
+	assert.NoFileExists(t, path, "nothing is written when verification fails")
+}
@@ -0,0 +1,71 @@
+// Package cli implements the faucet-server command line: the server itself
+// and subcommands for operating the faucet's Clearnode account by hand.
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+	"faucet-server/internal/version"
+)
+
+// NewRootCommand builds the command tree. Without a subcommand the server is
+// started, as before subcommands existed.
+func NewRootCommand() *cobra.Command {
+	var configPath string
+
+	root := &cobra.Command{
+		Use:           "faucet-server",
+		Short:         "Nitrolite faucet server dispensing test tokens through Clearnode",
+		Version:       version.Version,
+		Args:          cobra.NoArgs,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(configPath)
+		},
+	}
+	root.PersistentFlags().StringVar(&configPath, "config", "", "Path to a YAML or TOML config file (defaults to $CONFIG_FILE)")
+
+	root.AddCommand(
+		newServeCommand(&configPath),
+		newBalanceCommand(&configPath),
+		newAssetsCommand(&configPath),
+		newSendCommand(&configPath),
+		newCheckCommand(&configPath),
+		newKeysCommand(&configPath),
+		newKeygenCommand(),
+	)
+
+	return root
+}
+
+// Execute runs the command line and returns the process exit code.
+func Execute() int {
+	if err := NewRootCommand().Execute(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// loadConfig loads the configuration and sets up logging for a one-off
+// command. Logs go to stderr so that stdout only carries the command output.
+func loadConfig(configPath string) (*config.Config, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	// Only problems are worth showing next to the command output
+	if err := logger.Initialize("warn"); err != nil {
+		return nil, fmt.Errorf("failed to initialize logger: %w", err)
+	}
+	logger.SetOutput(os.Stderr)
+
+	return cfg, nil
+}
@@ -0,0 +1,234 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+
+	"github.com/ethereum/go-ethereum/ethclient"
+	"github.com/shopspring/decimal"
+	"github.com/spf13/cobra"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+	"faucet-server/internal/onchain"
+	"faucet-server/internal/server"
+	"faucet-server/internal/store"
+)
+
+func newServeCommand(configPath *string) *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Start the faucet server (the default)",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return runServe(*configPath)
+		},
+	}
+}
+
+// runServe runs the server until SIGINT or SIGTERM. SIGHUP reloads the
+// configuration from configPath.
+func runServe(configPath string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("failed to load configuration: %w", err)
+	}
+
+	if err := logger.Initialize(cfg.LogLevel); err != nil {
+		return fmt.Errorf("failed to initialize logger: %w", err)
+	}
+
+	logger.Info("Starting Nitrolite Faucet Server")
+	logger.Infof("Configuration loaded: Server port=%s, Clearnode URL=%s",
+		cfg.ServerPort, cfg.ClearnodeURL)
+
+	client, err := connect(cfg)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := client.Close(); err != nil {
+			logger.Errorf("Error closing Clearnode connection: %v", err)
+		}
+	}()
+
+	logger.Infof("Faucet owner address: %s", client.GetOwnerAddress())
+	logger.Infof("Faucet session key address: %s", client.GetSessionKeyAddress())
+	logger.Info("Successfully connected and authenticated with Clearnode")
+
+	var opts []server.Option
+	var sender *onchain.Sender
+	if cfg.OnchainEnabled() {
+		rpcClient, err := ethclient.Dial(cfg.OnchainRPCURL)
+		if err != nil {
+			return fmt.Errorf("failed to connect to %s: %w", cfg.OnchainRPCURL, err)
+		}
+		defer rpcClient.Close()
+
+		sender, err = newOnchainSender(context.Background(), cfg, rpcClient)
+		if err != nil {
+			return fmt.Errorf("failed to set up on-chain transfers: %w", err)
+		}
+
+		logger.Infof("On-chain sender %s dispensing %s on chain %s",
+			sender.Address(), strings.Join(slices.Sorted(maps.Keys(cfg.OnchainTokens)), ", "), sender.ChainID())
+		opts = append(opts, server.WithOnchainSender(sender))
+	}
+
+	if sender != nil && sender.Handles(cfg.TokenSymbol) {
+		if err := checkOnchainBalance(cfg, sender); err != nil {
+			return fmt.Errorf("operational check failed: %w", err)
+		}
+	} else if err := client.EnsureOperational(); err != nil {
+		return fmt.Errorf("operational check failed: %w", err)
+	}
+
+	st, err := store.Open(cfg.StorePath)
+	if err != nil {
+		return fmt.Errorf("failed to open store: %w", err)
+	}
+	defer func() {
+		if err := st.Flush(); err != nil {
+			logger.Errorf("Failed to write store: %v", err)
+		}
+	}()
+
+	if err := importAddressLists(cfg, st); err != nil {
+		return fmt.Errorf("failed to load address lists: %w", err)
+	}
+
+	httpServer, err := server.NewServer(cfg, server.NewClearnodeBackend(client), st, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create server: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go httpServer.MonitorBalance(ctx)
+	go httpServer.DeliverEvents(ctx)
+
+	// Start returns once ctx is cancelled and open requests have finished
+	served := make(chan error, 1)
+	go func() { served <- httpServer.Start(ctx) }()
+
+	logger.Info("Faucet server is ready to serve requests")
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(quit)
+
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	defer signal.Stop(reload)
+
+	running := true
+	for running {
+		select {
+		case <-reload:
+			logger.Info("Received SIGHUP, reloading configuration")
+			newCfg, err := config.Load(configPath)
+			if err != nil {
+				logger.Errorf("Configuration reload failed, keeping current configuration: %v", err)
+				continue
+			}
+			if err := httpServer.Reload(newCfg); err != nil {
+				logger.Errorf("Configuration reload rejected, keeping current configuration: %v", err)
+				continue
+			}
+			if err := importAddressLists(newCfg, st); err != nil {
+				logger.Errorf("Failed to load address lists: %v", err)
+			}
+		case err := <-served:
+			httpServer.Drain()
+			return fmt.Errorf("failed to start HTTP server: %w", err)
+		case <-quit:
+			running = false
+		}
+	}
+
+	logger.Info("Shutting down server...")
+	cancel()
+	if err := <-served; err != nil {
+		logger.Errorf("HTTP server stopped with an error: %v", err)
+	}
+
+	// Let transfers in flight, including airdrops and those that outlived
+	// the HTTP shutdown timeout, finish and be recorded before the store is
+	// flushed
+	httpServer.Drain()
+
+	logger.Info("Server shutdown complete")
+	return nil
+}
+
+// importAddressLists adds the addresses from the configured allowlist and
+// denylist files to the store. Entries managed via the admin API are kept.
+func importAddressLists(cfg *config.Config, st *store.Store) error {
+	lists := []struct {
+		list store.AddressList
+		path string
+	}{
+		{store.Allowlist, cfg.AllowlistFile},
+		{store.Denylist, cfg.DenylistFile},
+	}
+
+	for _, l := range lists {
+		if l.path == "" {
+			continue
+		}
+
+		addresses, err := store.ReadAddressFile(l.path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s file: %w", l.list, err)
+		}
+
+		added, err := st.ImportAddresses(l.list, addresses, "imported from "+l.path)
+		if err != nil {
+			return fmt.Errorf("failed to import %s: %w", l.list, err)
+		}
+
+		logger.Infof("Loaded %d addresses from %s (%d new)", len(addresses), l.path, added)
+	}
+
+	return nil
+}
+
+// newOnchainSender creates the sender for ONCHAIN_ASSETS on top of backend.
+func newOnchainSender(ctx context.Context, cfg *config.Config, backend onchain.Backend) (*onchain.Sender, error) {
+	opts := onchain.Options{
+		MaxFeePerGas:   cfg.OnchainMaxFeePerGasWei,
+		ReceiptTimeout: cfg.OnchainReceiptTimeout,
+		RPCTimeout:     cfg.OnchainRPCTimeout,
+	}
+	if cfg.OnchainChainID != 0 {
+		opts.ChainID = big.NewInt(cfg.OnchainChainID)
+	}
+
+	return onchain.NewSender(ctx, backend, cfg.OnchainPrivateKey, cfg.OnchainTokens, opts)
+}
+
+// checkOnchainBalance is the startup check for a TOKEN_SYMBOL dispensed
+// on-chain: the sender must afford MIN_TRANSFER_COUNT standard tips.
+func checkOnchainBalance(cfg *config.Config, sender *onchain.Sender) error {
+	balance, err := sender.Balance(context.Background(), cfg.TokenSymbol)
+	if err != nil {
+		return err
+	}
+
+	required := cfg.StandardTipAmountDecimal.Mul(decimal.NewFromInt(int64(cfg.MinTransferCount)))
+	if balance.LessThan(required) {
+		return fmt.Errorf("%w: %s %s (required: %s for %d transfers)",
+			onchain.ErrInsufficientBalance, balance, cfg.TokenSymbol, required, cfg.MinTransferCount)
+	}
+
+	logger.Infof("✓ Sufficient on-chain %s balance: %s", cfg.TokenSymbol, balance)
+	return nil
+}
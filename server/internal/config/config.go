@@ -1,38 +1,162 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
+	"github.com/BurntSushi/toml"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ilyakaznacheev/cleanenv"
+	"github.com/joho/godotenv"
 	"github.com/shopspring/decimal"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v3"
 )
 
+// ConfigFileEnv names the environment variable used to locate the config file
+// when no path is passed to Load explicitly.
+const ConfigFileEnv = "CONFIG_FILE"
+
+const minAdminTokenLength = 16
+
+// maxPowDifficulty keeps solving feasible for legitimate clients
+const maxPowDifficulty = 32
+
 type Config struct {
-	ServerPort string `env:"SERVER_PORT" env-default:"8080" env-description:"HTTP server port"`
+	ServerPort string `yaml:"server_port" toml:"server_port" env:"SERVER_PORT" env-default:"8080" env-description:"HTTP server port"`
+
+	TLSCertFile       string        `yaml:"tls_cert_file" toml:"tls_cert_file" env:"TLS_CERT_FILE" env-description:"PEM certificate (chain) for serving HTTPS (plain HTTP when empty)"`
+	TLSKeyFile        string        `yaml:"tls_key_file" toml:"tls_key_file" env:"TLS_KEY_FILE" env-description:"PEM private key for TLS_CERT_FILE"`
+	TLSReloadInterval time.Duration `yaml:"tls_reload_interval" toml:"tls_reload_interval" env:"TLS_RELOAD_INTERVAL" env-default:"1m" env-description:"How often the certificate and key files are checked for changes"`
+	TLSClientCAFile   string        `yaml:"tls_client_ca_file" toml:"tls_client_ca_file" env:"TLS_CLIENT_CA_FILE" env-description:"PEM CA bundle; when set, admin routes require a client certificate signed by it"`
+
+	TrustedProxies []string `yaml:"trusted_proxies" toml:"trusted_proxies" env:"TRUSTED_PROXIES" env-description:"IPs or CIDR ranges of reverse proxies whose X-Forwarded-For and X-Real-IP headers determine the client IP (headers are ignored when empty)"`
+
+	OwnerPrivateKey   string `yaml:"owner_private_key" toml:"owner_private_key" env:"OWNER_PRIVATE_KEY" env-required:"true" env-description:"Private key for faucet owner wallet (without 0x prefix)"`
+	SignerPrivateKey  string `yaml:"signer_private_key" toml:"signer_private_key" env:"SIGNER_PRIVATE_KEY" env-required:"true" env-description:"Private key for transaction signing (without 0x prefix)"`
+	ClearnodeURL      string `yaml:"clearnode_url" toml:"clearnode_url" env:"CLEARNODE_URL" env-required:"true" env-description:"Clearnode WebSocket URL"`
+	TokenSymbol       string `yaml:"token_symbol" toml:"token_symbol" env:"TOKEN_SYMBOL" env-required:"true" env-description:"Token symbol to distribute (e.g., usdc, weth)"`
+	StandardTipAmount string `yaml:"standard_tip_amount" toml:"standard_tip_amount" env:"STANDARD_TIP_AMOUNT" env-required:"true" env-description:"Default amount to send per request"`
+	MinTransferCount  int    `yaml:"min_transfer_count" toml:"min_transfer_count" env:"MIN_TRANSFER_COUNT" env-required:"true" env-description:"Number of transfers a server should have a balance for to operate"`
+
+	OnchainAssets         map[string]string `yaml:"onchain_assets" toml:"onchain_assets" env:"ONCHAIN_ASSETS" env-description:"Assets sent as on-chain transactions instead of through Clearnode, as symbol:token address or symbol:native (e.g. eth:native,usdc:0xA0b8...)"`
+	OnchainRPCURL         string            `yaml:"onchain_rpc_url" toml:"onchain_rpc_url" env:"ONCHAIN_RPC_URL" env-description:"Ethereum JSON-RPC endpoint used for ONCHAIN_ASSETS"`
+	OnchainPrivateKey     string            `yaml:"onchain_private_key" toml:"onchain_private_key" env:"ONCHAIN_PRIVATE_KEY" env-description:"Private key of the account sending on-chain transfers (without 0x prefix)"`
+	OnchainChainID        int64             `yaml:"onchain_chain_id" toml:"onchain_chain_id" env:"ONCHAIN_CHAIN_ID" env-default:"0" env-description:"Chain ID ONCHAIN_RPC_URL must serve (not checked when 0)"`
+	OnchainMaxFeePerGas   string            `yaml:"onchain_max_fee_per_gas" toml:"onchain_max_fee_per_gas" env:"ONCHAIN_MAX_FEE_PER_GAS" env-description:"Upper bound for the EIP-1559 max fee per gas, in gwei (no limit when empty)"`
+	OnchainReceiptTimeout time.Duration     `yaml:"onchain_receipt_timeout" toml:"onchain_receipt_timeout" env:"ONCHAIN_RECEIPT_TIMEOUT" env-default:"2m" env-description:"How long to wait for an on-chain transfer to be mined before reporting a timeout"`
+	OnchainRPCTimeout     time.Duration     `yaml:"onchain_rpc_timeout" toml:"onchain_rpc_timeout" env:"ONCHAIN_RPC_TIMEOUT" env-default:"10s" env-description:"Timeout of each call to ONCHAIN_RPC_URL, except the wait for a transfer to be mined"`
+
+	HourlyBudget map[string]string `yaml:"hourly_budget" toml:"hourly_budget" env:"HOURLY_BUDGET" env-description:"Maximum total amount dispensed per clock hour, per asset (e.g. usdc:500,weth:1)"`
+	DailyBudget  map[string]string `yaml:"daily_budget" toml:"daily_budget" env:"DAILY_BUDGET" env-description:"Maximum total amount dispensed per UTC day, per asset (e.g. usdc:5000,weth:10)"`
+
+	BalanceCheckInterval    time.Duration `yaml:"balance_check_interval" toml:"balance_check_interval" env:"BALANCE_CHECK_INTERVAL" env-default:"1m" env-description:"How often the balance is checked for low-balance alerts"`
+	BalanceWarningTips      int           `yaml:"balance_warning_tips" toml:"balance_warning_tips" env:"BALANCE_WARNING_TIPS" env-default:"50" env-description:"Send a warning alert when the balance covers at most this many tips"`
+	BalanceCriticalTips     int           `yaml:"balance_critical_tips" toml:"balance_critical_tips" env:"BALANCE_CRITICAL_TIPS" env-default:"10" env-description:"Send a critical alert when the balance covers at most this many tips"`
+	AlertWebhookURLs        []string      `yaml:"alert_webhook_urls" toml:"alert_webhook_urls" env:"ALERT_WEBHOOK_URLS" env-description:"Webhooks receiving low-balance alerts as generic JSON"`
+	AlertSlackWebhookURLs   []string      `yaml:"alert_slack_webhook_urls" toml:"alert_slack_webhook_urls" env:"ALERT_SLACK_WEBHOOK_URLS" env-description:"Slack incoming webhooks receiving low-balance alerts"`
+	AlertDiscordWebhookURLs []string      `yaml:"alert_discord_webhook_urls" toml:"alert_discord_webhook_urls" env:"ALERT_DISCORD_WEBHOOK_URLS" env-description:"Discord webhooks receiving low-balance alerts"`
+
+	EventWebhookURLs           []string      `yaml:"event_webhook_urls" toml:"event_webhook_urls" env:"EVENT_WEBHOOK_URLS" env-description:"Webhooks receiving signed faucet events (request accepted, transfer succeeded/failed, faucet paused)"`
+	EventWebhookSecret         string        `yaml:"event_webhook_secret" toml:"event_webhook_secret" env:"EVENT_WEBHOOK_SECRET" env-description:"HMAC-SHA256 secret signing event webhooks"`
+	EventWebhookMaxAttempts    int           `yaml:"event_webhook_max_attempts" toml:"event_webhook_max_attempts" env:"EVENT_WEBHOOK_MAX_ATTEMPTS" env-default:"10" env-description:"Delivery attempts before an event is dropped"`
+	EventWebhookInitialBackoff time.Duration `yaml:"event_webhook_initial_backoff" toml:"event_webhook_initial_backoff" env:"EVENT_WEBHOOK_INITIAL_BACKOFF" env-default:"5s" env-description:"Delay after the first failed delivery, doubled on every further failure"`
+	EventWebhookMaxBackoff     time.Duration `yaml:"event_webhook_max_backoff" toml:"event_webhook_max_backoff" env:"EVENT_WEBHOOK_MAX_BACKOFF" env-default:"1h" env-description:"Upper bound for the delay between delivery attempts"`
+
+	ActivityFeedSize       int `yaml:"activity_feed_size" toml:"activity_feed_size" env:"ACTIVITY_FEED_SIZE" env-default:"20" env-description:"Recent dispensations kept for GET /recent and GET /events (0 disables both)"`
+	ActivityMaxSubscribers int `yaml:"activity_max_subscribers" toml:"activity_max_subscribers" env:"ACTIVITY_MAX_SUBSCRIBERS" env-default:"1000" env-description:"Concurrent GET /events streams; further ones are answered with 503"`
+
+	CORSAllowedOrigins   []string      `yaml:"cors_allowed_origins" toml:"cors_allowed_origins" env:"CORS_ALLOWED_ORIGINS" env-default:"*" env-description:"Origins allowed to call the API from a browser: exact origins, wildcard subdomains (https://*.example.com) or *"`
+	CORSAllowedMethods   []string      `yaml:"cors_allowed_methods" toml:"cors_allowed_methods" env:"CORS_ALLOWED_METHODS" env-default:"GET,POST,OPTIONS" env-description:"Methods allowed in CORS preflight responses"`
+	CORSAllowedHeaders   []string      `yaml:"cors_allowed_headers" toml:"cors_allowed_headers" env:"CORS_ALLOWED_HEADERS" env-default:"Origin,Content-Type,Accept,Authorization" env-description:"Request headers allowed in CORS preflight responses"`
+	CORSAllowCredentials bool          `yaml:"cors_allow_credentials" toml:"cors_allow_credentials" env:"CORS_ALLOW_CREDENTIALS" env-default:"false" env-description:"Allow browsers to send credentials with cross-origin requests"`
+	CORSMaxAge           time.Duration `yaml:"cors_max_age" toml:"cors_max_age" env:"CORS_MAX_AGE" env-default:"10m" env-description:"How long browsers may cache a preflight response (0 omits the header)"`
+
+	StorePath     string `yaml:"store_path" toml:"store_path" env:"STORE_PATH" env-description:"Path of the JSON file persisting faucet state (kept in memory when empty)"`
+	AllowlistFile string `yaml:"allowlist_file" toml:"allowlist_file" env:"ALLOWLIST_FILE" env-description:"File with addresses to add to the allowlist on startup, one per line"`
+	DenylistFile  string `yaml:"denylist_file" toml:"denylist_file" env:"DENYLIST_FILE" env-description:"File with addresses to add to the denylist on startup, one per line"`
+	AllowlistOnly bool   `yaml:"allowlist_only" toml:"allowlist_only" env:"ALLOWLIST_ONLY" env-default:"false" env-description:"Only serve addresses on the allowlist"`
+
+	CaptchaSecret    string `yaml:"captcha_secret" toml:"captcha_secret" env:"CAPTCHA_SECRET" env-description:"Secret key for CAPTCHA verification (CAPTCHA is disabled when empty)"`
+	CaptchaVerifyURL string `yaml:"captcha_verify_url" toml:"captcha_verify_url" env:"CAPTCHA_VERIFY_URL" env-description:"siteverify endpoint of the CAPTCHA provider (hCaptcha, Turnstile, reCAPTCHA)"`
+
+	ChallengeSecret string        `yaml:"challenge_secret" toml:"challenge_secret" env:"CHALLENGE_SECRET" env-description:"HMAC secret signing challenges from GET /challenge (random per process when empty)"`
+	ChallengeTTL    time.Duration `yaml:"challenge_ttl" toml:"challenge_ttl" env:"CHALLENGE_TTL" env-default:"5m" env-description:"How long an issued challenge stays valid"`
+
+	RequireSignature bool `yaml:"require_signature" toml:"require_signature" env:"REQUIRE_SIGNATURE" env-default:"false" env-description:"Require an EIP-712 or EIP-191 signature over a challenge nonce proving control of the address"`
+
+	PowEnabled       bool          `yaml:"pow_enabled" toml:"pow_enabled" env:"POW_ENABLED" env-default:"false" env-description:"Accept proof-of-work solutions from GET /challenge as a bot deterrent"`
+	PowDifficulty    int           `yaml:"pow_difficulty" toml:"pow_difficulty" env:"POW_DIFFICULTY" env-default:"20" env-description:"Base number of leading zero bits required in a solution"`
+	PowMaxDifficulty int           `yaml:"pow_max_difficulty" toml:"pow_max_difficulty" env:"POW_MAX_DIFFICULTY" env-default:"26" env-description:"Upper bound for the dynamically adjusted difficulty"`
+	PowTargetRate    int           `yaml:"pow_target_rate" toml:"pow_target_rate" env:"POW_TARGET_RATE" env-default:"60" env-description:"Token requests per window above which difficulty increases (0 disables adjustment)"`
+	PowWindow        time.Duration `yaml:"pow_window" toml:"pow_window" env:"POW_WINDOW" env-default:"1m" env-description:"Window over which request volume is measured"`
 
-	OwnerPrivateKey   string `env:"OWNER_PRIVATE_KEY" env-required:"true" env-description:"Private key for faucet owner wallet (without 0x prefix)"`
-	SignerPrivateKey  string `env:"SIGNER_PRIVATE_KEY" env-required:"true" env-description:"Private key for transaction signing (without 0x prefix)"`
-	ClearnodeURL      string `env:"CLEARNODE_URL" env-required:"true" env-description:"Clearnode WebSocket URL"`
-	TokenSymbol       string `env:"TOKEN_SYMBOL" env-required:"true" env-description:"Token symbol to distribute (e.g., usdc, weth)"`
-	StandardTipAmount string `env:"STANDARD_TIP_AMOUNT" env-required:"true" env-description:"Default amount to send per request"`
-	MinTransferCount  int    `env:"MIN_TRANSFER_COUNT" env-required:"true" env-description:"Number of transfers a server should have a balance for to operate"`
+	AdminToken string `yaml:"admin_token" toml:"admin_token" env:"ADMIN_TOKEN" env-description:"Bearer token for the admin API (admin routes are disabled when empty)"`
 
-	LogLevel string `env:"LOG_LEVEL" env-default:"info" env-description:"Logging level (debug, info, warn, error)"`
+	AirdropConcurrency int `yaml:"airdrop_concurrency" toml:"airdrop_concurrency" env:"AIRDROP_CONCURRENCY" env-default:"4" env-description:"Transfers an airdrop runs in parallel"`
+	AirdropMaxRows     int `yaml:"airdrop_max_rows" toml:"airdrop_max_rows" env:"AIRDROP_MAX_ROWS" env-default:"1000" env-description:"Maximum number of addresses per airdrop (0 for no limit)"`
+
+	LogLevel string `yaml:"log_level" toml:"log_level" env:"LOG_LEVEL" env-default:"info" env-description:"Logging level (debug, info, warn, error)"`
 
 	// Parsed decimal amount (set after loading)
-	StandardTipAmountDecimal decimal.Decimal
+	StandardTipAmountDecimal decimal.Decimal `yaml:"-" toml:"-"`
+
+	// Parsed budgets keyed by lowercase asset symbol (set after loading)
+	HourlyBudgetDecimal map[string]decimal.Decimal `yaml:"-" toml:"-"`
+	DailyBudgetDecimal  map[string]decimal.Decimal `yaml:"-" toml:"-"`
+
+	// Parsed ONCHAIN_ASSETS keyed by lowercase asset symbol, the zero address
+	// denoting the native currency (set after loading)
+	OnchainTokens map[string]common.Address `yaml:"-" toml:"-"`
+	// Parsed ONCHAIN_MAX_FEE_PER_GAS in wei, nil when unlimited (set after loading)
+	OnchainMaxFeePerGasWei *big.Int `yaml:"-" toml:"-"`
 }
 
-func Load() (*Config, error) {
+// Load builds the configuration from, in increasing order of precedence,
+// the defaults, an optional YAML/TOML config file, the .env file and the
+// process environment. Values set explicitly in the config file, zeros
+// included, replace the defaults. The config file is taken from path, or from
+// CONFIG_FILE when path is empty.
+func Load(path string) (*Config, error) {
 	var config Config
+	var fromFile map[string]bool
+
+	if path == "" {
+		path = os.Getenv(ConfigFileEnv)
+	}
+
+	if err := loadDotEnv(".env"); err != nil {
+		return nil, fmt.Errorf("failed to load .env file: %w", err)
+	}
 
-	// Try to read from .env file first, then from environment variables
-	if err := cleanenv.ReadConfig(".env", &config); err != nil {
-		// If .env doesn't exist, try to read from environment variables only
-		if err := cleanenv.ReadEnv(&config); err != nil {
-			return nil, fmt.Errorf("failed to load configuration: %w", err)
+	if path != "" {
+		var err error
+		if fromFile, err = readFile(path, &config); err != nil {
+			return nil, fmt.Errorf("failed to load config file %s: %w", path, err)
 		}
 	}
+	file := config
+
+	// ReadEnv also fills in the defaults of all zero fields
+	if err := cleanenv.ReadEnv(&config); err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	restoreFileValues(&config, &file, fromFile)
 
 	if err := config.Validate(); err != nil {
 		return nil, fmt.Errorf("config validation failed: %w", err)
@@ -41,7 +165,166 @@ func Load() (*Config, error) {
 	return &config, nil
 }
 
+// dotEnvKeys records the variables exported from .env by a previous Load,
+// so that a reload picks up edits to the file instead of the stale values.
+var (
+	dotEnvKeys   = map[string]bool{}
+	dotEnvKeysMu sync.Mutex
+)
+
+// loadDotEnv exports the variables from a .env file into the process
+// environment without overriding variables that are already set.
+// A missing file is not an error, a malformed one is.
+func loadDotEnv(path string) error {
+	vars, err := godotenv.Read(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	dotEnvKeysMu.Lock()
+	defer dotEnvKeysMu.Unlock()
+
+	for key := range dotEnvKeys {
+		if _, ok := vars[key]; !ok {
+			os.Unsetenv(key)
+			delete(dotEnvKeys, key)
+		}
+	}
+
+	for key, value := range vars {
+		if _, ok := os.LookupEnv(key); ok && !dotEnvKeys[key] {
+			continue
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("failed to set %s: %w", key, err)
+		}
+		dotEnvKeys[key] = true
+	}
+
+	return nil
+}
+
+// readFile decodes a YAML or TOML config file into cfg, rejecting unknown
+// keys, and returns the names of the fields it sets.
+func readFile(path string, cfg *Config) (map[string]bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var keys []string
+	var tag string
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		var values map[string]yaml.Node
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, err
+		}
+		keys, tag = slices.Collect(maps.Keys(values)), "yaml"
+	case ".toml":
+		meta, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return nil, err
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			keys := make([]string, len(undecoded))
+			for i, key := range undecoded {
+				keys[i] = key.String()
+			}
+			return nil, fmt.Errorf("unknown keys: %s", strings.Join(keys, ", "))
+		}
+
+		for _, key := range meta.Keys() {
+			if len(key) == 1 {
+				keys = append(keys, key[0])
+			}
+		}
+		tag = "toml"
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q (expected .yaml, .yml or .toml)", ext)
+	}
+
+	fields := make(map[string]string)
+	configType := reflect.TypeFor[Config]()
+	for i := range configType.NumField() {
+		field := configType.Field(i)
+		fields[field.Tag.Get(tag)] = field.Name
+	}
+
+	set := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		set[fields[key]] = true
+	}
+	return set, nil
+}
+
+// restoreFileValues undoes the defaults cleanenv applied to fields the config
+// file set to their zero value, such as POW_TARGET_RATE: 0. Fields whose
+// environment variable is set keep the environment's value.
+func restoreFileValues(cfg, file *Config, fromFile map[string]bool) {
+	cfgValue := reflect.ValueOf(cfg).Elem()
+	fileValue := reflect.ValueOf(file).Elem()
+
+	for i := range cfgValue.NumField() {
+		field := cfgValue.Type().Field(i)
+		if !fromFile[field.Name] || envSet(field.Tag.Get("env")) {
+			continue
+		}
+		cfgValue.Field(i).Set(fileValue.Field(i))
+	}
+}
+
+// envSet reports whether any of the comma-separated environment variables is set.
+func envSet(names string) bool {
+	for _, name := range strings.Split(names, ",") {
+		if _, ok := os.LookupEnv(name); ok && name != "" {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *Config) Validate() error {
+	port, err := strconv.Atoi(c.ServerPort)
+	if err != nil || port < 0 || port > 65535 {
+		return fmt.Errorf("SERVER_PORT must be a port number between 0 and 65535, got %q", c.ServerPort)
+	}
+
+	if err := validatePrivateKey(c.OwnerPrivateKey); err != nil {
+		return fmt.Errorf("OWNER_PRIVATE_KEY is invalid: %w", err)
+	}
+
+	if err := validatePrivateKey(c.SignerPrivateKey); err != nil {
+		return fmt.Errorf("SIGNER_PRIVATE_KEY is invalid: %w", err)
+	}
+
+	if strings.TrimPrefix(c.OwnerPrivateKey, "0x") == strings.TrimPrefix(c.SignerPrivateKey, "0x") {
+		return fmt.Errorf("OWNER_PRIVATE_KEY and SIGNER_PRIVATE_KEY must be different")
+	}
+
+	clearnodeURL, err := url.Parse(c.ClearnodeURL)
+	if err != nil {
+		return fmt.Errorf("CLEARNODE_URL must be a valid URL: %w", err)
+	}
+
+	if clearnodeURL.Scheme != "ws" && clearnodeURL.Scheme != "wss" {
+		return fmt.Errorf("CLEARNODE_URL must use the ws:// or wss:// scheme, got %q", c.ClearnodeURL)
+	}
+
+	if clearnodeURL.Host == "" {
+		return fmt.Errorf("CLEARNODE_URL must include a host")
+	}
+
+	if strings.TrimSpace(c.TokenSymbol) == "" {
+		return fmt.Errorf("TOKEN_SYMBOL must not be empty")
+	}
+
 	// Parse the decimal amount
 	amount, err := decimal.NewFromString(c.StandardTipAmount)
 	if err != nil {
@@ -52,8 +335,409 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("STANDARD_TIP_AMOUNT must be a positive number")
 	}
 
-	// Store the parsed decimal
+	if c.MinTransferCount <= 0 {
+		return fmt.Errorf("MIN_TRANSFER_COUNT must be greater than zero")
+	}
+
+	hourlyBudget, err := parseBudget("HOURLY_BUDGET", c.HourlyBudget)
+	if err != nil {
+		return err
+	}
+
+	dailyBudget, err := parseBudget("DAILY_BUDGET", c.DailyBudget)
+	if err != nil {
+		return err
+	}
+
+	onchainTokens, maxFeePerGas, err := c.parseOnchain()
+	if err != nil {
+		return err
+	}
+
+	if err := c.validateAlerts(); err != nil {
+		return err
+	}
+
+	if c.ActivityFeedSize < 0 {
+		return fmt.Errorf("ACTIVITY_FEED_SIZE must not be negative")
+	}
+	if c.ActivityFeedSize > 0 && c.ActivityMaxSubscribers <= 0 {
+		return fmt.Errorf("ACTIVITY_MAX_SUBSCRIBERS must be positive")
+	}
+
+	if err := c.validateEventWebhooks(); err != nil {
+		return err
+	}
+
+	if err := c.validateCORS(); err != nil {
+		return err
+	}
+
+	if err := c.validateTLS(); err != nil {
+		return err
+	}
+
+	for _, proxy := range c.TrustedProxies {
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("TRUSTED_PROXIES must contain IP addresses or CIDR ranges, got %q", proxy)
+		}
+	}
+
+	if c.AdminToken != "" && len(c.AdminToken) < minAdminTokenLength {
+		return fmt.Errorf("ADMIN_TOKEN must be at least %d characters long", minAdminTokenLength)
+	}
+
+	if c.AdminToken != "" && c.AirdropConcurrency < 1 {
+		return fmt.Errorf("AIRDROP_CONCURRENCY must be greater than zero")
+	}
+
+	if c.AirdropMaxRows < 0 {
+		return fmt.Errorf("AIRDROP_MAX_ROWS must not be negative")
+	}
+
+	if c.CaptchaSecret != "" {
+		if !isHTTPURL(c.CaptchaVerifyURL) {
+			return fmt.Errorf("CAPTCHA_VERIFY_URL must be an http:// or https:// URL when CAPTCHA_SECRET is set")
+		}
+	}
+
+	if c.PowEnabled {
+		if c.PowDifficulty < 1 || c.PowDifficulty > maxPowDifficulty {
+			return fmt.Errorf("POW_DIFFICULTY must be between 1 and %d", maxPowDifficulty)
+		}
+
+		if c.PowMaxDifficulty < c.PowDifficulty || c.PowMaxDifficulty > maxPowDifficulty {
+			return fmt.Errorf("POW_MAX_DIFFICULTY must be between POW_DIFFICULTY and %d", maxPowDifficulty)
+		}
+
+		if c.PowTargetRate < 0 {
+			return fmt.Errorf("POW_TARGET_RATE must not be negative")
+		}
+
+		if c.PowWindow <= 0 {
+			return fmt.Errorf("POW_WINDOW must be a positive duration")
+		}
+	}
+
+	if (c.PowEnabled || c.RequireSignature) && c.ChallengeTTL <= 0 {
+		return fmt.Errorf("CHALLENGE_TTL must be a positive duration")
+	}
+
+	if _, err := logrus.ParseLevel(c.LogLevel); err != nil {
+		return fmt.Errorf("LOG_LEVEL is invalid: %w", err)
+	}
+
+	// Store the parsed values
 	c.StandardTipAmountDecimal = amount
+	c.HourlyBudgetDecimal = hourlyBudget
+	c.DailyBudgetDecimal = dailyBudget
+	c.OnchainTokens = onchainTokens
+	c.OnchainMaxFeePerGasWei = maxFeePerGas
+
+	return nil
+}
+
+// CheckReloadable reports whether the running configuration can be replaced
+// by next without a restart. Keys, the Clearnode URL and the listening port
+// are bound at startup and cannot change.
+func (c *Config) CheckReloadable(next *Config) error {
+	var changed []string
+
+	if c.OwnerPrivateKey != next.OwnerPrivateKey {
+		changed = append(changed, "OWNER_PRIVATE_KEY")
+	}
+	if c.SignerPrivateKey != next.SignerPrivateKey {
+		changed = append(changed, "SIGNER_PRIVATE_KEY")
+	}
+	if c.ClearnodeURL != next.ClearnodeURL {
+		changed = append(changed, "CLEARNODE_URL")
+	}
+	if !maps.Equal(c.OnchainAssets, next.OnchainAssets) || c.OnchainRPCURL != next.OnchainRPCURL ||
+		c.OnchainPrivateKey != next.OnchainPrivateKey || c.OnchainChainID != next.OnchainChainID ||
+		c.OnchainMaxFeePerGas != next.OnchainMaxFeePerGas || c.OnchainReceiptTimeout != next.OnchainReceiptTimeout ||
+		c.OnchainRPCTimeout != next.OnchainRPCTimeout {
+		changed = append(changed, "ONCHAIN_*")
+	}
+	if c.ServerPort != next.ServerPort {
+		changed = append(changed, "SERVER_PORT")
+	}
+	if c.TLSCertFile != next.TLSCertFile || c.TLSKeyFile != next.TLSKeyFile ||
+		c.TLSReloadInterval != next.TLSReloadInterval || c.TLSClientCAFile != next.TLSClientCAFile {
+		changed = append(changed, "TLS_*")
+	}
+	if !slices.Equal(c.TrustedProxies, next.TrustedProxies) {
+		changed = append(changed, "TRUSTED_PROXIES")
+	}
+	if c.AdminToken != next.AdminToken {
+		changed = append(changed, "ADMIN_TOKEN")
+	}
+	if c.StorePath != next.StorePath {
+		changed = append(changed, "STORE_PATH")
+	}
+	if c.CaptchaSecret != next.CaptchaSecret || c.CaptchaVerifyURL != next.CaptchaVerifyURL {
+		changed = append(changed, "CAPTCHA_SECRET/CAPTCHA_VERIFY_URL")
+	}
+	if c.ChallengeSecret != next.ChallengeSecret || c.ChallengeTTL != next.ChallengeTTL {
+		changed = append(changed, "CHALLENGE_SECRET/CHALLENGE_TTL")
+	}
+	if c.RequireSignature != next.RequireSignature {
+		changed = append(changed, "REQUIRE_SIGNATURE")
+	}
+	if c.PowEnabled != next.PowEnabled || c.PowWindow != next.PowWindow {
+		changed = append(changed, "POW_ENABLED/POW_WINDOW")
+	}
+	if !slices.Equal(c.AlertWebhookURLs, next.AlertWebhookURLs) ||
+		!slices.Equal(c.AlertSlackWebhookURLs, next.AlertSlackWebhookURLs) ||
+		!slices.Equal(c.AlertDiscordWebhookURLs, next.AlertDiscordWebhookURLs) {
+		changed = append(changed, "ALERT_WEBHOOK_URLS/ALERT_SLACK_WEBHOOK_URLS/ALERT_DISCORD_WEBHOOK_URLS")
+	}
+	if !slices.Equal(c.EventWebhookURLs, next.EventWebhookURLs) || c.EventWebhookSecret != next.EventWebhookSecret ||
+		c.EventWebhookMaxAttempts != next.EventWebhookMaxAttempts ||
+		c.EventWebhookInitialBackoff != next.EventWebhookInitialBackoff || c.EventWebhookMaxBackoff != next.EventWebhookMaxBackoff {
+		changed = append(changed, "EVENT_WEBHOOK_*")
+	}
+	if c.ActivityFeedSize != next.ActivityFeedSize {
+		changed = append(changed, "ACTIVITY_FEED_SIZE")
+	}
+
+	if len(changed) > 0 {
+		return fmt.Errorf("%s cannot be changed without a restart", strings.Join(changed, ", "))
+	}
+
+	return nil
+}
+
+// HasAlertWebhooks reports whether low-balance alerts are delivered anywhere.
+func (c *Config) HasAlertWebhooks() bool {
+	return len(c.AlertWebhookURLs)+len(c.AlertSlackWebhookURLs)+len(c.AlertDiscordWebhookURLs) > 0
+}
+
+func (c *Config) validateAlerts() error {
+	webhooks := []struct {
+		name string
+		urls []string
+	}{
+		{"ALERT_WEBHOOK_URLS", c.AlertWebhookURLs},
+		{"ALERT_SLACK_WEBHOOK_URLS", c.AlertSlackWebhookURLs},
+		{"ALERT_DISCORD_WEBHOOK_URLS", c.AlertDiscordWebhookURLs},
+	}
+	for _, webhook := range webhooks {
+		for _, rawURL := range webhook.urls {
+			if !isHTTPURL(rawURL) {
+				return fmt.Errorf("%s must contain http:// or https:// URLs, got %q", webhook.name, rawURL)
+			}
+		}
+	}
+
+	if !c.HasAlertWebhooks() {
+		return nil
+	}
+
+	if c.BalanceCheckInterval <= 0 {
+		return fmt.Errorf("BALANCE_CHECK_INTERVAL must be a positive duration")
+	}
+
+	if c.BalanceCriticalTips < 0 {
+		return fmt.Errorf("BALANCE_CRITICAL_TIPS must not be negative")
+	}
+
+	if c.BalanceWarningTips <= c.BalanceCriticalTips {
+		return fmt.Errorf("BALANCE_WARNING_TIPS must be greater than BALANCE_CRITICAL_TIPS")
+	}
+
+	return nil
+}
+
+func (c *Config) validateEventWebhooks() error {
+	if len(c.EventWebhookURLs) == 0 {
+		return nil
+	}
+
+	for _, rawURL := range c.EventWebhookURLs {
+		if !isHTTPURL(rawURL) {
+			return fmt.Errorf("EVENT_WEBHOOK_URLS must contain http:// or https:// URLs, got %q", rawURL)
+		}
+	}
+
+	if c.EventWebhookSecret == "" {
+		return fmt.Errorf("EVENT_WEBHOOK_SECRET is required when EVENT_WEBHOOK_URLS is set")
+	}
+
+	if c.EventWebhookMaxAttempts < 1 {
+		return fmt.Errorf("EVENT_WEBHOOK_MAX_ATTEMPTS must be at least 1")
+	}
+
+	if c.EventWebhookInitialBackoff <= 0 {
+		return fmt.Errorf("EVENT_WEBHOOK_INITIAL_BACKOFF must be a positive duration")
+	}
+
+	if c.EventWebhookMaxBackoff < c.EventWebhookInitialBackoff {
+		return fmt.Errorf("EVENT_WEBHOOK_MAX_BACKOFF must not be shorter than EVENT_WEBHOOK_INITIAL_BACKOFF")
+	}
+
+	return nil
+}
+
+func isHTTPURL(rawURL string) bool {
+	parsed, err := url.Parse(rawURL)
+	return err == nil && (parsed.Scheme == "http" || parsed.Scheme == "https") && parsed.Host != ""
+}
+
+// TLSEnabled reports whether the server terminates TLS itself.
+func (c *Config) TLSEnabled() bool {
+	return c.TLSCertFile != ""
+}
+
+func (c *Config) validateTLS() error {
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		return fmt.Errorf("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	if !c.TLSEnabled() {
+		if c.TLSClientCAFile != "" {
+			return fmt.Errorf("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		return nil
+	}
+
+	if c.TLSReloadInterval <= 0 {
+		return fmt.Errorf("TLS_RELOAD_INTERVAL must be a positive duration")
+	}
+
+	if c.TLSClientCAFile != "" && c.AdminToken == "" {
+		return fmt.Errorf("TLS_CLIENT_CA_FILE only protects the admin API, which requires ADMIN_TOKEN")
+	}
+
+	return nil
+}
+
+func (c *Config) validateCORS() error {
+	for _, origin := range c.CORSAllowedOrigins {
+		origin = strings.TrimSpace(origin)
+		if origin == "*" {
+			if c.CORSAllowCredentials {
+				return fmt.Errorf("CORS_ALLOWED_ORIGINS must list explicit origins when CORS_ALLOW_CREDENTIALS is set")
+			}
+			continue
+		}
+
+		parsed, err := url.Parse(origin)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" ||
+			parsed.Path != "" || parsed.RawQuery != "" || parsed.User != nil {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS must contain origins like https://app.example.com, https://*.example.com or *, got %q", origin)
+		}
+
+		if host := strings.TrimPrefix(parsed.Host, "*."); strings.Contains(host, "*") {
+			return fmt.Errorf("CORS_ALLOWED_ORIGINS only supports a leading *. wildcard, got %q", origin)
+		}
+	}
+
+	if c.CORSMaxAge < 0 {
+		return fmt.Errorf("CORS_MAX_AGE must not be negative")
+	}
+
+	return nil
+}
+
+// OnchainEnabled reports whether any asset is dispensed on-chain.
+func (c *Config) OnchainEnabled() bool {
+	return len(c.OnchainTokens) > 0
+}
+
+// parseOnchain parses ONCHAIN_ASSETS and validates the settings it requires.
+func (c *Config) parseOnchain() (map[string]common.Address, *big.Int, error) {
+	tokens := make(map[string]common.Address, len(c.OnchainAssets))
+	for asset, token := range c.OnchainAssets {
+		asset = strings.ToLower(strings.TrimSpace(asset))
+		token = strings.TrimSpace(token)
+		if asset == "" {
+			return nil, nil, fmt.Errorf("ONCHAIN_ASSETS must not contain an empty asset symbol")
+		}
+
+		switch {
+		case strings.EqualFold(token, "native"):
+			tokens[asset] = common.Address{}
+		case common.IsHexAddress(token) && common.HexToAddress(token) != (common.Address{}):
+			tokens[asset] = common.HexToAddress(token)
+		default:
+			return nil, nil, fmt.Errorf("ONCHAIN_ASSETS for %s must be a token address or \"native\", got %q", asset, token)
+		}
+	}
+
+	if len(tokens) == 0 {
+		return tokens, nil, nil
+	}
+
+	rpcURL, err := url.Parse(c.OnchainRPCURL)
+	if err != nil || (rpcURL.Scheme != "http" && rpcURL.Scheme != "https" && rpcURL.Scheme != "ws" && rpcURL.Scheme != "wss") || rpcURL.Host == "" {
+		return nil, nil, fmt.Errorf("ONCHAIN_RPC_URL must be an http(s):// or ws(s):// URL when ONCHAIN_ASSETS is set")
+	}
+
+	if err := validatePrivateKey(c.OnchainPrivateKey); err != nil {
+		return nil, nil, fmt.Errorf("ONCHAIN_PRIVATE_KEY is invalid: %w", err)
+	}
+
+	if c.OnchainChainID < 0 {
+		return nil, nil, fmt.Errorf("ONCHAIN_CHAIN_ID must not be negative")
+	}
+
+	if c.OnchainReceiptTimeout <= 0 {
+		return nil, nil, fmt.Errorf("ONCHAIN_RECEIPT_TIMEOUT must be a positive duration")
+	}
+
+	if c.OnchainRPCTimeout <= 0 {
+		return nil, nil, fmt.Errorf("ONCHAIN_RPC_TIMEOUT must be a positive duration")
+	}
+
+	if strings.TrimSpace(c.OnchainMaxFeePerGas) == "" {
+		return tokens, nil, nil
+	}
+
+	maxFee, err := decimal.NewFromString(strings.TrimSpace(c.OnchainMaxFeePerGas))
+	if err != nil {
+		return nil, nil, fmt.Errorf("ONCHAIN_MAX_FEE_PER_GAS must be a valid decimal number: %w", err)
+	}
+
+	maxFeeWei := maxFee.Shift(9)
+	if !maxFeeWei.IsPositive() || !maxFeeWei.IsInteger() {
+		return nil, nil, fmt.Errorf("ONCHAIN_MAX_FEE_PER_GAS must be a positive amount of gwei with at most 9 decimals")
+	}
+
+	return tokens, maxFeeWei.BigInt(), nil
+}
+
+// parseBudget parses per-asset budget amounts, keyed by lowercase symbol.
+func parseBudget(name string, budget map[string]string) (map[string]decimal.Decimal, error) {
+	parsed := make(map[string]decimal.Decimal, len(budget))
+	for asset, value := range budget {
+		asset = strings.ToLower(strings.TrimSpace(asset))
+		if asset == "" {
+			return nil, fmt.Errorf("%s must not contain an empty asset symbol", name)
+		}
+
+		amount, err := decimal.NewFromString(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("%s for %s must be a valid decimal number: %w", name, asset, err)
+		}
+
+		if !amount.IsPositive() {
+			return nil, fmt.Errorf("%s for %s must be a positive number", name, asset)
+		}
+
+		parsed[asset] = amount
+	}
+
+	return parsed, nil
+}
+
+func validatePrivateKey(key string) error {
+	if key == "" {
+		return fmt.Errorf("must not be empty")
+	}
+
+	if _, err := crypto.HexToECDSA(strings.TrimPrefix(key, "0x")); err != nil {
+		return err
+	}
 
 	return nil
 }
@@ -0,0 +1,388 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testOwnerKey  = "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	testSignerKey = "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321"
+)
+
+func validConfig() *Config {
+	return &Config{
+		ServerPort:        "8080",
+		OwnerPrivateKey:   testOwnerKey,
+		SignerPrivateKey:  testSignerKey,
+		ClearnodeURL:      "wss://clearnode.example.com/ws",
+		TokenSymbol:       "usdc",
+		StandardTipAmount: "10",
+		MinTransferCount:  5,
+		LogLevel:          "info",
+	}
+}
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	yamlConfig := `
+server_port: "9090"
+owner_private_key: ` + testOwnerKey + `
+signer_private_key: ` + testSignerKey + `
+clearnode_url: wss://clearnode.example.com/ws
+token_symbol: usdc
+standard_tip_amount: "2.5"
+min_transfer_count: 3
+pow_window: 30s
+`
+
+	t.Run("reads YAML config file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		path := writeFile(t, dir, "faucet.yaml", yamlConfig)
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "9090", cfg.ServerPort)
+		assert.Equal(t, "usdc", cfg.TokenSymbol)
+		assert.Equal(t, 3, cfg.MinTransferCount)
+		assert.Equal(t, "2.5", cfg.StandardTipAmountDecimal.String())
+		assert.Equal(t, "info", cfg.LogLevel, "defaults apply to keys missing from the file")
+		assert.Equal(t, 30*time.Second, cfg.PowWindow)
+		assert.Equal(t, 5*time.Minute, cfg.ChallengeTTL)
+	})
+
+	t.Run("reads TOML config file from CONFIG_FILE", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		path := writeFile(t, dir, "faucet.toml", `
+owner_private_key = "`+testOwnerKey+`"
+signer_private_key = "`+testSignerKey+`"
+clearnode_url = "ws://localhost:8000/ws"
+token_symbol = "weth"
+standard_tip_amount = "0.1"
+min_transfer_count = 10
+pow_window = "1m"
+`)
+		t.Setenv(ConfigFileEnv, path)
+
+		cfg, err := Load("")
+		require.NoError(t, err)
+
+		assert.Equal(t, "8080", cfg.ServerPort)
+		assert.Equal(t, "weth", cfg.TokenSymbol)
+		assert.Equal(t, 10, cfg.MinTransferCount)
+		assert.Equal(t, time.Minute, cfg.PowWindow)
+	})
+
+	t.Run("environment overrides config file", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		path := writeFile(t, dir, "faucet.yaml", yamlConfig)
+		t.Setenv("STANDARD_TIP_AMOUNT", "7")
+		t.Setenv("LOG_LEVEL", "debug")
+		t.Setenv("DAILY_BUDGET", "usdc:1000,weth:2")
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "1000", cfg.DailyBudgetDecimal["usdc"].String())
+		assert.Equal(t, "2", cfg.DailyBudgetDecimal["weth"].String())
+
+		assert.Equal(t, "7", cfg.StandardTipAmountDecimal.String())
+		assert.Equal(t, "debug", cfg.LogLevel)
+	})
+
+	t.Run("explicit zeros in config file replace defaults", func(t *testing.T) {
+		assertZeros := func(t *testing.T, cfg *Config) {
+			t.Helper()
+			assert.Zero(t, cfg.PowTargetRate)
+			assert.Zero(t, cfg.ActivityFeedSize)
+			assert.Zero(t, cfg.CORSMaxAge)
+			assert.Zero(t, cfg.AirdropMaxRows)
+			assert.Zero(t, cfg.BalanceCriticalTips)
+			assert.Equal(t, 50, cfg.BalanceWarningTips, "defaults still apply to keys missing from the file")
+		}
+
+		t.Run("YAML", func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			path := writeFile(t, dir, "faucet.yaml", yamlConfig+`
+pow_target_rate: 0
+activity_feed_size: 0
+cors_max_age: 0s
+airdrop_max_rows: 0
+balance_critical_tips: 0
+`)
+
+			cfg, err := Load(path)
+			require.NoError(t, err)
+			assertZeros(t, cfg)
+		})
+
+		t.Run("TOML", func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			path := writeFile(t, dir, "faucet.toml", `
+owner_private_key = "`+testOwnerKey+`"
+signer_private_key = "`+testSignerKey+`"
+clearnode_url = "ws://localhost:8000/ws"
+token_symbol = "weth"
+standard_tip_amount = "0.1"
+min_transfer_count = 10
+pow_target_rate = 0
+activity_feed_size = 0
+cors_max_age = "0s"
+airdrop_max_rows = 0
+balance_critical_tips = 0
+`)
+
+			cfg, err := Load(path)
+			require.NoError(t, err)
+			assertZeros(t, cfg)
+		})
+
+		t.Run("environment still overrides", func(t *testing.T) {
+			dir := t.TempDir()
+			t.Chdir(dir)
+			path := writeFile(t, dir, "faucet.yaml", yamlConfig+"activity_feed_size: 0\n")
+			t.Setenv("ACTIVITY_FEED_SIZE", "5")
+
+			cfg, err := Load(path)
+			require.NoError(t, err)
+			assert.Equal(t, 5, cfg.ActivityFeedSize)
+		})
+	})
+
+	t.Run("rejects unknown YAML keys", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		path := writeFile(t, dir, "faucet.yaml", yamlConfig+"tip_amount: 5\n")
+
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tip_amount")
+	})
+
+	t.Run("rejects unknown TOML keys", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		path := writeFile(t, dir, "faucet.toml", `token_symbol = "usdc"`+"\n"+`tip_amount = "5"`+"\n")
+
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "tip_amount")
+	})
+
+	t.Run("rejects unsupported file extension", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		path := writeFile(t, dir, "faucet.ini", "")
+
+		_, err := Load(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported config file format")
+	})
+
+	t.Run("fails on malformed .env instead of falling back", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		writeFile(t, dir, ".env", "NOT A VALID LINE\n")
+
+		_, err := Load("")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ".env")
+	})
+
+	t.Run("environment takes precedence over .env", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+		writeFile(t, dir, ".env", "TOKEN_SYMBOL=weth\nMIN_TRANSFER_COUNT=2\n")
+		path := writeFile(t, dir, "faucet.yaml", `
+owner_private_key: `+testOwnerKey+`
+signer_private_key: `+testSignerKey+`
+clearnode_url: wss://clearnode.example.com/ws
+standard_tip_amount: "1"
+`)
+		t.Setenv("TOKEN_SYMBOL", "usdc")
+		// Setenv registers cleanup that restores the variable after the test
+		t.Setenv("MIN_TRANSFER_COUNT", "")
+		require.NoError(t, os.Unsetenv("MIN_TRANSFER_COUNT"))
+
+		cfg, err := Load(path)
+		require.NoError(t, err)
+
+		assert.Equal(t, "usdc", cfg.TokenSymbol)
+		assert.Equal(t, 2, cfg.MinTransferCount)
+	})
+}
+
+func TestValidate(t *testing.T) {
+	t.Run("accepts valid config", func(t *testing.T) {
+		cfg := validConfig()
+		require.NoError(t, cfg.Validate())
+		assert.Equal(t, "10", cfg.StandardTipAmountDecimal.String())
+	})
+
+	t.Run("accepts exact and wildcard CORS origins", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.CORSAllowedOrigins = []string{"https://app.example.com", "https://*.example.com", "http://localhost:3000"}
+		cfg.CORSAllowCredentials = true
+		require.NoError(t, cfg.Validate())
+	})
+
+	t.Run("accepts trusted proxy IPs and CIDR ranges", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.TrustedProxies = []string{"10.0.0.0/8", "192.0.2.1", "2001:db8::/32"}
+		require.NoError(t, cfg.Validate())
+	})
+
+	t.Run("parses budgets keyed by lowercase asset", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.HourlyBudget = map[string]string{"USDC": "500"}
+		cfg.DailyBudget = map[string]string{"usdc": "5000", "weth": "0.5"}
+		require.NoError(t, cfg.Validate())
+
+		assert.Equal(t, "500", cfg.HourlyBudgetDecimal["usdc"].String())
+		assert.Equal(t, "0.5", cfg.DailyBudgetDecimal["weth"].String())
+	})
+
+	t.Run("parses on-chain assets", func(t *testing.T) {
+		cfg := validConfig()
+		cfg.OnchainAssets = map[string]string{"ETH": "native", "usdc": "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}
+		cfg.OnchainRPCURL = "https://rpc.example.com"
+		cfg.OnchainPrivateKey = testOwnerKey
+		cfg.OnchainReceiptTimeout = time.Minute
+		cfg.OnchainRPCTimeout = 10 * time.Second
+		cfg.OnchainMaxFeePerGas = "1.5"
+		require.NoError(t, cfg.Validate())
+
+		assert.True(t, cfg.OnchainEnabled())
+		assert.Equal(t, "0x0000000000000000000000000000000000000000", cfg.OnchainTokens["eth"].Hex())
+		assert.Equal(t, "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", cfg.OnchainTokens["usdc"].Hex())
+		assert.Equal(t, "1500000000", cfg.OnchainMaxFeePerGasWei.String())
+	})
+
+	onchain := func(mutate func(*Config)) func(*Config) {
+		return func(c *Config) {
+			c.OnchainAssets = map[string]string{"eth": "native"}
+			c.OnchainRPCURL = "https://rpc.example.com"
+			c.OnchainPrivateKey = testOwnerKey
+			c.OnchainReceiptTimeout = time.Minute
+			c.OnchainRPCTimeout = 10 * time.Second
+			mutate(c)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		mutate  func(*Config)
+		wantErr string
+	}{
+		{"non-numeric port", func(c *Config) { c.ServerPort = "http" }, "SERVER_PORT"},
+		{"port out of range", func(c *Config) { c.ServerPort = "70000" }, "SERVER_PORT"},
+		{"invalid owner key", func(c *Config) { c.OwnerPrivateKey = "not-hex" }, "OWNER_PRIVATE_KEY"},
+		{"empty signer key", func(c *Config) { c.SignerPrivateKey = "" }, "SIGNER_PRIVATE_KEY"},
+		{"identical keys", func(c *Config) { c.SignerPrivateKey = "0x" + testOwnerKey }, "must be different"},
+		{"http clearnode URL", func(c *Config) { c.ClearnodeURL = "https://clearnode.example.com/ws" }, "ws:// or wss://"},
+		{"clearnode URL without host", func(c *Config) { c.ClearnodeURL = "ws:///ws" }, "host"},
+		{"empty token symbol", func(c *Config) { c.TokenSymbol = " " }, "TOKEN_SYMBOL"},
+		{"invalid tip amount", func(c *Config) { c.StandardTipAmount = "ten" }, "STANDARD_TIP_AMOUNT"},
+		{"zero tip amount", func(c *Config) { c.StandardTipAmount = "0" }, "STANDARD_TIP_AMOUNT"},
+		{"zero min transfer count", func(c *Config) { c.MinTransferCount = 0 }, "MIN_TRANSFER_COUNT"},
+		{"negative min transfer count", func(c *Config) { c.MinTransferCount = -1 }, "MIN_TRANSFER_COUNT"},
+		{"short admin token", func(c *Config) { c.AdminToken = "secret" }, "ADMIN_TOKEN"},
+		{"admin API without airdrop concurrency", func(c *Config) { c.AdminToken = "s3cr3t-admin-token-value" }, "AIRDROP_CONCURRENCY"},
+		{"negative airdrop row limit", func(c *Config) { c.AirdropMaxRows = -1 }, "AIRDROP_MAX_ROWS"},
+		{"CAPTCHA secret without verify URL", func(c *Config) { c.CaptchaSecret = "secret" }, "CAPTCHA_VERIFY_URL"},
+		{"proof-of-work difficulty out of range", func(c *Config) { c.PowEnabled, c.PowDifficulty, c.PowMaxDifficulty = true, 40, 40 }, "POW_DIFFICULTY"},
+		{"proof-of-work max below base", func(c *Config) {
+			c.PowEnabled, c.PowDifficulty, c.PowMaxDifficulty, c.PowWindow, c.ChallengeTTL = true, 20, 10, time.Minute, time.Minute
+		}, "POW_MAX_DIFFICULTY"},
+		{"signed requests without challenge TTL", func(c *Config) { c.RequireSignature = true }, "CHALLENGE_TTL"},
+		{"invalid hourly budget", func(c *Config) { c.HourlyBudget = map[string]string{"usdc": "lots"} }, "HOURLY_BUDGET"},
+		{"negative daily budget", func(c *Config) { c.DailyBudget = map[string]string{"usdc": "-1"} }, "DAILY_BUDGET"},
+		{"non-HTTP alert webhook", func(c *Config) { c.AlertDiscordWebhookURLs = []string{"ftp://example.com/hook"} }, "ALERT_DISCORD_WEBHOOK_URLS"},
+		{"warning threshold not above critical", func(c *Config) {
+			c.AlertWebhookURLs, c.BalanceCheckInterval, c.BalanceWarningTips, c.BalanceCriticalTips = []string{"https://example.com/hook"}, time.Minute, 10, 10
+		}, "BALANCE_WARNING_TIPS"},
+		{"event webhooks without secret", func(c *Config) { c.EventWebhookURLs = []string{"https://example.com/events"} }, "EVENT_WEBHOOK_SECRET"},
+		{"CORS origin with path", func(c *Config) { c.CORSAllowedOrigins = []string{"https://app.example.com/"} }, "CORS_ALLOWED_ORIGINS"},
+		{"CORS origin without scheme", func(c *Config) { c.CORSAllowedOrigins = []string{"app.example.com"} }, "CORS_ALLOWED_ORIGINS"},
+		{"CORS wildcard inside host", func(c *Config) { c.CORSAllowedOrigins = []string{"https://app.*.example.com"} }, "CORS_ALLOWED_ORIGINS"},
+		{"CORS credentials with any origin", func(c *Config) { c.CORSAllowedOrigins, c.CORSAllowCredentials = []string{"*"}, true }, "CORS_ALLOW_CREDENTIALS"},
+		{"no activity subscribers", func(c *Config) { c.ActivityFeedSize = 20; c.ActivityMaxSubscribers = 0 }, "ACTIVITY_MAX_SUBSCRIBERS"},
+		{"negative CORS max age", func(c *Config) { c.CORSMaxAge = -time.Second }, "CORS_MAX_AGE"},
+		{"trusted proxy hostname", func(c *Config) { c.TrustedProxies = []string{"lb.internal"} }, "TRUSTED_PROXIES"},
+		{"TLS certificate without key", func(c *Config) { c.TLSCertFile = "tls.crt" }, "TLS_KEY_FILE"},
+		{"client CA without TLS", func(c *Config) { c.TLSClientCAFile = "ca.crt" }, "TLS_CLIENT_CA_FILE"},
+		{"client CA without admin API", func(c *Config) {
+			c.TLSCertFile, c.TLSKeyFile, c.TLSReloadInterval, c.TLSClientCAFile = "tls.crt", "tls.key", time.Minute, "ca.crt"
+		}, "ADMIN_TOKEN"},
+		{"invalid on-chain token", onchain(func(c *Config) { c.OnchainAssets = map[string]string{"usdc": "0x1234"} }), "ONCHAIN_ASSETS"},
+		{"zero address on-chain token", onchain(func(c *Config) {
+			c.OnchainAssets = map[string]string{"usdc": "0x0000000000000000000000000000000000000000"}
+		}), "ONCHAIN_ASSETS"},
+		{"on-chain assets without RPC URL", onchain(func(c *Config) { c.OnchainRPCURL = "" }), "ONCHAIN_RPC_URL"},
+		{"on-chain assets without private key", onchain(func(c *Config) { c.OnchainPrivateKey = "" }), "ONCHAIN_PRIVATE_KEY"},
+		{"on-chain receipt timeout", onchain(func(c *Config) { c.OnchainReceiptTimeout = 0 }), "ONCHAIN_RECEIPT_TIMEOUT"},
+		{"on-chain RPC timeout", onchain(func(c *Config) { c.OnchainRPCTimeout = 0 }), "ONCHAIN_RPC_TIMEOUT"},
+		{"on-chain max fee below one wei", onchain(func(c *Config) { c.OnchainMaxFeePerGas = "0.0000000001" }), "ONCHAIN_MAX_FEE_PER_GAS"},
+		{"unknown log level", func(c *Config) { c.LogLevel = "verbose" }, "LOG_LEVEL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.mutate(cfg)
+
+			err := cfg.Validate()
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCheckReloadable(t *testing.T) {
+	t.Run("allows tunables to change", func(t *testing.T) {
+		current := validConfig()
+		next := validConfig()
+		next.StandardTipAmount = "5"
+		next.MinTransferCount = 50
+		next.TokenSymbol = "weth"
+		next.LogLevel = "debug"
+		next.CORSAllowedOrigins = []string{"https://app.example.com"}
+
+		assert.NoError(t, current.CheckReloadable(next))
+	})
+
+	t.Run("rejects changes to keys and connection settings", func(t *testing.T) {
+		current := validConfig()
+		next := validConfig()
+		next.SignerPrivateKey = "1111111111111111111111111111111111111111111111111111111111111111"
+		next.ClearnodeURL = "wss://other.example.com/ws"
+		next.TLSCertFile = "tls.crt"
+		next.OnchainAssets = map[string]string{"eth": "native"}
+		next.TrustedProxies = []string{"10.0.0.0/8"}
+
+		err := current.CheckReloadable(next)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "SIGNER_PRIVATE_KEY")
+		assert.Contains(t, err.Error(), "CLEARNODE_URL")
+		assert.Contains(t, err.Error(), "TLS_*")
+		assert.Contains(t, err.Error(), "ONCHAIN_*")
+		assert.Contains(t, err.Error(), "TRUSTED_PROXIES")
+		assert.NotContains(t, err.Error(), "OWNER_PRIVATE_KEY")
+	})
+}
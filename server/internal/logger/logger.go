@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"io"
 	"os"
 
 	"github.com/sirupsen/logrus"
@@ -27,6 +28,24 @@ func Initialize(level string) error {
 	return nil
 }
 
+// SetOutput redirects the initialized logger, e.g. to keep stdout free for
+// command output.
+func SetOutput(w io.Writer) {
+	Log.SetOutput(w)
+}
+
+// SetLevel changes the logging level of the initialized logger.
+func SetLevel(level string) error {
+	logLevel, err := logrus.ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	Log.SetLevel(logLevel)
+
+	return nil
+}
+
 func Info(args ...interface{}) {
 	Log.Info(args...)
 }
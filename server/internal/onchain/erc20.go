@@ -0,0 +1,68 @@
+package onchain
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/accounts/abi"
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// erc20ABI covers the parts of the ERC-20 interface the sender uses.
+const erc20ABI = `[
+	{"type":"function","name":"decimals","stateMutability":"view","inputs":[],"outputs":[{"name":"","type":"uint8"}]},
+	{"type":"function","name":"balanceOf","stateMutability":"view","inputs":[{"name":"account","type":"address"}],"outputs":[{"name":"","type":"uint256"}]},
+	{"type":"function","name":"transfer","stateMutability":"nonpayable","inputs":[{"name":"to","type":"address"},{"name":"value","type":"uint256"}],"outputs":[{"name":"","type":"bool"}]}
+]`
+
+var erc20 = mustParseABI(erc20ABI)
+
+func mustParseABI(definition string) abi.ABI {
+	parsed, err := abi.JSON(strings.NewReader(definition))
+	if err != nil {
+		panic(err)
+	}
+	return parsed
+}
+
+// callERC20 calls a view method of the token contract at the latest block
+// and returns its single output.
+func callERC20(ctx context.Context, backend Backend, token common.Address, method string, args ...interface{}) (interface{}, error) {
+	data, err := erc20.Pack(method, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err := backend.CallContract(ctx, ethereum.CallMsg{To: &token, Data: data}, nil)
+	if err != nil {
+		return nil, fmt.Errorf("%s call failed: %w", method, err)
+	}
+
+	values, err := erc20.Unpack(method, output)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s result of %s: %w", method, token.Hex(), err)
+	}
+	if len(values) != 1 {
+		return nil, fmt.Errorf("unexpected %s result of %s", method, token.Hex())
+	}
+	return values[0], nil
+}
+
+func erc20Decimals(ctx context.Context, backend Backend, token common.Address) (int32, error) {
+	value, err := callERC20(ctx, backend, token, "decimals")
+	if err != nil {
+		return 0, err
+	}
+	return int32(value.(uint8)), nil
+}
+
+func erc20BalanceOf(ctx context.Context, backend Backend, token, account common.Address) (*big.Int, error) {
+	value, err := callERC20(ctx, backend, token, "balanceOf", account)
+	if err != nil {
+		return nil, err
+	}
+	return value.(*big.Int), nil
+}
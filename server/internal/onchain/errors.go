@@ -0,0 +1,27 @@
+package onchain
+
+import "errors"
+
+// Sentinel errors returned (wrapped) by Sender. Use errors.Is to match them.
+var (
+	// ErrUnsupportedAsset means the asset is not configured for on-chain dispensing.
+	ErrUnsupportedAsset = errors.New("asset is not dispensed on-chain")
+	// ErrInvalidDestination means the destination is not a hex address.
+	ErrInvalidDestination = errors.New("invalid destination address")
+	// ErrUnavailable means the RPC endpoint did not answer.
+	ErrUnavailable = errors.New("RPC endpoint unavailable")
+	// ErrChainMismatch means the RPC endpoint serves a different chain than expected.
+	ErrChainMismatch = errors.New("unexpected chain ID")
+	// ErrInvalidAmount means the amount is not positive or has more decimals
+	// than the token.
+	ErrInvalidAmount = errors.New("invalid amount")
+	// ErrInsufficientBalance means the sender cannot cover the transfer.
+	ErrInsufficientBalance = errors.New("insufficient balance")
+	// ErrFeeTooHigh means the network fee exceeds the configured maximum.
+	ErrFeeTooHigh = errors.New("network fee exceeds the configured maximum")
+	// ErrReceiptTimeout means the transaction was sent but not mined within
+	// the receipt timeout. It may still be mined later.
+	ErrReceiptTimeout = errors.New("timed out waiting for transaction receipt")
+	// ErrTransactionFailed means the transaction was mined but reverted.
+	ErrTransactionFailed = errors.New("transaction reverted")
+)
@@ -0,0 +1,423 @@
+// Package onchain dispenses native currency and ERC-20 tokens with plain
+// transactions through an Ethereum JSON-RPC endpoint, for assets the faucet
+// hands out on-chain instead of through Clearnode.
+package onchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/logger"
+)
+
+const (
+	nativeDecimals = 18
+
+	defaultReceiptTimeout = 2 * time.Minute
+	defaultPollInterval   = time.Second
+	defaultRPCTimeout     = 10 * time.Second
+)
+
+// Backend is the part of an Ethereum JSON-RPC client the sender uses.
+// It is implemented by *ethclient.Client and by go-ethereum's simulated
+// backend.
+type Backend interface {
+	ChainID(ctx context.Context) (*big.Int, error)
+	HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error)
+	BalanceAt(ctx context.Context, account common.Address, blockNumber *big.Int) (*big.Int, error)
+	CallContract(ctx context.Context, call ethereum.CallMsg, blockNumber *big.Int) ([]byte, error)
+	PendingNonceAt(ctx context.Context, account common.Address) (uint64, error)
+	SuggestGasTipCap(ctx context.Context) (*big.Int, error)
+	EstimateGas(ctx context.Context, call ethereum.CallMsg) (uint64, error)
+	SendTransaction(ctx context.Context, tx *types.Transaction) error
+	TransactionReceipt(ctx context.Context, txHash common.Hash) (*types.Receipt, error)
+}
+
+// Token is an asset dispensed on-chain. The zero Address denotes the chain's
+// native currency.
+type Token struct {
+	Symbol   string
+	Address  common.Address
+	Decimals int32
+}
+
+// Native reports whether the token is the chain's native currency.
+func (t Token) Native() bool {
+	return t.Address == (common.Address{})
+}
+
+// Options tune a Sender. The zero value uses the defaults.
+type Options struct {
+	// ChainID, when set, must match the chain served by the backend
+	ChainID *big.Int
+	// MaxFeePerGas caps the EIP-1559 fee cap in wei (no cap when nil)
+	MaxFeePerGas *big.Int
+	// ReceiptTimeout bounds the wait for a transaction to be mined (default 2m)
+	ReceiptTimeout time.Duration
+	// PollInterval is the delay between receipt lookups (default 1s)
+	PollInterval time.Duration
+	// RPCTimeout bounds every JSON-RPC call other than the receipt wait
+	// (default 10s)
+	RPCTimeout time.Duration
+}
+
+// Sender sends transfers from a single account. It is safe for concurrent
+// use: nonces are assigned locally, so transfers don't wait for each other
+// to be mined.
+type Sender struct {
+	backend    Backend
+	privateKey *ecdsa.PrivateKey
+	address    common.Address
+	chainID    *big.Int
+	signer     types.Signer
+	tokens     map[string]Token
+	opts       Options
+
+	// Next nonce to use; nil until fetched and after a failed send
+	nonce   *uint64
+	nonceMu sync.Mutex
+}
+
+// NewSender creates a sender for the account of privateKey (hex, with or
+// without 0x prefix). assets maps asset symbols to token contracts, the zero
+// address denoting the native currency. The decimals of every token are
+// queried from its contract.
+func NewSender(ctx context.Context, backend Backend, privateKey string, assets map[string]common.Address, opts Options) (*Sender, error) {
+	key, err := crypto.HexToECDSA(strings.TrimPrefix(privateKey, "0x"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid private key: %w", err)
+	}
+
+	chainID, err := backend.ChainID(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch chain ID: %w", err)
+	}
+	if opts.ChainID != nil && opts.ChainID.Cmp(chainID) != 0 {
+		return nil, fmt.Errorf("%w: RPC endpoint serves chain %s, expected %s", ErrChainMismatch, chainID, opts.ChainID)
+	}
+
+	if opts.ReceiptTimeout <= 0 {
+		opts.ReceiptTimeout = defaultReceiptTimeout
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = defaultPollInterval
+	}
+	if opts.RPCTimeout <= 0 {
+		opts.RPCTimeout = defaultRPCTimeout
+	}
+
+	tokens := make(map[string]Token, len(assets))
+	for symbol, address := range assets {
+		token := Token{Symbol: strings.ToLower(symbol), Address: address, Decimals: nativeDecimals}
+		if !token.Native() {
+			token.Decimals, err = erc20Decimals(ctx, backend, address)
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch decimals of %s token %s: %w", symbol, address.Hex(), err)
+			}
+		}
+		tokens[token.Symbol] = token
+	}
+
+	return &Sender{
+		backend:    backend,
+		privateKey: key,
+		address:    crypto.PubkeyToAddress(key.PublicKey),
+		chainID:    chainID,
+		signer:     types.LatestSignerForChainID(chainID),
+		tokens:     tokens,
+		opts:       opts,
+	}, nil
+}
+
+// Address returns the account transfers are sent from.
+func (s *Sender) Address() common.Address {
+	return s.address
+}
+
+// ChainID returns the chain the sender sends transactions on.
+func (s *Sender) ChainID() *big.Int {
+	return new(big.Int).Set(s.chainID)
+}
+
+// Handles reports whether asset is dispensed by this sender.
+func (s *Sender) Handles(asset string) bool {
+	_, ok := s.tokens[strings.ToLower(asset)]
+	return ok
+}
+
+// Tokens lists the dispensed tokens, sorted by symbol.
+func (s *Sender) Tokens() []Token {
+	tokens := make([]Token, 0, len(s.tokens))
+	for _, token := range s.tokens {
+		tokens = append(tokens, token)
+	}
+	slices.SortFunc(tokens, func(a, b Token) int { return strings.Compare(a.Symbol, b.Symbol) })
+	return tokens
+}
+
+func (s *Sender) token(asset string) (Token, error) {
+	token, ok := s.tokens[strings.ToLower(asset)]
+	if !ok {
+		return Token{}, fmt.Errorf("%w: %s", ErrUnsupportedAsset, asset)
+	}
+	return token, nil
+}
+
+// rpcContext bounds a JSON-RPC call by RPCTimeout.
+func (s *Sender) rpcContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, s.opts.RPCTimeout)
+}
+
+// CheckOperational checks that asset is dispensed by this sender and that the
+// RPC endpoint answers.
+func (s *Sender) CheckOperational(ctx context.Context, asset string) error {
+	if _, err := s.token(asset); err != nil {
+		return err
+	}
+
+	ctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+
+	if _, err := s.backend.HeaderByNumber(ctx, nil); err != nil {
+		return fmt.Errorf("%w: %w", ErrUnavailable, err)
+	}
+	return nil
+}
+
+// Balance fetches the sender's balance of asset at the latest block.
+func (s *Sender) Balance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	token, err := s.token(asset)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	ctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+
+	var raw *big.Int
+	if token.Native() {
+		raw, err = s.backend.BalanceAt(ctx, s.address, nil)
+	} else {
+		raw, err = erc20BalanceOf(ctx, s.backend, token.Address, s.address)
+	}
+	if err != nil {
+		return decimal.Decimal{}, fmt.Errorf("failed to fetch %s balance: %w", token.Symbol, err)
+	}
+
+	return decimal.NewFromBigInt(raw, -token.Decimals), nil
+}
+
+// Transfer sends amount of asset to destination (a hex address) and waits
+// until the transaction is mined. The transaction hash is returned whenever
+// the transaction was sent, including when waiting for it failed with
+// ErrReceiptTimeout.
+//
+// ctx bounds the calls made before sending, each also bounded by RPCTimeout.
+// Once the transaction is signed, cancelling ctx no longer aborts the send or
+// the wait for its receipt, so a sent transaction is always followed up.
+func (s *Sender) Transfer(ctx context.Context, destination, asset string, amount decimal.Decimal) (string, error) {
+	hash, err := s.transfer(ctx, destination, asset, amount)
+	if hash == (common.Hash{}) {
+		return "", err
+	}
+	return hash.Hex(), err
+}
+
+func (s *Sender) transfer(ctx context.Context, destination, asset string, amount decimal.Decimal) (common.Hash, error) {
+	if !common.IsHexAddress(destination) {
+		return common.Hash{}, fmt.Errorf("%w: %q", ErrInvalidDestination, destination)
+	}
+	to := common.HexToAddress(destination)
+
+	token, err := s.token(asset)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	value, err := toBaseUnits(amount, token.Decimals)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	balance, err := s.Balance(ctx, token.Symbol)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	if balance.LessThan(amount) {
+		return common.Hash{}, fmt.Errorf("%w: %s %s available, %s requested", ErrInsufficientBalance, balance, token.Symbol, amount)
+	}
+
+	call := ethereum.CallMsg{From: s.address, To: &to, Value: value}
+	if !token.Native() {
+		data, err := erc20.Pack("transfer", to, value)
+		if err != nil {
+			return common.Hash{}, err
+		}
+		call = ethereum.CallMsg{From: s.address, To: &token.Address, Data: data}
+	}
+
+	estimateCtx, cancel := s.rpcContext(ctx)
+	gas, err := s.backend.EstimateGas(estimateCtx, call)
+	cancel()
+	if err != nil {
+		return common.Hash{}, fmt.Errorf("gas estimation failed: %w", err)
+	}
+	if !token.Native() {
+		// Token storage may change between estimation and inclusion
+		gas += gas / 5
+	}
+
+	tipCap, feeCap, err := s.fees(ctx)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx, err := s.send(ctx, &types.DynamicFeeTx{
+		ChainID:   s.chainID,
+		GasTipCap: tipCap,
+		GasFeeCap: feeCap,
+		Gas:       gas,
+		To:        call.To,
+		Value:     call.Value,
+		Data:      call.Data,
+	})
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	logger.Infof("Sent on-chain transfer of %s %s to %s (tx: %s, nonce: %d)",
+		amount, token.Symbol, to.Hex(), tx.Hash().Hex(), tx.Nonce())
+
+	receipt, err := s.waitMined(context.WithoutCancel(ctx), tx.Hash())
+	if err != nil {
+		return tx.Hash(), err
+	}
+	if receipt.Status != types.ReceiptStatusSuccessful {
+		return tx.Hash(), fmt.Errorf("%w: %s", ErrTransactionFailed, tx.Hash().Hex())
+	}
+
+	logger.Debugf("On-chain transfer %s mined in block %s", tx.Hash().Hex(), receipt.BlockNumber)
+	return tx.Hash(), nil
+}
+
+// fees returns the EIP-1559 tip and fee cap for a new transaction. The fee
+// cap leaves room for the base fee to double before inclusion.
+func (s *Sender) fees(ctx context.Context) (*big.Int, *big.Int, error) {
+	ctx, cancel := s.rpcContext(ctx)
+	defer cancel()
+
+	tipCap, err := s.backend.SuggestGasTipCap(ctx)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch gas tip cap: %w", err)
+	}
+
+	head, err := s.backend.HeaderByNumber(ctx, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to fetch latest block: %w", err)
+	}
+	if head.BaseFee == nil {
+		return nil, nil, fmt.Errorf("chain %s does not support EIP-1559 transactions", s.chainID)
+	}
+
+	feeCap := new(big.Int).Add(tipCap, new(big.Int).Mul(head.BaseFee, big.NewInt(2)))
+
+	if limit := s.opts.MaxFeePerGas; limit != nil && feeCap.Cmp(limit) > 0 {
+		if minimum := new(big.Int).Add(tipCap, head.BaseFee); minimum.Cmp(limit) > 0 {
+			return nil, nil, fmt.Errorf("%w: base fee %s wei plus tip %s wei exceeds %s wei", ErrFeeTooHigh, head.BaseFee, tipCap, limit)
+		}
+		feeCap = new(big.Int).Set(limit)
+	}
+
+	return tipCap, feeCap, nil
+}
+
+// send assigns the next nonce to tx, signs and submits it. Nonces are
+// refetched from the pending state after a failed send, as it is unknown
+// whether the node accepted the transaction.
+//
+// Both calls made under nonceMu are bounded by RPCTimeout, so a hung
+// endpoint can't block other transfers indefinitely. The submission itself
+// ignores cancellation of ctx: abandoning it halfway would leave the nonce
+// state unknown.
+func (s *Sender) send(ctx context.Context, unsigned *types.DynamicFeeTx) (*types.Transaction, error) {
+	s.nonceMu.Lock()
+	defer s.nonceMu.Unlock()
+
+	if s.nonce == nil {
+		nonceCtx, cancel := s.rpcContext(ctx)
+		nonce, err := s.backend.PendingNonceAt(nonceCtx, s.address)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch nonce: %w", err)
+		}
+		s.nonce = &nonce
+	}
+	unsigned.Nonce = *s.nonce
+
+	tx, err := types.SignNewTx(s.privateKey, s.signer, unsigned)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign transaction: %w", err)
+	}
+
+	sendCtx, cancel := s.rpcContext(context.WithoutCancel(ctx))
+	defer cancel()
+
+	if err := s.backend.SendTransaction(sendCtx, tx); err != nil {
+		s.nonce = nil
+		return nil, fmt.Errorf("failed to send transaction: %w", err)
+	}
+
+	*s.nonce++
+	return tx, nil
+}
+
+// waitMined polls for the receipt of hash until ReceiptTimeout elapses.
+func (s *Sender) waitMined(ctx context.Context, hash common.Hash) (*types.Receipt, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.opts.ReceiptTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		receipt, err := s.backend.TransactionReceipt(ctx, hash)
+		if err == nil {
+			return receipt, nil
+		}
+		if !errors.Is(err, ethereum.NotFound) && ctx.Err() == nil {
+			logger.Debugf("Receipt lookup for %s failed: %v", hash.Hex(), err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %s", ErrReceiptTimeout, hash.Hex())
+		case <-ticker.C:
+		}
+	}
+}
+
+// toBaseUnits converts amount to the smallest unit of a token with decimals.
+func toBaseUnits(amount decimal.Decimal, decimals int32) (*big.Int, error) {
+	if !amount.IsPositive() {
+		return nil, fmt.Errorf("%w: %s must be positive", ErrInvalidAmount, amount)
+	}
+
+	shifted := amount.Shift(decimals)
+	if !shifted.IsInteger() {
+		return nil, fmt.Errorf("%w: %s has more than %d decimals", ErrInvalidAmount, amount, decimals)
+	}
+	return shifted.BigInt(), nil
+}
@@ -0,0 +1,465 @@
+package onchain
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/core/vm"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/logger"
+)
+
+const testSenderKey = "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+
+// chain is a simulated chain on which the sender account holds 100 ETH.
+type chain struct {
+	*simulated.Backend
+	client simulated.Client
+	key    *ecdsa.PrivateKey
+}
+
+func newChain(t *testing.T) *chain {
+	t.Helper()
+
+	if logger.Log == nil {
+		require.NoError(t, logger.Initialize("debug"))
+	}
+
+	key, err := crypto.HexToECDSA(testSenderKey)
+	require.NoError(t, err)
+
+	backend := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(100), big.NewInt(params.Ether))},
+	})
+	t.Cleanup(func() { backend.Close() })
+
+	return &chain{Backend: backend, client: backend.Client(), key: key}
+}
+
+// autoMining returns a backend that mines a block after every sent transaction.
+func (c *chain) autoMining() Backend {
+	return &autoMiningClient{Client: c.client, chain: c}
+}
+
+type autoMiningClient struct {
+	simulated.Client
+	chain *chain
+	mu    sync.Mutex
+}
+
+func (a *autoMiningClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := a.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	a.chain.Commit()
+	return nil
+}
+
+// deployToken deploys a minimal ERC-20 token (decimals, balanceOf, transfer)
+// that mints supply base units to the sender account.
+func (c *chain) deployToken(t *testing.T, decimals uint8, supply *big.Int) common.Address {
+	t.Helper()
+	ctx := context.Background()
+
+	from := crypto.PubkeyToAddress(c.key.PublicKey)
+	nonce, err := c.client.PendingNonceAt(ctx, from)
+	require.NoError(t, err)
+	chainID, err := c.client.ChainID(ctx)
+	require.NoError(t, err)
+
+	tx, err := types.SignNewTx(c.key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+		ChainID:   chainID,
+		Nonce:     nonce,
+		GasTipCap: big.NewInt(params.GWei),
+		GasFeeCap: big.NewInt(10 * params.GWei),
+		Gas:       500000,
+		Data:      tokenInitCode(decimals, supply),
+	})
+	require.NoError(t, err)
+	require.NoError(t, c.client.SendTransaction(ctx, tx))
+	c.Commit()
+
+	receipt, err := c.client.TransactionReceipt(ctx, tx.Hash())
+	require.NoError(t, err)
+	require.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+	return receipt.ContractAddress
+}
+
+// tokenInitCode assembles the token contract. Balances are stored at the
+// slot equal to the account address; transfer reverts on insufficient funds.
+func tokenInitCode(decimals uint8, supply *big.Int) []byte {
+	var a assembler
+	a.push(0).op(vm.CALLDATALOAD).push(0xe0).op(vm.SHR)
+	for selector, label := range map[string]string{"decimals()": "decimals", "balanceOf(address)": "balanceOf", "transfer(address,uint256)": "transfer"} {
+		a.op(vm.DUP1).pushBytes(crypto.Keccak256([]byte(selector))[:4]).op(vm.EQ).pushLabel(label).op(vm.JUMPI)
+	}
+	a.push(0).op(vm.DUP1).op(vm.REVERT)
+
+	a.label("decimals").push(uint64(decimals)).returnWord()
+	a.label("balanceOf").push(4).op(vm.CALLDATALOAD).op(vm.SLOAD).returnWord()
+
+	a.label("transfer")
+	a.push(0x24).op(vm.CALLDATALOAD).op(vm.CALLER).op(vm.SLOAD)
+	a.op(vm.DUP2).op(vm.DUP2).op(vm.LT).pushLabel("fail").op(vm.JUMPI)
+	a.op(vm.SUB).op(vm.CALLER).op(vm.SSTORE)
+	a.push(0x24).op(vm.CALLDATALOAD).push(4).op(vm.CALLDATALOAD).op(vm.DUP1).op(vm.SLOAD)
+	a.op(vm.DUP3).op(vm.ADD).op(vm.SWAP1).op(vm.SSTORE).op(vm.POP)
+	a.push(1).returnWord()
+
+	a.label("fail").push(0).op(vm.DUP1).op(vm.REVERT)
+	runtime := a.assemble()
+
+	var init assembler
+	init.pushBytes(common.LeftPadBytes(supply.Bytes(), 32)).op(vm.CALLER).op(vm.SSTORE)
+	init.pushBytes([]byte{byte(len(runtime) >> 8), byte(len(runtime))}).op(vm.DUP1).pushLabel("runtime").push(0).op(vm.CODECOPY)
+	init.push(0).op(vm.RETURN)
+	init.mark("runtime")
+	return append(init.assemble(), runtime...)
+}
+
+// assembler builds EVM bytecode with jump labels, which are pushed as
+// two-byte offsets and resolved by assemble.
+type assembler struct {
+	code   []byte
+	labels map[string]int
+	refs   map[int]string
+}
+
+func (a *assembler) op(op vm.OpCode) *assembler {
+	a.code = append(a.code, byte(op))
+	return a
+}
+
+func (a *assembler) pushBytes(value []byte) *assembler {
+	a.code = append(a.code, byte(vm.PUSH1)+byte(len(value)-1))
+	a.code = append(a.code, value...)
+	return a
+}
+
+func (a *assembler) push(value uint64) *assembler {
+	bytes := new(big.Int).SetUint64(value).Bytes()
+	if len(bytes) == 0 {
+		bytes = []byte{0}
+	}
+	return a.pushBytes(bytes)
+}
+
+func (a *assembler) pushLabel(name string) *assembler {
+	if a.refs == nil {
+		a.refs = make(map[int]string)
+	}
+	a.code = append(a.code, byte(vm.PUSH2))
+	a.refs[len(a.code)] = name
+	a.code = append(a.code, 0, 0)
+	return a
+}
+
+// mark names the current offset.
+func (a *assembler) mark(name string) *assembler {
+	if a.labels == nil {
+		a.labels = make(map[string]int)
+	}
+	a.labels[name] = len(a.code)
+	return a
+}
+
+// label marks a jump target.
+func (a *assembler) label(name string) *assembler {
+	return a.mark(name).op(vm.JUMPDEST)
+}
+
+// returnWord returns the top of the stack as a 32-byte word.
+func (a *assembler) returnWord() *assembler {
+	return a.push(0).op(vm.MSTORE).push(32).push(0).op(vm.RETURN)
+}
+
+func (a *assembler) assemble() []byte {
+	for offset, name := range a.refs {
+		target, ok := a.labels[name]
+		if !ok {
+			panic("undefined label " + name)
+		}
+		a.code[offset], a.code[offset+1] = byte(target>>8), byte(target)
+	}
+	return a.code
+}
+
+func newSender(t *testing.T, backend Backend, assets map[string]common.Address, opts Options) *Sender {
+	t.Helper()
+
+	if opts.PollInterval == 0 {
+		opts.PollInterval = 10 * time.Millisecond
+	}
+	sender, err := NewSender(context.Background(), backend, testSenderKey, assets, opts)
+	require.NoError(t, err)
+	return sender
+}
+
+func TestNewSender(t *testing.T) {
+	c := newChain(t)
+	token := c.deployToken(t, 6, big.NewInt(1000000000))
+
+	t.Run("resolves tokens", func(t *testing.T) {
+		sender := newSender(t, c.client, map[string]common.Address{"ETH": {}, "usdc": token}, Options{})
+
+		assert.Equal(t, crypto.PubkeyToAddress(c.key.PublicKey), sender.Address())
+		assert.Equal(t, []Token{
+			{Symbol: "eth", Decimals: 18},
+			{Symbol: "usdc", Address: token, Decimals: 6},
+		}, sender.Tokens())
+		assert.True(t, sender.Handles("USDC"))
+		assert.False(t, sender.Handles("weth"))
+	})
+
+	t.Run("chain mismatch", func(t *testing.T) {
+		_, err := NewSender(context.Background(), c.client, testSenderKey, nil, Options{ChainID: big.NewInt(5)})
+		assert.ErrorIs(t, err, ErrChainMismatch)
+	})
+
+	t.Run("not a token", func(t *testing.T) {
+		_, err := NewSender(context.Background(), c.client, testSenderKey, map[string]common.Address{"usdc": common.HexToAddress("0x1234")}, Options{})
+		assert.ErrorContains(t, err, "failed to fetch decimals of usdc")
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		_, err := NewSender(context.Background(), c.client, "not-a-key", nil, Options{})
+		assert.ErrorContains(t, err, "invalid private key")
+	})
+}
+
+func TestTransfer(t *testing.T) {
+	ctx := context.Background()
+	c := newChain(t)
+	token := c.deployToken(t, 6, big.NewInt(1000000000))
+	sender := newSender(t, c.autoMining(), map[string]common.Address{"eth": {}, "usdc": token}, Options{})
+	recipient := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	t.Run("native", func(t *testing.T) {
+		hash, err := sender.Transfer(ctx, recipient, "eth", decimal.RequireFromString("0.5"))
+		require.NoError(t, err)
+
+		tx, pending, err := c.client.TransactionByHash(ctx, common.HexToHash(hash))
+		require.NoError(t, err)
+		assert.False(t, pending)
+		assert.Equal(t, uint8(types.DynamicFeeTxType), tx.Type())
+
+		balance, err := c.client.BalanceAt(ctx, common.HexToAddress(recipient), nil)
+		require.NoError(t, err)
+		assert.Equal(t, "500000000000000000", balance.String())
+	})
+
+	t.Run("erc20", func(t *testing.T) {
+		_, err := sender.Transfer(ctx, recipient, "USDC", decimal.RequireFromString("12.5"))
+		require.NoError(t, err)
+
+		balance, err := erc20BalanceOf(ctx, c.client, token, common.HexToAddress(recipient))
+		require.NoError(t, err)
+		assert.Equal(t, "12500000", balance.String())
+
+		remaining, err := sender.Balance(ctx, "usdc")
+		require.NoError(t, err)
+		assert.Equal(t, "987.5", remaining.String())
+	})
+
+	t.Run("concurrent transfers get consecutive nonces", func(t *testing.T) {
+		var wg sync.WaitGroup
+		errs := make([]error, 5)
+		for i := range errs {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				_, errs[i] = sender.Transfer(ctx, recipient, "usdc", decimal.NewFromInt(1))
+			}(i)
+		}
+		wg.Wait()
+
+		for _, err := range errs {
+			assert.NoError(t, err)
+		}
+		balance, err := erc20BalanceOf(ctx, c.client, token, common.HexToAddress(recipient))
+		require.NoError(t, err)
+		assert.Equal(t, "17500000", balance.String())
+	})
+
+	t.Run("rejected before sending", func(t *testing.T) {
+		_, err := sender.Transfer(ctx, recipient, "usdc", decimal.NewFromInt(1000))
+		assert.ErrorIs(t, err, ErrInsufficientBalance)
+
+		_, err = sender.Transfer(ctx, recipient, "usdc", decimal.RequireFromString("0.0000001"))
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+
+		_, err = sender.Transfer(ctx, recipient, "eth", decimal.Zero)
+		assert.ErrorIs(t, err, ErrInvalidAmount)
+
+		_, err = sender.Transfer(ctx, recipient, "weth", decimal.NewFromInt(1))
+		assert.ErrorIs(t, err, ErrUnsupportedAsset)
+
+		_, err = sender.Transfer(ctx, "0x1234", "eth", decimal.NewFromInt(1))
+		assert.ErrorIs(t, err, ErrInvalidDestination)
+	})
+
+	t.Run("recovers after a failed send", func(t *testing.T) {
+		// Consume the cached nonce behind the sender's back
+		nonce, err := c.client.PendingNonceAt(ctx, sender.Address())
+		require.NoError(t, err)
+		chainID, err := c.client.ChainID(ctx)
+		require.NoError(t, err)
+		to := common.HexToAddress(recipient)
+		tx, err := types.SignNewTx(c.key, types.LatestSignerForChainID(chainID), &types.DynamicFeeTx{
+			ChainID: chainID, Nonce: nonce, GasTipCap: big.NewInt(params.GWei), GasFeeCap: big.NewInt(10 * params.GWei), Gas: 21000, To: &to,
+		})
+		require.NoError(t, err)
+		require.NoError(t, c.client.SendTransaction(ctx, tx))
+		c.Commit()
+
+		_, err = sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+		require.ErrorContains(t, err, "failed to send transaction")
+
+		_, err = sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+		assert.NoError(t, err)
+	})
+}
+
+func TestTransferFees(t *testing.T) {
+	ctx := context.Background()
+	c := newChain(t)
+	recipient := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	head, err := c.client.HeaderByNumber(ctx, nil)
+	require.NoError(t, err)
+	tipCap, err := c.client.SuggestGasTipCap(ctx)
+	require.NoError(t, err)
+
+	t.Run("fee cap leaves room for the base fee to double", func(t *testing.T) {
+		sender := newSender(t, c.autoMining(), map[string]common.Address{"eth": {}}, Options{})
+		hash, err := sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+		require.NoError(t, err)
+
+		tx, _, err := c.client.TransactionByHash(ctx, common.HexToHash(hash))
+		require.NoError(t, err)
+		assert.Equal(t, tipCap, tx.GasTipCap())
+		assert.True(t, tx.GasFeeCap().Cmp(new(big.Int).Add(tipCap, head.BaseFee)) > 0)
+	})
+
+	t.Run("capped by MaxFeePerGas", func(t *testing.T) {
+		head, err := c.client.HeaderByNumber(ctx, nil)
+		require.NoError(t, err)
+		limit := new(big.Int).Add(new(big.Int).Add(tipCap, head.BaseFee), big.NewInt(1))
+
+		sender := newSender(t, c.autoMining(), map[string]common.Address{"eth": {}}, Options{MaxFeePerGas: limit})
+		hash, err := sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+		require.NoError(t, err)
+
+		tx, _, err := c.client.TransactionByHash(ctx, common.HexToHash(hash))
+		require.NoError(t, err)
+		assert.Equal(t, limit, tx.GasFeeCap())
+	})
+
+	t.Run("base fee above MaxFeePerGas", func(t *testing.T) {
+		sender := newSender(t, c.autoMining(), map[string]common.Address{"eth": {}}, Options{MaxFeePerGas: big.NewInt(1)})
+		_, err := sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+		assert.ErrorIs(t, err, ErrFeeTooHigh)
+	})
+}
+
+func TestTransferReceiptTimeout(t *testing.T) {
+	ctx := context.Background()
+	c := newChain(t)
+	recipient := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	// Without auto-mining the transaction stays pending
+	sender := newSender(t, c.client, map[string]common.Address{"eth": {}}, Options{ReceiptTimeout: 50 * time.Millisecond})
+	hash, err := sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+	require.ErrorIs(t, err, ErrReceiptTimeout)
+	assert.NotEmpty(t, hash)
+
+	c.Commit()
+	receipt, err := c.client.TransactionReceipt(ctx, common.HexToHash(hash))
+	require.NoError(t, err)
+	assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+}
+
+// hangingClient never answers the calls of the blocked methods until their
+// context is done.
+type hangingClient struct {
+	Backend
+	blocked map[string]bool
+}
+
+func (h *hangingClient) hang(ctx context.Context, method string) error {
+	if !h.blocked[method] {
+		return nil
+	}
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (h *hangingClient) HeaderByNumber(ctx context.Context, number *big.Int) (*types.Header, error) {
+	if err := h.hang(ctx, "HeaderByNumber"); err != nil {
+		return nil, err
+	}
+	return h.Backend.HeaderByNumber(ctx, number)
+}
+
+func (h *hangingClient) PendingNonceAt(ctx context.Context, account common.Address) (uint64, error) {
+	if err := h.hang(ctx, "PendingNonceAt"); err != nil {
+		return 0, err
+	}
+	return h.Backend.PendingNonceAt(ctx, account)
+}
+
+func (h *hangingClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := h.hang(ctx, "SendTransaction"); err != nil {
+		return err
+	}
+	return h.Backend.SendTransaction(ctx, tx)
+}
+
+func TestRPCTimeout(t *testing.T) {
+	ctx := context.Background()
+	c := newChain(t)
+	recipient := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	for _, method := range []string{"PendingNonceAt", "SendTransaction"} {
+		t.Run(method+" releases the nonce lock", func(t *testing.T) {
+			client := &hangingClient{Backend: c.autoMining(), blocked: map[string]bool{method: true}}
+			sender := newSender(t, client, map[string]common.Address{"eth": {}}, Options{RPCTimeout: 50 * time.Millisecond})
+
+			start := time.Now()
+			_, err := sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+			assert.Less(t, time.Since(start), 5*time.Second)
+
+			// The lock was released, so the next transfer gets through
+			client.blocked[method] = false
+			_, err = sender.Transfer(ctx, recipient, "eth", decimal.NewFromInt(1))
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("operational check", func(t *testing.T) {
+		client := &hangingClient{Backend: c.client, blocked: map[string]bool{"HeaderByNumber": true}}
+		sender := newSender(t, client, map[string]common.Address{"eth": {}}, Options{RPCTimeout: 50 * time.Millisecond})
+
+		assert.ErrorIs(t, sender.CheckOperational(ctx, "eth"), ErrUnavailable)
+		assert.ErrorIs(t, sender.CheckOperational(ctx, "weth"), ErrUnsupportedAsset)
+
+		client.blocked["HeaderByNumber"] = false
+		assert.NoError(t, sender.CheckOperational(ctx, "eth"))
+	})
+}
@@ -0,0 +1,115 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/activity"
+	"faucet-server/internal/logger"
+)
+
+// sseKeepAlive keeps idle streams from being closed by proxies.
+const sseKeepAlive = 15 * time.Second
+
+type RecentResponse struct {
+	Events []activity.Entry `json:"events"`
+}
+
+// setupActivityFeed prepares the public dispensation feed unless disabled.
+func (s *Server) setupActivityFeed() {
+	if size := s.Config().ActivityFeedSize; size > 0 {
+		s.activity = activity.NewFeed(size)
+	}
+}
+
+// publishActivity adds a successful dispensation to the public feed.
+func (s *Server) publishActivity(address, amount, asset, txID string) {
+	if s.activity != nil {
+		s.activity.Publish(address, amount, asset, txID)
+	}
+}
+
+// getRecent returns the buffered dispensations, newest first.
+func (s *Server) getRecent(c *gin.Context) {
+	events := s.activity.Recent()
+	slices.Reverse(events)
+
+	c.JSON(http.StatusOK, RecentResponse{Events: events})
+}
+
+// streamEvents serves dispensations as Server-Sent Events. New subscribers
+// first receive the buffered entries; reconnecting clients sending
+// Last-Event-ID only receive the entries they missed. An ID above any issued
+// by this process stems from another one, e.g. from before a restart with a
+// clock set back, and gets the full backlog.
+// Streams end when the server shuts down, so they don't hold up a graceful
+// shutdown.
+func (s *Server) streamEvents(c *gin.Context) {
+	recent, entries, cancel, err := s.activity.Subscribe(s.Config().ActivityMaxSubscribers)
+	if err != nil {
+		logger.Warnf("Rejected event stream from %s: %v", c.ClientIP(), err)
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeServiceUnavailable, ErrServiceUnavailable))
+		return
+	}
+	defer cancel()
+
+	lastID, _ := strconv.ParseUint(c.GetHeader("Last-Event-ID"), 10, 64)
+	if len(recent) > 0 && lastID > recent[len(recent)-1].ID {
+		lastID = 0
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	// Stop nginx from buffering the stream
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	for _, entry := range recent {
+		if entry.ID > lastID {
+			if err := writeSSE(c.Writer, entry); err != nil {
+				return
+			}
+		}
+	}
+	c.Writer.Flush()
+
+	keepAlive := time.NewTicker(sseKeepAlive)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-s.shutdownCtx.Done():
+			return
+		case entry := <-entries:
+			if err := writeSSE(c.Writer, entry); err != nil {
+				logger.Debugf("Closing event stream: %v", err)
+				return
+			}
+		case <-keepAlive.C:
+			if _, err := io.WriteString(c.Writer, ": keep-alive\n\n"); err != nil {
+				return
+			}
+		}
+		c.Writer.Flush()
+	}
+}
+
+func writeSSE(w io.Writer, entry activity.Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %d\nevent: dispensation\ndata: %s\n\n", entry.ID, data)
+	return err
+}
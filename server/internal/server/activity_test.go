@@ -0,0 +1,193 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/activity"
+	"faucet-server/internal/config"
+)
+
+func enableActivityFeed(cfg *config.Config) {
+	cfg.ActivityFeedSize = 5
+	cfg.ActivityMaxSubscribers = 10
+}
+
+func TestRecentActivity(t *testing.T) {
+	server, mockClearnode := newTestServer(t, enableActivityFeed)
+
+	for _, address := range []string{
+		"0x742D35CC6634c0532925a3B8c17D18fBe3b78890",
+		"0x9fc51BEE23Fb53569c46CcF013400f0E19524bd2",
+	} {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	w := doJSON(t, server, "GET", "/recent", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response RecentResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	require.Len(t, response.Events, 2)
+
+	latest := response.Events[0]
+	assert.Equal(t, "0x9fc5…4bd2", latest.Address, "addresses are anonymised, newest first")
+	assert.Equal(t, "10", latest.Amount)
+	assert.Equal(t, "usdc", latest.Asset)
+	assert.Equal(t, txID(mockClearnode.LastTransfer()), latest.TxID)
+	assert.NotContains(t, w.Body.String(), "0x9fc51BEE23Fb53569c46CcF013400f0E19524bd2")
+}
+
+func TestEventStream(t *testing.T) {
+	server, mockClearnode := newTestServer(t, enableActivityFeed)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+	w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", httpServer.URL+"/events", nil)
+	require.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	reader := bufio.NewReader(resp.Body)
+	nextEntry := func(t *testing.T) activity.Entry {
+		t.Helper()
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var entry activity.Entry
+				require.NoError(t, json.Unmarshal([]byte(data), &entry))
+				return entry
+			}
+		}
+	}
+
+	backlog := nextEntry(t)
+	assert.Equal(t, "0x742D…8890", backlog.Address)
+
+	require.Eventually(t, func() bool { return server.activity.Subscribers() == 1 }, time.Second, 5*time.Millisecond)
+
+	w = doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	live := nextEntry(t)
+	assert.Equal(t, backlog.ID+1, live.ID)
+	assert.Equal(t, txID(mockClearnode.LastTransfer()), live.TxID)
+}
+
+func TestEventStreamLastEventID(t *testing.T) {
+	server, _ := newTestServer(t, enableActivityFeed)
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	for range 2 {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+	recent := server.activity.Recent()
+	require.Len(t, recent, 2)
+
+	// firstReplayed connects with lastEventID and returns the ID of the
+	// first entry replayed
+	firstReplayed := func(t *testing.T, lastEventID uint64) uint64 {
+		t.Helper()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		req, err := http.NewRequestWithContext(ctx, "GET", httpServer.URL+"/events", nil)
+		require.NoError(t, err)
+		req.Header.Set("Last-Event-ID", strconv.FormatUint(lastEventID, 10))
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if data, ok := strings.CutPrefix(line, "data: "); ok {
+				var entry activity.Entry
+				require.NoError(t, json.Unmarshal([]byte(data), &entry))
+				return entry.ID
+			}
+		}
+	}
+
+	t.Run("replays missed entries", func(t *testing.T) {
+		assert.Equal(t, recent[1].ID, firstReplayed(t, recent[0].ID))
+	})
+
+	t.Run("replays everything for an ID from an earlier process", func(t *testing.T) {
+		assert.Equal(t, recent[0].ID, firstReplayed(t, 42))
+	})
+
+	t.Run("replays everything for an ID above any issued", func(t *testing.T) {
+		assert.Equal(t, recent[0].ID, firstReplayed(t, recent[1].ID+1000))
+	})
+}
+
+func TestActivityFeedDisabled(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+
+	assert.Equal(t, http.StatusNotFound, doJSON(t, server, "GET", "/recent", nil, nil).Code)
+	assert.Equal(t, http.StatusNotFound, doJSON(t, server, "GET", "/events", nil, nil).Code)
+}
+
+func TestEventStreamLimits(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		enableActivityFeed(cfg)
+		cfg.ActivityMaxSubscribers = 1
+	})
+	httpServer := httptest.NewServer(server.router)
+	defer httpServer.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	subscribe := func(t *testing.T) *http.Response {
+		t.Helper()
+		req, err := http.NewRequestWithContext(ctx, "GET", httpServer.URL+"/events", nil)
+		require.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	stream := subscribe(t)
+	require.Equal(t, http.StatusOK, stream.StatusCode)
+	require.Eventually(t, func() bool { return server.activity.Subscribers() == 1 }, time.Second, 5*time.Millisecond)
+
+	rejected := subscribe(t)
+	assert.Equal(t, http.StatusServiceUnavailable, rejected.StatusCode)
+
+	// Draining ends open streams instead of leaving them to the shutdown timeout
+	server.Drain()
+	_, err := io.Copy(io.Discard, stream.Body)
+	require.NoError(t, err)
+	assert.Zero(t, server.activity.Subscribers())
+}
@@ -0,0 +1,328 @@
+package server
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/alert"
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+	"faucet-server/internal/webhook"
+)
+
+// pauseState describes why and since when the faucet stopped dispensing.
+type pauseState struct {
+	Message string
+	Since   time.Time
+}
+
+type AdminStatusResponse struct {
+	Paused            bool       `json:"paused"`
+	PauseMessage      string     `json:"pauseMessage,omitempty"`
+	PausedAt          *time.Time `json:"pausedAt,omitempty"`
+	TokenSymbol       string     `json:"tokenSymbol"`
+	StandardTipAmount string     `json:"standardTipAmount"`
+	// Set while the tip amount comes from PUT /admin/tip-amount. The change
+	// is runtime-only: a restart or SIGHUP reload reverts it.
+	TipAmountOverridden bool                       `json:"tipAmountOverridden,omitempty"`
+	MinTransferCount    int                        `json:"minTransferCount"`
+	BalanceLevel        alert.Level                `json:"balanceLevel,omitempty"`
+	Clearnode           clearnode.ConnectionStatus `json:"clearnode"`
+}
+
+type PauseRequest struct {
+	Message string `json:"message"`
+}
+
+type TipAmountRequest struct {
+	Amount string `json:"amount" binding:"required"`
+}
+
+type AddressListRequest struct {
+	Reason string `json:"reason"`
+}
+
+type AddressListResponse struct {
+	List      store.AddressList    `json:"list"`
+	Addresses []store.AddressEntry `json:"addresses"`
+}
+
+type AdminActionResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message,omitempty"`
+}
+
+func (s *Server) registerAdminRoutes(routes *gin.RouterGroup) {
+	cfg := s.Config()
+
+	var middleware []gin.HandlerFunc
+	if cfg.TLSClientCAFile != "" {
+		middleware = append(middleware, requireClientCert())
+	}
+	middleware = append(middleware, adminAuth(cfg.AdminToken))
+
+	admin := routes.Group("/admin", middleware...)
+	admin.GET("/status", s.adminStatus)
+	admin.POST("/pause", s.pauseFaucet)
+	admin.POST("/resume", s.resumeFaucet)
+	admin.PUT("/tip-amount", s.setTipAmount)
+	admin.POST("/check", s.checkOperational)
+	admin.POST("/reconnect", s.reconnectClearnode)
+
+	for _, list := range []store.AddressList{store.Allowlist, store.Denylist} {
+		path := "/" + string(list)
+		admin.GET(path, s.listAddresses(list))
+		admin.PUT(path+"/:address", s.addAddress(list))
+		admin.DELETE(path+"/:address", s.removeAddress(list))
+	}
+
+	admin.POST("/api-keys", s.createAPIKey)
+	admin.GET("/api-keys", s.listAPIKeys)
+	admin.GET("/api-keys/:id", s.getAPIKey)
+	admin.DELETE("/api-keys/:id", s.deleteAPIKey)
+
+	admin.POST("/airdrops", s.createAirdrop)
+	admin.GET("/airdrops", s.listAirdrops)
+	admin.GET("/airdrops/:id", s.getAirdrop)
+	admin.POST("/airdrops/:id/resume", s.resumeAirdrop)
+
+	admin.POST("/claim-codes", s.createClaimBatch)
+	admin.GET("/claim-codes", s.listClaimBatches)
+	admin.DELETE("/claim-codes/:id", s.deleteClaimBatch)
+}
+
+// Pause stops the faucet from dispensing; token requests are answered with
+// 503 and message until Resume is called. An empty message uses the default.
+func (s *Server) Pause(message string) {
+	if message == "" {
+		message = ErrFaucetPaused
+	}
+	pause := &pauseState{Message: message, Since: time.Now()}
+	s.pause.Store(pause)
+	logger.Warnf("Faucet paused: %s", message)
+	s.emitEvent(webhook.EventFaucetPaused, PauseEvent{Message: pause.Message, PausedAt: pause.Since})
+}
+
+// Resume re-enables dispensing after Pause.
+func (s *Server) Resume() {
+	if s.pause.Swap(nil) != nil {
+		logger.Info("Faucet resumed")
+	}
+}
+
+func (s *Server) adminStatus(c *gin.Context) {
+	c.JSON(http.StatusOK, s.buildAdminStatus())
+}
+
+func (s *Server) buildAdminStatus() AdminStatusResponse {
+	cfg := s.Config()
+	status := AdminStatusResponse{
+		TokenSymbol:       cfg.TokenSymbol,
+		StandardTipAmount: cfg.StandardTipAmountDecimal.String(),
+		MinTransferCount:  cfg.MinTransferCount,
+		Clearnode:         s.connectionStatus(),
+	}
+
+	s.reloadMu.Lock()
+	status.TipAmountOverridden = s.tipAmountOverridden
+	s.reloadMu.Unlock()
+
+	if s.balanceMonitor != nil {
+		status.BalanceLevel = s.balanceMonitor.Level(cfg.TokenSymbol)
+	}
+
+	if pause := s.pause.Load(); pause != nil {
+		status.Paused = true
+		status.PauseMessage = pause.Message
+		status.PausedAt = &pause.Since
+	}
+
+	return status
+}
+
+func (s *Server) pauseFaucet(c *gin.Context) {
+	var req PauseRequest
+	// The body is optional; an empty request pauses with the default message
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidAdminRequest))
+			return
+		}
+	}
+
+	s.Pause(strings.TrimSpace(req.Message))
+	c.JSON(http.StatusOK, s.buildAdminStatus())
+}
+
+func (s *Server) resumeFaucet(c *gin.Context) {
+	s.Resume()
+	c.JSON(http.StatusOK, s.buildAdminStatus())
+}
+
+func (s *Server) setTipAmount(c *gin.Context) {
+	var req TipAmountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidAdminRequest))
+		return
+	}
+
+	// Hold reloadMu across the copy so a concurrent update or SIGHUP
+	// reload isn't lost
+	s.reloadMu.Lock()
+	newCfg := *s.Config()
+	newCfg.StandardTipAmount = strings.TrimSpace(req.Amount)
+	if err := newCfg.Validate(); err != nil {
+		s.reloadMu.Unlock()
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if err := s.reload(&newCfg); err != nil {
+		s.reloadMu.Unlock()
+		logger.Errorf("Failed to apply tip amount %s: %v", newCfg.StandardTipAmount, err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+		return
+	}
+	s.tipAmountOverridden = true
+	s.reloadMu.Unlock()
+
+	c.JSON(http.StatusOK, s.buildAdminStatus())
+}
+
+func (s *Server) checkOperational(c *gin.Context) {
+	cfg := s.Config()
+	if err := s.ensureOperational(c.Request.Context(), cfg, cfg.TokenSymbol, cfg.StandardTipAmountDecimal); err != nil {
+		s.clearnodeAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminActionResponse{
+		Success: true,
+		Message: "Faucet is operational",
+	})
+}
+
+func (s *Server) reconnectClearnode(c *gin.Context) {
+	manager, ok := s.backend.(ConnectionManager)
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrNoConnection))
+		return
+	}
+
+	if err := manager.Reconnect(); err != nil {
+		logger.Errorf("Forced reconnect failed: %v", err)
+		s.clearnodeAdminError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, AdminActionResponse{
+		Success: true,
+		Message: "Reconnected to Clearnode",
+	})
+}
+
+func (s *Server) listAddresses(list store.AddressList) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, AddressListResponse{
+			List:      list,
+			Addresses: s.store.ListAddresses(list),
+		})
+	}
+}
+
+func (s *Server) addAddress(list store.AddressList) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, ok := addressParam(c)
+		if !ok {
+			return
+		}
+
+		var req AddressListRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidAdminRequest))
+				return
+			}
+		}
+
+		if err := s.store.AddAddress(list, address, strings.TrimSpace(req.Reason)); err != nil {
+			logger.Errorf("Failed to add %s to %s: %v", address.Hex(), list, err)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+			return
+		}
+
+		logger.Infof("Added %s to %s", address.Hex(), list)
+		c.JSON(http.StatusOK, AdminActionResponse{
+			Success: true,
+			Message: "Added " + address.Hex() + " to " + string(list),
+		})
+	}
+}
+
+func (s *Server) removeAddress(list store.AddressList) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		address, ok := addressParam(c)
+		if !ok {
+			return
+		}
+
+		removed, err := s.store.RemoveAddress(list, address)
+		if err != nil {
+			logger.Errorf("Failed to remove %s from %s: %v", address.Hex(), list, err)
+			c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+			return
+		}
+
+		if !removed {
+			c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrAddressNotListed))
+			return
+		}
+
+		logger.Infof("Removed %s from %s", address.Hex(), list)
+		c.JSON(http.StatusOK, AdminActionResponse{
+			Success: true,
+			Message: "Removed " + address.Hex() + " from " + string(list),
+		})
+	}
+}
+
+// clearnodeAdminError classifies err like a token request would, but reports
+// the underlying cause to the operator.
+func (s *Server) clearnodeAdminError(c *gin.Context, err error) {
+	status, response := backendError(err)
+	response.Error = err.Error()
+	c.JSON(status, response)
+}
+
+// addressParam parses the :address path parameter, answering 400 if it is invalid.
+func addressParam(c *gin.Context) (common.Address, bool) {
+	address := strings.TrimSpace(c.Param("address"))
+	if !common.IsHexAddress(address) {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidAddress, ErrInvalidAddressFormat))
+		return common.Address{}, false
+	}
+
+	return common.HexToAddress(address), true
+}
+
+// adminAuth requires a matching "Authorization: Bearer <token>" header.
+func adminAuth(token string) gin.HandlerFunc {
+	expected := []byte(token)
+
+	return func(c *gin.Context) {
+		provided, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(provided), expected) != 1 {
+			logger.Warnf("Unauthorized admin request: %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, newErrorResponse(CodeUnauthorized, ErrUnauthorized))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+)
+
+const testAdminToken = "test-admin-token-0123456789"
+
+func TestAdminAPI(t *testing.T) {
+	server, mockClearnode := newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	faucetRequest := FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}
+
+	t.Run("rejects missing or wrong token", func(t *testing.T) {
+		w := doJSON(t, server, "GET", "/admin/status", nil, nil)
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+
+		w = doJSON(t, server, "GET", "/admin/status", nil, map[string]string{"Authorization": "Bearer wrong"})
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("reports status", func(t *testing.T) {
+		w := doJSON(t, server, "GET", "/admin/status", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status AdminStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.False(t, status.Paused)
+		assert.Equal(t, "10", status.StandardTipAmount)
+		assert.True(t, status.Clearnode.Connected)
+		assert.True(t, status.Clearnode.Authenticated)
+		assert.Equal(t, mockClearnode.URL(), status.Clearnode.URL)
+	})
+
+	t.Run("pause and resume", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/pause", PauseRequest{Message: "Topping up, back soon"}, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, "Topping up, back soon", errorResponse.Error)
+		assert.Equal(t, CodeFaucetPaused, errorResponse.Code)
+		assert.True(t, errorResponse.Retryable)
+
+		w = doJSON(t, server, "POST", "/admin/resume", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("pause without body uses default message", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/pause", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		defer server.Resume()
+
+		var status AdminStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.True(t, status.Paused)
+		assert.Equal(t, ErrFaucetPaused, status.PauseMessage)
+	})
+
+	t.Run("changes tip amount", func(t *testing.T) {
+		w := doJSON(t, server, "PUT", "/admin/tip-amount", TipAmountRequest{Amount: "3.5"}, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status AdminStatusResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.Equal(t, "3.5", status.StandardTipAmount)
+		assert.True(t, status.TipAmountOverridden)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.True(t, decimal.RequireFromString("3.5").Equal(mockClearnode.LastTransfer().Amount))
+	})
+
+	t.Run("rejects invalid tip amount", func(t *testing.T) {
+		w := doJSON(t, server, "PUT", "/admin/tip-amount", TipAmountRequest{Amount: "-1"}, auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Equal(t, "3.5", server.Config().StandardTipAmountDecimal.String())
+	})
+
+	t.Run("reload reverts the tip amount", func(t *testing.T) {
+		reloaded := *server.Config()
+		reloaded.StandardTipAmount = "10"
+		require.NoError(t, reloaded.Validate())
+		require.NoError(t, server.Reload(&reloaded))
+
+		status := server.buildAdminStatus()
+		assert.Equal(t, "10", status.StandardTipAmount)
+		assert.False(t, status.TipAmountOverridden)
+	})
+
+	t.Run("runs operational check", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/check", nil, auth)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("forces reconnect", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/reconnect", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		assert.True(t, server.connectionStatus().Authenticated)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
+
+func TestAdminRoutesDisabledWithoutToken(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+
+	w := doJSON(t, server, "GET", "/admin/status", nil, map[string]string{"Authorization": "Bearer anything"})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestAddressLists(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+	faucetRequest := FaucetRequest{UserAddress: address}
+
+	t.Run("denylisted address gets 403", func(t *testing.T) {
+		w := doJSON(t, server, "PUT", "/admin/denylist/"+address, AddressListRequest{Reason: "abuse"}, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, ErrAddressDenied, errorResponse.Error)
+	})
+
+	t.Run("lists entries", func(t *testing.T) {
+		w := doJSON(t, server, "GET", "/admin/denylist", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response AddressListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Addresses, 1)
+		assert.Equal(t, address, response.Addresses[0].Address)
+		assert.Equal(t, "abuse", response.Addresses[0].Reason)
+	})
+
+	t.Run("removing from denylist restores access", func(t *testing.T) {
+		w := doJSON(t, server, "DELETE", "/admin/denylist/"+address, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(t, server, "DELETE", "/admin/denylist/"+address, nil, auth)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("rejects invalid address parameter", func(t *testing.T) {
+		w := doJSON(t, server, "PUT", "/admin/allowlist/not-an-address", nil, auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("allowlist-only mode serves only allowlisted addresses", func(t *testing.T) {
+		cfg := *server.Config()
+		cfg.AllowlistOnly = true
+		require.NoError(t, server.Reload(&cfg))
+
+		w := doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusForbidden, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, ErrAddressNotAllowlisted, errorResponse.Error)
+
+		w = doJSON(t, server, "PUT", "/admin/allowlist/"+address, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		w = doJSON(t, server, "POST", "/requestTokens", faucetRequest, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
@@ -0,0 +1,555 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+)
+
+// maxAirdropBodySize bounds uploads; 4 MiB holds far more rows than
+// AIRDROP_MAX_ROWS allows by default.
+const maxAirdropBodySize = 4 << 20
+
+const (
+	AirdropStatusRunning    = "running"
+	AirdropStatusCompleted  = "completed"
+	AirdropStatusIncomplete = "incomplete"
+)
+
+// AirdropRowRequest is one row of a JSON airdrop. Rows may also be given as
+// plain address strings.
+type AirdropRowRequest struct {
+	Address string `json:"address"`
+	Amount  string `json:"amount,omitempty"`
+
+	// Line of the upload the row was read from, reported in AirdropRowError
+	line int
+}
+
+// AirdropRowError reports an invalid row by its line in a CSV upload or its
+// position in a JSON array, both counted from 1.
+type AirdropRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+// AirdropRejectedResponse lists every invalid row so that the whole file
+// can be fixed in one go.
+type AirdropRejectedResponse struct {
+	ErrorResponse
+	Rows []AirdropRowError `json:"rows"`
+}
+
+// AirdropRowResult reports the outcome of a row, numbered like
+// AirdropRowError.
+type AirdropRowResult struct {
+	Row     int                    `json:"row"`
+	Address string                 `json:"address"`
+	Amount  string                 `json:"amount"`
+	Status  store.AirdropRowStatus `json:"status"`
+	TxID    string                 `json:"txId,omitempty"`
+	Error   string                 `json:"error,omitempty"`
+}
+
+type AirdropResponse struct {
+	ID        string                         `json:"id"`
+	Asset     string                         `json:"asset"`
+	Status    string                         `json:"status"`
+	Total     string                         `json:"total"`
+	Counts    map[store.AirdropRowStatus]int `json:"counts"`
+	CreatedAt time.Time                      `json:"createdAt"`
+	UpdatedAt time.Time                      `json:"updatedAt"`
+	Rows      []AirdropRowResult             `json:"rows,omitempty"`
+}
+
+type AirdropListResponse struct {
+	Airdrops []AirdropResponse `json:"airdrops"`
+}
+
+// createAirdrop validates an uploaded batch, checks that the faucet can
+// cover it and starts its transfers in the background. The response reports
+// the airdrop as running; its outcome is polled with getAirdrop. Rows left
+// pending or failed, e.g. because the faucet was paused or shut down, are
+// picked up by resumeAirdrop.
+func (s *Server) createAirdrop(c *gin.Context) {
+	cfg := s.Config()
+
+	if !s.checkAirdropPause(c) {
+		return
+	}
+
+	requests, err := readAirdropRows(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if len(requests) == 0 {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrAirdropEmpty))
+		return
+	}
+
+	if cfg.AirdropMaxRows > 0 && len(requests) > cfg.AirdropMaxRows {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, fmt.Sprintf(ErrAirdropTooLarge, cfg.AirdropMaxRows)))
+		return
+	}
+
+	defaultAmount := cfg.StandardTipAmountDecimal
+	if value := strings.TrimSpace(c.Query("amount")); value != "" {
+		defaultAmount, err = decimal.NewFromString(value)
+		if err != nil || !defaultAmount.IsPositive() {
+			c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, "amount must be a positive number"))
+			return
+		}
+	}
+
+	asset := normalizeAsset(cfg.TokenSymbol)
+	if value := normalizeAsset(c.Query("asset")); value != "" {
+		asset = value
+	}
+
+	rows, rowErrors := validateAirdropRows(requests, defaultAmount)
+	if len(rowErrors) > 0 {
+		c.JSON(http.StatusBadRequest, AirdropRejectedResponse{
+			ErrorResponse: newErrorResponse(CodeInvalidRequest, ErrAirdropRowsInvalid),
+			Rows:          rowErrors,
+		})
+		return
+	}
+
+	if !s.checkAsset(c, asset) || !s.checkAirdropBalance(c, asset, rows, nil) {
+		return
+	}
+
+	airdrop, err := s.store.CreateAirdrop(asset, rows)
+	if err != nil {
+		logger.Errorf("Failed to store airdrop: %v", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+		return
+	}
+
+	// The airdrop was only just created, so no resume can be running it
+	s.startAirdrop(airdrop.ID)
+	logger.Infof("Starting airdrop %s of %d transfers", airdrop.ID, len(airdrop.Rows))
+	s.launchAirdrop(airdrop)
+
+	s.respondAirdrop(c, http.StatusAccepted, airdrop.ID)
+}
+
+// resumeAirdrop starts retrying the pending and failed rows of an airdrop
+// in the background. Rows whose transfer timed out may have gone through
+// and are left alone.
+func (s *Server) resumeAirdrop(c *gin.Context) {
+	if !s.checkAirdropPause(c) {
+		return
+	}
+
+	id := c.Param("id")
+	if !s.startAirdrop(id) {
+		c.JSON(http.StatusConflict, newErrorResponse(CodeAirdropInProgress, ErrAirdropInProgress))
+		return
+	}
+
+	// Read the rows only now that no other run can change them
+	airdrop, ok := s.store.GetAirdrop(id)
+	if !ok {
+		s.finishAirdrop(id)
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrAirdropNotFound))
+		return
+	}
+
+	remaining := airdrop.Remaining()
+	if len(remaining) == 0 {
+		s.finishAirdrop(id)
+		s.respondAirdrop(c, http.StatusOK, id)
+		return
+	}
+
+	if !s.checkAirdropBalance(c, airdrop.Asset, airdrop.Rows, remaining) {
+		s.finishAirdrop(id)
+		return
+	}
+
+	logger.Infof("Resuming airdrop %s with %d transfers left", airdrop.ID, len(remaining))
+	s.launchAirdrop(airdrop)
+
+	s.respondAirdrop(c, http.StatusAccepted, id)
+}
+
+// launchAirdrop runs an airdrop the caller has marked as running until its
+// rows are done or the server stops. The run is detached from the request
+// so that it outlives the admin's connection.
+func (s *Server) launchAirdrop(airdrop store.Airdrop) {
+	if !s.beginWork() {
+		logger.Infof("Server is shutting down, airdrop %s stays pending for a resume", airdrop.ID)
+		s.finishAirdrop(airdrop.ID)
+		return
+	}
+
+	go func() {
+		defer s.inFlight.Done()
+		defer s.finishAirdrop(airdrop.ID)
+		s.runAirdrop(s.shutdownCtx, airdrop)
+	}()
+}
+
+// checkAirdropPause writes the error response and returns false while the
+// faucet is paused. Airdrops spend from the same balance as dispensing, so
+// a pause for maintenance or an incident stops them too.
+func (s *Server) checkAirdropPause(c *gin.Context) bool {
+	if pause := s.pause.Load(); pause != nil {
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeFaucetPaused, pause.Message))
+		return false
+	}
+	return true
+}
+
+func (s *Server) getAirdrop(c *gin.Context) {
+	airdrop, ok := s.store.GetAirdrop(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrAirdropNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, s.newAirdropResponse(airdrop, true))
+}
+
+func (s *Server) listAirdrops(c *gin.Context) {
+	airdrops := s.store.ListAirdrops()
+
+	response := AirdropListResponse{Airdrops: make([]AirdropResponse, 0, len(airdrops))}
+	for _, airdrop := range airdrops {
+		response.Airdrops = append(response.Airdrops, s.newAirdropResponse(airdrop, false))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) respondAirdrop(c *gin.Context, status int, id string) {
+	airdrop, ok := s.store.GetAirdrop(id)
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrAirdropNotFound))
+		return
+	}
+
+	c.JSON(status, s.newAirdropResponse(airdrop, true))
+}
+
+// checkAirdropBalance writes the error response and returns false if the
+// faucet balance doesn't cover the rows at indexes (all rows when nil).
+func (s *Server) checkAirdropBalance(c *gin.Context, asset string, rows []store.AirdropRow, indexes []int) bool {
+	total := decimal.Zero
+	if indexes == nil {
+		for _, row := range rows {
+			total = total.Add(row.Amount)
+		}
+	} else {
+		for _, i := range indexes {
+			total = total.Add(rows[i].Amount)
+		}
+	}
+
+	balance, err := s.fetchBalance(c.Request.Context(), asset)
+	if err != nil {
+		logger.Errorf("Failed to fetch %s balance for airdrop: %v", asset, err)
+		c.JSON(backendError(err))
+		return false
+	}
+
+	if balance.LessThan(total) {
+		message := fmt.Sprintf(ErrAirdropExceedsBalance, balance, asset, total)
+		logger.Warnf("Rejected airdrop: %s", message)
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeInsufficientFaucetBalance, message))
+		return false
+	}
+
+	return true
+}
+
+// runAirdrop sends the remaining rows of airdrop, at most
+// AIRDROP_CONCURRENCY at a time, recording each outcome as it arrives.
+// When ctx is cancelled or the faucet is paused no further transfers are
+// started.
+func (s *Server) runAirdrop(ctx context.Context, airdrop store.Airdrop) {
+	slots := make(chan struct{}, max(s.Config().AirdropConcurrency, 1))
+	var wg sync.WaitGroup
+
+	for _, index := range airdrop.Remaining() {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+		}
+		// Checked after acquiring a slot too, as select picks randomly
+		// when both are ready
+		if ctx.Err() != nil {
+			logger.Warnf("Airdrop %s interrupted: %v", airdrop.ID, ctx.Err())
+			break
+		}
+		if s.pause.Load() != nil {
+			logger.Warnf("Airdrop %s interrupted: faucet paused", airdrop.ID)
+			break
+		}
+
+		wg.Add(1)
+		go func(index int) {
+			defer wg.Done()
+			defer func() { <-slots }()
+			s.sendAirdropRow(ctx, airdrop.ID, airdrop.Asset, index, airdrop.Rows[index])
+		}(index)
+	}
+
+	wg.Wait()
+}
+
+// sendAirdropRow sends the row at index through the transfer path shared
+// with the endpoints and records the outcome. The row is recorded as
+// sending first, so that it is not sent again if the process dies before
+// the outcome is recorded. Cancelling ctx abandons the transfer, leaving its
+// outcome unknown.
+func (s *Server) sendAirdropRow(ctx context.Context, id, asset string, index int, row store.AirdropRow) {
+	if err := s.store.MarkAirdropRowSending(id, index); err != nil {
+		logger.Errorf("Failed to record airdrop %s row %d, not sending it: %v", id, airdropRowNumber(row, index), err)
+		return
+	}
+
+	txID, err := s.sendTransfer(ctx, s.Config(), dispenseRequest{
+		address:  row.Address,
+		asset:    asset,
+		amount:   row.Amount,
+		budgeted: true,
+	})
+
+	var notSent *notSentError
+	switch {
+	case err == nil:
+		row.Status = store.AirdropRowSent
+		row.Error = ""
+		row.TxID = txID
+	case errors.Is(err, errShuttingDown), errors.As(err, &notSent) && ctx.Err() != nil:
+		// Nothing was sent, so the row is left for a resume
+		row.Status = store.AirdropRowPending
+	case errors.As(err, &notSent):
+		row.Status = store.AirdropRowFailed
+		row.Error = err.Error()
+	case transferTimedOut(err), ctx.Err() != nil:
+		row.Status = store.AirdropRowUnknown
+		row.Error = err.Error()
+		row.TxID = txID
+	default:
+		row.Status = store.AirdropRowFailed
+		row.Error = err.Error()
+	}
+
+	if err != nil {
+		logger.Errorf("Airdrop %s: transfer to %s failed: %v", id, row.Address, err)
+	}
+
+	if err := s.store.UpdateAirdropRow(id, index, row); err != nil {
+		logger.Errorf("Failed to record airdrop %s row %d, retrying: %v", id, airdropRowNumber(row, index), err)
+	}
+}
+
+// startAirdrop marks an airdrop as running, or returns false if it already is.
+func (s *Server) startAirdrop(id string) bool {
+	s.airdropsMu.Lock()
+	defer s.airdropsMu.Unlock()
+
+	if _, running := s.runningAirdrops[id]; running {
+		return false
+	}
+	s.runningAirdrops[id] = struct{}{}
+	return true
+}
+
+func (s *Server) finishAirdrop(id string) {
+	s.airdropsMu.Lock()
+	defer s.airdropsMu.Unlock()
+
+	delete(s.runningAirdrops, id)
+}
+
+func (s *Server) airdropRunning(id string) bool {
+	s.airdropsMu.Lock()
+	defer s.airdropsMu.Unlock()
+
+	_, running := s.runningAirdrops[id]
+	return running
+}
+
+func (s *Server) newAirdropResponse(airdrop store.Airdrop, withRows bool) AirdropResponse {
+	response := AirdropResponse{
+		ID:        airdrop.ID,
+		Asset:     airdrop.Asset,
+		Status:    AirdropStatusCompleted,
+		Counts:    make(map[store.AirdropRowStatus]int),
+		CreatedAt: airdrop.CreatedAt,
+		UpdatedAt: airdrop.UpdatedAt,
+	}
+
+	total := decimal.Zero
+	for i, row := range airdrop.Rows {
+		total = total.Add(row.Amount)
+		response.Counts[row.Status]++
+		if row.Status != store.AirdropRowSent {
+			response.Status = AirdropStatusIncomplete
+		}
+
+		if withRows {
+			response.Rows = append(response.Rows, AirdropRowResult{
+				Row:     airdropRowNumber(row, i),
+				Address: row.Address,
+				Amount:  row.Amount.String(),
+				Status:  row.Status,
+				TxID:    row.TxID,
+				Error:   row.Error,
+			})
+		}
+	}
+	response.Total = total.String()
+
+	if s.airdropRunning(airdrop.ID) {
+		response.Status = AirdropStatusRunning
+	}
+	return response
+}
+
+// readAirdropRows reads the uploaded rows: a CSV file in a multipart form
+// field named "file", a text/csv body, or a JSON array.
+func readAirdropRows(c *gin.Context) ([]AirdropRowRequest, error) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAirdropBodySize)
+
+	switch c.ContentType() {
+	case "multipart/form-data":
+		header, err := c.FormFile("file")
+		if err != nil {
+			return nil, fmt.Errorf("multipart upload must contain a CSV file named \"file\"")
+		}
+		file, err := header.Open()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read uploaded file: %w", err)
+		}
+		defer file.Close()
+		return readAirdropCSV(file)
+	case "text/csv", "text/plain":
+		return readAirdropCSV(c.Request.Body)
+	default:
+		return readAirdropJSON(c.Request.Body)
+	}
+}
+
+// readAirdropCSV reads address[,amount] records. A header row is skipped
+// and lines starting with # are comments. Each row keeps its line number so
+// that errors point at the right line of the file.
+func readAirdropCSV(r io.Reader) ([]AirdropRowRequest, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	var rows []AirdropRowRequest
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %w", err)
+		}
+
+		address := strings.TrimSpace(record[0])
+		if len(rows) == 0 && strings.EqualFold(address, "address") {
+			continue
+		}
+
+		line, _ := reader.FieldPos(0)
+		row := AirdropRowRequest{Address: address, line: line}
+		if len(record) > 1 {
+			row.Amount = strings.TrimSpace(record[1])
+		}
+		rows = append(rows, row)
+	}
+}
+
+func readAirdropJSON(r io.Reader) ([]AirdropRowRequest, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(r).Decode(&raw); err != nil {
+		return nil, fmt.Errorf("body must be a JSON array of addresses or {\"address\", \"amount\"} objects")
+	}
+
+	rows := make([]AirdropRowRequest, len(raw))
+	for i, element := range raw {
+		var err error
+		if bytes.HasPrefix(bytes.TrimSpace(element), []byte(`"`)) {
+			err = json.Unmarshal(element, &rows[i].Address)
+		} else {
+			err = json.Unmarshal(element, &rows[i])
+		}
+		if err != nil {
+			return nil, fmt.Errorf("row %d must be an address or an {\"address\", \"amount\"} object", i+1)
+		}
+		rows[i].line = i + 1
+	}
+	return rows, nil
+}
+
+// validateAirdropRows checks every row and returns either the rows to send
+// or all problems found.
+func validateAirdropRows(requests []AirdropRowRequest, defaultAmount decimal.Decimal) ([]store.AirdropRow, []AirdropRowError) {
+	rows := make([]store.AirdropRow, 0, len(requests))
+	var rowErrors []AirdropRowError
+
+	for i, request := range requests {
+		line := request.line
+		if line == 0 {
+			line = i + 1
+		}
+
+		address := strings.TrimSpace(request.Address)
+		if !common.IsHexAddress(address) {
+			rowErrors = append(rowErrors, AirdropRowError{Row: line, Error: fmt.Sprintf("invalid address %q", address)})
+			continue
+		}
+
+		amount := defaultAmount
+		if value := strings.TrimSpace(request.Amount); value != "" {
+			parsed, err := decimal.NewFromString(value)
+			if err != nil || !parsed.IsPositive() {
+				rowErrors = append(rowErrors, AirdropRowError{Row: line, Error: fmt.Sprintf("invalid amount %q", value)})
+				continue
+			}
+			amount = parsed
+		}
+
+		rows = append(rows, store.AirdropRow{
+			Line:    line,
+			Address: common.HexToAddress(address).Hex(),
+			Amount:  amount,
+		})
+	}
+
+	return rows, rowErrors
+}
+
+// airdropRowNumber returns the number under which the row at index is
+// reported: its line in the upload, or its position for airdrops stored
+// without one.
+func airdropRowNumber(row store.AirdropRow, index int) int {
+	if row.Line > 0 {
+		return row.Line
+	}
+	return index + 1
+}
@@ -0,0 +1,357 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnodetest"
+	"faucet-server/internal/config"
+	"faucet-server/internal/store"
+)
+
+func TestAirdrops(t *testing.T) {
+	server, mockClearnode := newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		cfg.AirdropConcurrency = 2
+		cfg.AirdropMaxRows = 5
+	})
+
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	addresses := []string{
+		"0x742D35CC6634c0532925a3B8c17D18fBe3b78890",
+		"0x8ba1f109551bD432803012645Ac136ddd64DBA72",
+		"0x0000000000000000000000000000000000000001",
+	}
+
+	upload := func(t *testing.T, ctx context.Context, path, contentType string, body []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest("POST", path, bytes.NewReader(body)).WithContext(ctx)
+		req.Header.Set("Content-Type", contentType)
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+
+		w := httptest.NewRecorder()
+		server.router.ServeHTTP(w, req)
+		return w
+	}
+
+	decode := func(t *testing.T, w *httptest.ResponseRecorder) AirdropResponse {
+		t.Helper()
+		var response AirdropResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return response
+	}
+
+	t.Run("sends a JSON batch", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/airdrops?amount=3", []interface{}{
+			addresses[0],
+			map[string]string{"address": addresses[1], "amount": "7.5"},
+		}, auth)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+		response := waitForAirdrop(t, server, decode(t, w).ID)
+		assert.Equal(t, AirdropStatusCompleted, response.Status)
+		assert.Equal(t, "usdc", response.Asset)
+		assert.Equal(t, "10.5", response.Total)
+		assert.Equal(t, 2, response.Counts[store.AirdropRowSent])
+		require.Len(t, response.Rows, 2)
+		assert.Equal(t, "3", response.Rows[0].Amount)
+		assert.Equal(t, "7.5", response.Rows[1].Amount)
+		assert.Equal(t, 1, response.Rows[0].Row)
+		assert.Equal(t, 2, response.Rows[1].Row)
+		for _, row := range response.Rows {
+			assert.Equal(t, store.AirdropRowSent, row.Status)
+			assert.NotEmpty(t, row.TxID)
+		}
+	})
+
+	t.Run("accepts CSV bodies and uploads", func(t *testing.T) {
+		csvBody := []byte("address,amount\n# workshop attendees\n" + addresses[0] + ",2\n" + addresses[2] + "\n")
+
+		w := upload(t, context.Background(), "/admin/airdrops", "text/csv", csvBody)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		response := waitForAirdrop(t, server, decode(t, w).ID)
+		assert.Equal(t, "12", response.Total)
+		assert.Equal(t, 2, response.Counts[store.AirdropRowSent])
+		require.Len(t, response.Rows, 2)
+		assert.Equal(t, 3, response.Rows[0].Row)
+		assert.Equal(t, 4, response.Rows[1].Row)
+
+		var form bytes.Buffer
+		writer := multipart.NewWriter(&form)
+		part, err := writer.CreateFormFile("file", "attendees.csv")
+		require.NoError(t, err)
+		_, err = part.Write(csvBody)
+		require.NoError(t, err)
+		require.NoError(t, writer.Close())
+
+		w = upload(t, context.Background(), "/admin/airdrops", writer.FormDataContentType(), form.Bytes())
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		assert.Equal(t, 2, waitForAirdrop(t, server, decode(t, w).ID).Counts[store.AirdropRowSent])
+	})
+
+	t.Run("reports every invalid row", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/airdrops", []AirdropRowRequest{
+			{Address: addresses[0]},
+			{Address: "0x1234"},
+			{Address: addresses[1], Amount: "-1"},
+		}, auth)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response AirdropRejectedResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeInvalidRequest, response.Code)
+		require.Len(t, response.Rows, 2)
+		assert.Equal(t, 2, response.Rows[0].Row)
+		assert.Equal(t, 3, response.Rows[1].Row)
+	})
+
+	t.Run("reports invalid CSV rows by line", func(t *testing.T) {
+		csvBody := []byte("address,amount\n# workshop attendees\n" + addresses[0] + ",2\n0x1234\n" + addresses[1] + ",-1\n")
+
+		w := upload(t, context.Background(), "/admin/airdrops", "text/csv", csvBody)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var response AirdropRejectedResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Len(t, response.Rows, 2)
+		assert.Equal(t, 4, response.Rows[0].Row)
+		assert.Equal(t, 5, response.Rows[1].Row)
+	})
+
+	t.Run("rejects empty, oversized and malformed batches", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/airdrops", []string{}, auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(t, server, "POST", "/admin/airdrops", []string{addresses[0], addresses[0], addresses[0], addresses[0], addresses[0], addresses[0]}, auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		w = doJSON(t, server, "POST", "/admin/airdrops", map[string]string{"address": addresses[0]}, auth)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+
+	t.Run("rejects unsupported assets", func(t *testing.T) {
+		stored := len(server.store.ListAirdrops())
+		w := doJSON(t, server, "POST", "/admin/airdrops?asset=doge", []string{addresses[0]}, auth)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeAssetNotAllowed, response.Code)
+		assert.Len(t, server.store.ListAirdrops(), stored)
+	})
+
+	t.Run("rejects batches exceeding the faucet balance", func(t *testing.T) {
+		stored := len(server.store.ListAirdrops())
+		w := doJSON(t, server, "POST", "/admin/airdrops?amount=600000000", []string{addresses[0], addresses[1]}, auth)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeInsufficientFaucetBalance, response.Code)
+		assert.Len(t, server.store.ListAirdrops(), stored, "rejected batches are not stored")
+	})
+
+	t.Run("resumes failed transfers", func(t *testing.T) {
+		mockClearnode.InjectFault(clearnodetest.MethodTransfer, clearnodetest.Fault{Error: "transfer rejected", Times: len(addresses)})
+		w := doJSON(t, server, "POST", "/admin/airdrops", addresses, auth)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		response := waitForAirdrop(t, server, decode(t, w).ID)
+		assert.Equal(t, AirdropStatusIncomplete, response.Status)
+		assert.Equal(t, 3, response.Counts[store.AirdropRowFailed])
+		assert.Contains(t, response.Rows[0].Error, "transfer rejected")
+
+		w = doJSON(t, server, "POST", "/admin/airdrops/"+response.ID+"/resume", nil, auth)
+		require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+		resumed := waitForAirdrop(t, server, response.ID)
+		assert.Equal(t, AirdropStatusCompleted, resumed.Status)
+		assert.Equal(t, 3, resumed.Counts[store.AirdropRowSent])
+		assert.Empty(t, resumed.Rows[0].Error)
+	})
+
+	t.Run("outlives the request", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		body, err := json.Marshal(addresses)
+		require.NoError(t, err)
+		w := upload(t, ctx, "/admin/airdrops", "application/json", body)
+		require.Equal(t, http.StatusAccepted, w.Code)
+
+		response := waitForAirdrop(t, server, decode(t, w).ID)
+		assert.Equal(t, 3, response.Counts[store.AirdropRowSent])
+	})
+
+	t.Run("resumes interrupted runs", func(t *testing.T) {
+		airdrop := createPendingAirdrop(t, server, addresses)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		server.runAirdrop(ctx, airdrop)
+
+		w := doJSON(t, server, "GET", "/admin/airdrops/"+airdrop.ID, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		response := decode(t, w)
+		assert.Equal(t, AirdropStatusIncomplete, response.Status)
+		assert.Equal(t, 3, response.Counts[store.AirdropRowPending])
+
+		w = doJSON(t, server, "POST", "/admin/airdrops/"+airdrop.ID+"/resume", nil, auth)
+		require.Equal(t, http.StatusAccepted, w.Code)
+		assert.Equal(t, 3, waitForAirdrop(t, server, airdrop.ID).Counts[store.AirdropRowSent])
+
+		w = doJSON(t, server, "POST", "/admin/airdrops/"+airdrop.ID+"/resume", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code, "nothing left to retry")
+		assert.Equal(t, AirdropStatusCompleted, decode(t, w).Status)
+	})
+
+	t.Run("stops while the faucet is paused", func(t *testing.T) {
+		airdrop := createPendingAirdrop(t, server, addresses)
+		server.pause.Store(&pauseState{Message: "Topping up"})
+		defer server.pause.Store(nil)
+
+		w := doJSON(t, server, "POST", "/admin/airdrops", addresses, auth)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+		var rejected ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &rejected))
+		assert.Equal(t, CodeFaucetPaused, rejected.Code)
+		assert.Equal(t, "Topping up", rejected.Error)
+
+		w = doJSON(t, server, "POST", "/admin/airdrops/"+airdrop.ID+"/resume", nil, auth)
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		server.runAirdrop(context.Background(), airdrop)
+		stored, ok := server.store.GetAirdrop(airdrop.ID)
+		require.True(t, ok)
+		assert.Equal(t, 3, server.newAirdropResponse(stored, false).Counts[store.AirdropRowPending])
+	})
+
+	t.Run("refuses to resume a running airdrop", func(t *testing.T) {
+		airdrop, err := server.store.CreateAirdrop("usdc", nil)
+		require.NoError(t, err)
+
+		require.True(t, server.startAirdrop(airdrop.ID))
+		w := doJSON(t, server, "POST", "/admin/airdrops/"+airdrop.ID+"/resume", nil, auth)
+		assert.Equal(t, http.StatusConflict, w.Code)
+		server.finishAirdrop(airdrop.ID)
+
+		w = doJSON(t, server, "POST", "/admin/airdrops/missing/resume", nil, auth)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("lists airdrops without rows", func(t *testing.T) {
+		w := doJSON(t, server, "GET", "/admin/airdrops", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var list AirdropListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.NotEmpty(t, list.Airdrops)
+		for _, airdrop := range list.Airdrops {
+			assert.Empty(t, airdrop.Rows)
+		}
+	})
+}
+
+func TestAirdropTransferPath(t *testing.T) {
+	fake := &fakeClearnode{balance: decimal.NewFromInt(1000)}
+	server := newFakeBackedServer(t, fake, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		cfg.HourlyBudgetDecimal = map[string]decimal.Decimal{"usdc": decimal.NewFromInt(5)}
+		enableActivityFeed(cfg)
+	})
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+
+	w := doJSON(t, server, "POST", "/admin/airdrops?amount=3", []string{
+		"0x742D35CC6634c0532925a3B8c17D18fBe3b78890",
+		"0x8ba1f109551bD432803012645Ac136ddd64DBA72",
+	}, auth)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var created AirdropResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	response := waitForAirdrop(t, server, created.ID)
+
+	// Rows count against the budgets and appear in the activity feed like
+	// any other transfer
+	assert.Equal(t, 1, response.Counts[store.AirdropRowSent])
+	assert.Equal(t, 1, response.Counts[store.AirdropRowFailed])
+	assert.Len(t, fake.transfers, 1)
+	assert.Len(t, server.activity.Recent(), 1)
+	for _, row := range response.Rows {
+		if row.Status == store.AirdropRowFailed {
+			assert.Contains(t, row.Error, "budget for usdc exhausted")
+		}
+	}
+}
+
+func TestDrainAbandonsAirdropTransfers(t *testing.T) {
+	fake := &fakeClearnode{
+		balance:         decimal.NewFromInt(1000),
+		transferStarted: make(chan struct{}, 1),
+		transferGate:    make(chan struct{}),
+	}
+	server := newFakeBackedServer(t, fake, func(cfg *config.Config) { cfg.AdminToken = testAdminToken })
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+
+	w := doJSON(t, server, "POST", "/admin/airdrops", []string{"0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}, auth)
+	require.Equal(t, http.StatusAccepted, w.Code, w.Body.String())
+
+	var created AirdropResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+	<-fake.transferStarted
+
+	// The row is recorded before the transfer starts, so a crash now
+	// doesn't leave it to be sent again on resume
+	stored, ok := server.store.GetAirdrop(created.ID)
+	require.True(t, ok)
+	assert.Equal(t, store.AirdropRowSending, stored.Rows[0].Status)
+	assert.Empty(t, stored.Remaining())
+
+	drained := make(chan struct{})
+	go func() {
+		server.Drain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Drain waited for a stuck airdrop transfer")
+	}
+
+	response := waitForAirdrop(t, server, created.ID)
+	assert.Equal(t, 1, response.Counts[store.AirdropRowUnknown])
+}
+
+// createPendingAirdrop stores an airdrop of one unit per address without
+// running it.
+func createPendingAirdrop(t *testing.T, server *Server, addresses []string) store.Airdrop {
+	t.Helper()
+
+	rows := make([]store.AirdropRow, len(addresses))
+	for i, address := range addresses {
+		rows[i] = store.AirdropRow{Address: address, Amount: decimal.NewFromInt(1)}
+	}
+	airdrop, err := server.store.CreateAirdrop("usdc", rows)
+	require.NoError(t, err)
+	return airdrop
+}
+
+// waitForAirdrop waits until the background run of an airdrop has finished
+// and returns its report.
+func waitForAirdrop(t *testing.T, server *Server, id string) AirdropResponse {
+	t.Helper()
+
+	require.Eventually(t, func() bool { return !server.airdropRunning(id) }, 10*time.Second, 5*time.Millisecond)
+	airdrop, ok := server.store.GetAirdrop(id)
+	require.True(t, ok)
+	return server.newAirdropResponse(airdrop, true)
+}
@@ -0,0 +1,334 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+)
+
+type APIKeyRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	DailyQuota int      `json:"dailyQuota"`
+	Assets     []string `json:"assets"`
+	TipAmount  string   `json:"tipAmount"`
+	AllowedIPs []string `json:"allowedIps"`
+}
+
+type APIKeyUsageResponse struct {
+	RequestsToday int               `json:"requestsToday"`
+	TotalRequests int               `json:"totalRequests"`
+	Dispensed     map[string]string `json:"dispensed"`
+	LastUsedAt    *time.Time        `json:"lastUsedAt,omitempty"`
+}
+
+type APIKeyResponse struct {
+	ID         string              `json:"id"`
+	Name       string              `json:"name"`
+	DailyQuota int                 `json:"dailyQuota"`
+	Assets     []string            `json:"assets,omitempty"`
+	TipAmount  string              `json:"tipAmount,omitempty"`
+	AllowedIPs []string            `json:"allowedIps,omitempty"`
+	CreatedAt  time.Time           `json:"createdAt"`
+	Usage      APIKeyUsageResponse `json:"usage"`
+}
+
+// CreateAPIKeyResponse carries the token, which is not retrievable later.
+type CreateAPIKeyResponse struct {
+	APIKeyResponse
+	Token string `json:"token"`
+}
+
+type APIKeyListResponse struct {
+	Keys []APIKeyResponse `json:"keys"`
+}
+
+// authenticateAPIKey returns the partner key presented as a bearer token,
+// or nil for anonymous requests, and writes the error response if the key
+// is invalid or used from an IP it isn't allowed for.
+func (s *Server) authenticateAPIKey(c *gin.Context) (*store.APIKey, bool) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return nil, true
+	}
+
+	key, err := s.store.AuthenticateAPIKey(strings.TrimSpace(token))
+	if err != nil {
+		logger.Warnf("Rejected request with invalid API key from %s", c.ClientIP())
+		c.JSON(http.StatusUnauthorized, newErrorResponse(CodeInvalidAPIKey, ErrInvalidAPIKey))
+		return nil, false
+	}
+
+	if !ipAllowed(key.AllowedIPs, c.ClientIP()) {
+		logger.Warnf("Rejected request with API key %s from %s", key.ID, c.ClientIP())
+		c.JSON(http.StatusForbidden, newErrorResponse(CodeIPNotAllowed, ErrIPNotAllowed))
+		return nil, false
+	}
+
+	return &key, true
+}
+
+// dispenseParams resolves what a request receives: partners may choose among
+// their key's assets and get its tip amount, anonymous requests get the
+// standard tip of the configured token.
+func dispenseParams(cfg *config.Config, key *store.APIKey, requestedAsset string) (string, decimal.Decimal, bool) {
+	allowed := []string{cfg.TokenSymbol}
+	amount := cfg.StandardTipAmountDecimal
+	if key != nil {
+		if len(key.Assets) > 0 {
+			allowed = key.Assets
+		}
+		if key.TipAmount != nil {
+			amount = *key.TipAmount
+		}
+	}
+
+	requestedAsset = strings.TrimSpace(requestedAsset)
+	if requestedAsset == "" {
+		return allowed[0], amount, true
+	}
+
+	for _, asset := range allowed {
+		if strings.EqualFold(asset, requestedAsset) {
+			return asset, amount, true
+		}
+	}
+	return "", decimal.Zero, false
+}
+
+// reserveAPIKeyRequest counts the request against key's daily quota and
+// writes the error response if it is used up. On success it returns the
+// reservation time, which releaseAPIKeyRequest needs if the request fails.
+func (s *Server) reserveAPIKeyRequest(c *gin.Context, key *store.APIKey) (time.Time, bool) {
+	now := time.Now()
+	if key == nil {
+		return now, true
+	}
+
+	err := s.store.ReserveAPIKeyRequest(key.ID, now)
+	if err == nil {
+		return now, true
+	}
+
+	var quotaErr *store.APIKeyQuotaError
+	if errors.As(err, &quotaErr) {
+		logger.Warnf("Rejected request with API key %s: %v", key.ID, err)
+		retryAfter := int(quotaErr.ResetsAt.Sub(now).Seconds()) + 1
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		response := newErrorResponse(CodeQuotaExceeded, fmt.Sprintf(ErrQuotaExceeded, quotaErr.ResetsAt.Format(time.RFC3339)))
+		response.RetryAfter = retryAfter
+		c.JSON(http.StatusTooManyRequests, response)
+		return time.Time{}, false
+	}
+
+	if errors.Is(err, store.ErrAPIKeyNotFound) {
+		// Revoked while the request was in flight
+		c.JSON(http.StatusUnauthorized, newErrorResponse(CodeInvalidAPIKey, ErrInvalidAPIKey))
+		return time.Time{}, false
+	}
+
+	logger.Errorf("Failed to record usage of API key %s: %v", key.ID, err)
+	c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, ErrServiceUnavailable))
+	return time.Time{}, false
+}
+
+// releaseAPIKeyRequest returns a reserved request after a failure.
+func (s *Server) releaseAPIKeyRequest(key *store.APIKey, reservedAt time.Time) {
+	if key == nil {
+		return
+	}
+	s.store.ReleaseAPIKeyRequest(key.ID, reservedAt)
+}
+
+// recordAPIKeyTransfer adds a successful transfer to key's usage counters.
+func (s *Server) recordAPIKeyTransfer(key *store.APIKey, asset string, amount decimal.Decimal) {
+	if key == nil {
+		return
+	}
+	if err := s.store.RecordAPIKeyTransfer(key.ID, asset, amount, time.Now()); err != nil {
+		logger.Errorf("Failed to record transfer for API key %s: %v", key.ID, err)
+	}
+}
+
+// ipAllowed reports whether ip matches one of the allowed IPs or CIDR
+// ranges. An empty list allows any IP.
+func ipAllowed(allowed []string, ip string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	for _, entry := range allowed {
+		if prefix, err := parseIPOrPrefix(entry); err == nil && prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseIPOrPrefix parses a CIDR range or a single IP as its /32 or /128 range.
+func parseIPOrPrefix(value string) (netip.Prefix, error) {
+	if strings.Contains(value, "/") {
+		prefix, err := netip.ParsePrefix(value)
+		return prefix.Masked(), err
+	}
+
+	addr, err := netip.ParseAddr(value)
+	if err != nil {
+		return netip.Prefix{}, err
+	}
+	addr = addr.Unmap()
+	return netip.PrefixFrom(addr, addr.BitLen()), nil
+}
+
+func newAPIKeyResponse(key store.APIKey, now time.Time) APIKeyResponse {
+	response := APIKeyResponse{
+		ID:         key.ID,
+		Name:       key.Name,
+		DailyQuota: key.DailyQuota,
+		Assets:     key.Assets,
+		AllowedIPs: key.AllowedIPs,
+		CreatedAt:  key.CreatedAt,
+		Usage: APIKeyUsageResponse{
+			RequestsToday: key.RequestsToday(now),
+			TotalRequests: key.Usage.TotalRequests,
+			Dispensed:     make(map[string]string, len(key.Usage.Dispensed)),
+			LastUsedAt:    key.Usage.LastUsedAt,
+		},
+	}
+	if key.TipAmount != nil {
+		response.TipAmount = key.TipAmount.String()
+	}
+	for asset, amount := range key.Usage.Dispensed {
+		response.Usage.Dispensed[asset] = amount.String()
+	}
+	return response
+}
+
+func (s *Server) createAPIKey(c *gin.Context) {
+	var req APIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidAdminRequest))
+		return
+	}
+
+	key, err := parseAPIKeyRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, err.Error()))
+		return
+	}
+
+	key, token, err := s.store.CreateAPIKey(key)
+	if err != nil {
+		logger.Errorf("Failed to create API key %q: %v", req.Name, err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+		return
+	}
+
+	logger.Infof("Created API key %s (%s)", key.ID, key.Name)
+	c.JSON(http.StatusCreated, CreateAPIKeyResponse{
+		APIKeyResponse: newAPIKeyResponse(key, time.Now()),
+		Token:          token,
+	})
+}
+
+func parseAPIKeyRequest(req APIKeyRequest) (store.APIKey, error) {
+	key := store.APIKey{
+		Name:       strings.TrimSpace(req.Name),
+		DailyQuota: req.DailyQuota,
+	}
+
+	if key.Name == "" {
+		return store.APIKey{}, fmt.Errorf("name must not be empty")
+	}
+
+	if key.DailyQuota < 0 {
+		return store.APIKey{}, fmt.Errorf("dailyQuota must not be negative")
+	}
+
+	for _, asset := range req.Assets {
+		asset = strings.ToLower(strings.TrimSpace(asset))
+		if asset == "" {
+			return store.APIKey{}, fmt.Errorf("assets must not contain empty symbols")
+		}
+		if !slices.Contains(key.Assets, asset) {
+			key.Assets = append(key.Assets, asset)
+		}
+	}
+
+	if tip := strings.TrimSpace(req.TipAmount); tip != "" {
+		amount, err := decimal.NewFromString(tip)
+		if err != nil || !amount.IsPositive() {
+			return store.APIKey{}, fmt.Errorf("tipAmount must be a positive number")
+		}
+		key.TipAmount = &amount
+	}
+
+	for _, entry := range req.AllowedIPs {
+		entry = strings.TrimSpace(entry)
+		if _, err := parseIPOrPrefix(entry); err != nil {
+			return store.APIKey{}, fmt.Errorf("allowedIps must contain IP addresses or CIDR ranges, got %q", entry)
+		}
+		key.AllowedIPs = append(key.AllowedIPs, entry)
+	}
+
+	return key, nil
+}
+
+func (s *Server) listAPIKeys(c *gin.Context) {
+	now := time.Now()
+	keys := s.store.ListAPIKeys()
+
+	response := APIKeyListResponse{Keys: make([]APIKeyResponse, 0, len(keys))}
+	for _, key := range keys {
+		response.Keys = append(response.Keys, newAPIKeyResponse(key, now))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) getAPIKey(c *gin.Context) {
+	key, ok := s.store.GetAPIKey(c.Param("id"))
+	if !ok {
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrAPIKeyNotFound))
+		return
+	}
+
+	c.JSON(http.StatusOK, newAPIKeyResponse(key, time.Now()))
+}
+
+func (s *Server) deleteAPIKey(c *gin.Context) {
+	id := c.Param("id")
+	deleted, err := s.store.DeleteAPIKey(id)
+	if err != nil {
+		logger.Errorf("Failed to revoke API key %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+		return
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrAPIKeyNotFound))
+		return
+	}
+
+	logger.Infof("Revoked API key %s", id)
+	c.JSON(http.StatusOK, AdminActionResponse{
+		Success: true,
+		Message: "Revoked API key " + id,
+	})
+}
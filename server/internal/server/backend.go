@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+)
+
+// Backend is what the faucet dispenses an asset from. The Clearnode ledger
+// (ClearnodeBackend) is the default; assets may be routed to other backends,
+// such as an on-chain sender, with WithAssetBackend.
+//
+// Errors are classified with errors.Is against the clearnode and onchain
+// sentinel errors (ErrTimeout, ErrNotConnected, ErrInsufficientBalance,
+// ErrReceiptTimeout, ...), so other implementations should wrap them where
+// they apply.
+type Backend interface {
+	// Transfer sends amount of asset to destination and returns the
+	// transaction ID. An ID returned along with a timeout error identifies a
+	// transfer that may still complete.
+	Transfer(ctx context.Context, destination, asset string, amount decimal.Decimal) (string, error)
+	// Balance fetches the faucet's current balance of asset.
+	Balance(ctx context.Context, asset string) (decimal.Decimal, error)
+	// CheckOperational checks that the backend is reachable and dispenses asset.
+	CheckOperational(ctx context.Context, asset string) error
+	// Address returns the account tokens are sent from.
+	Address() common.Address
+}
+
+// ConnectionManager is implemented by backends holding a connection that the
+// admin API can inspect and re-establish.
+type ConnectionManager interface {
+	// Status reports the connection state and the faucet's addresses.
+	Status() clearnode.ConnectionStatus
+	// Reconnect drops the current connection and establishes a new one.
+	Reconnect() error
+}
+
+// AssetLister is implemented by backends that know the token metadata of the
+// assets they dispense.
+type AssetLister interface {
+	// Assets returns the assets as of the last fetch, without querying the
+	// backend.
+	Assets() []Asset
+}
+
+// Asset is the metadata of a token on one chain.
+type Asset struct {
+	Symbol   string
+	Token    string
+	ChainID  uint64
+	Decimals uint8
+}
+
+// errLowBalance means the faucet balance doesn't cover MIN_TRANSFER_COUNT
+// transfers of the requested amount.
+var errLowBalance = fmt.Errorf("faucet balance too low: %w", clearnode.ErrInsufficientBalance)
+
+// cachedBalance is a faucet balance as of FetchedAt.
+type cachedBalance struct {
+	Amount    decimal.Decimal
+	FetchedAt time.Time
+}
+
+// balanceCache keeps the last balance fetched of every asset, so GET /info
+// can report it without a backend round trip.
+type balanceCache struct {
+	mu       sync.RWMutex
+	balances map[string]cachedBalance
+}
+
+func (b *balanceCache) store(asset string, amount decimal.Decimal) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.balances == nil {
+		b.balances = make(map[string]cachedBalance)
+	}
+	b.balances[asset] = cachedBalance{Amount: amount, FetchedAt: time.Now()}
+}
+
+func (b *balanceCache) load(asset string) (cachedBalance, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	balance, ok := b.balances[asset]
+	return balance, ok
+}
+
+// backendFor returns the backend dispensing asset. It is the only place
+// backends are chosen.
+func (s *Server) backendFor(asset string) Backend {
+	if backend, ok := s.assetBackends[strings.ToLower(asset)]; ok {
+		return backend
+	}
+	return s.backend
+}
+
+// checkAsset writes the error response and returns false unless the backend
+// of asset dispenses it. Airdrops and claim code batches check their asset
+// when created, so that they don't store transfers nothing can send.
+func (s *Server) checkAsset(c *gin.Context, asset string) bool {
+	err := s.backendFor(asset).CheckOperational(c.Request.Context(), asset)
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, clearnode.ErrTokenNotSupported):
+		logger.Warnf("Rejected unsupported asset %q", asset)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeAssetNotAllowed, ErrAssetNotAllowed))
+	default:
+		logger.Errorf("Failed to check asset %s: %v", asset, err)
+		c.JSON(backendError(err))
+	}
+	return false
+}
+
+// normalizeAsset returns the symbol under which asset is stored, budgeted
+// and reported.
+func normalizeAsset(asset string) string {
+	return strings.ToLower(strings.TrimSpace(asset))
+}
+
+// fetchBalance fetches the faucet's balance of asset and caches it.
+func (s *Server) fetchBalance(ctx context.Context, asset string) (decimal.Decimal, error) {
+	balance, err := s.backendFor(asset).Balance(ctx, asset)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+
+	s.balances.store(asset, balance)
+	return balance, nil
+}
+
+// ensureOperational checks that the backend of asset is operational and that
+// the faucet balance covers MIN_TRANSFER_COUNT transfers of amount. It is run
+// before every transfer, whichever backend sends it.
+func (s *Server) ensureOperational(ctx context.Context, cfg *config.Config, asset string, amount decimal.Decimal) error {
+	if err := s.backendFor(asset).CheckOperational(ctx, asset); err != nil {
+		return err
+	}
+
+	balance, err := s.fetchBalance(ctx, asset)
+	if err != nil {
+		return fmt.Errorf("failed to fetch faucet balance: %w", err)
+	}
+
+	required := amount.Mul(decimal.NewFromInt(int64(cfg.MinTransferCount)))
+	if balance.LessThan(required) {
+		return fmt.Errorf("%w: %s %s (required: %s for %d transfers)", errLowBalance, balance, asset, required, cfg.MinTransferCount)
+	}
+
+	return nil
+}
+
+// connectionStatus reports the default backend's connection. Backends
+// without a connection are reported as connected, with the sending account
+// as both addresses.
+func (s *Server) connectionStatus() clearnode.ConnectionStatus {
+	if manager, ok := s.backend.(ConnectionManager); ok {
+		return manager.Status()
+	}
+
+	address := s.backend.Address().Hex()
+	return clearnode.ConnectionStatus{
+		Connected:         true,
+		Authenticated:     true,
+		OwnerAddress:      address,
+		SessionKeyAddress: address,
+	}
+}
@@ -0,0 +1,81 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"faucet-server/internal/alert"
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+)
+
+// setupBalanceMonitor prepares low-balance alerting when webhooks are configured.
+func (s *Server) setupBalanceMonitor() {
+	cfg := s.Config()
+	if !cfg.HasAlertWebhooks() {
+		return
+	}
+
+	var notifiers []alert.Notifier
+	for _, url := range cfg.AlertWebhookURLs {
+		notifiers = append(notifiers, alert.NewWebhookNotifier(url, alert.FormatGeneric))
+	}
+	for _, url := range cfg.AlertSlackWebhookURLs {
+		notifiers = append(notifiers, alert.NewWebhookNotifier(url, alert.FormatSlack))
+	}
+	for _, url := range cfg.AlertDiscordWebhookURLs {
+		notifiers = append(notifiers, alert.NewWebhookNotifier(url, alert.FormatDiscord))
+	}
+
+	s.balanceMonitor = alert.NewMonitor(s.backendFor(cfg.TokenSymbol).Address().Hex(), notifiers)
+}
+
+// MonitorBalance checks the faucet balance every BALANCE_CHECK_INTERVAL and
+// sends low-balance alerts until ctx is cancelled. It returns immediately
+// when no alert webhooks are configured.
+func (s *Server) MonitorBalance(ctx context.Context) {
+	if s.balanceMonitor == nil {
+		return
+	}
+
+	logger.Infof("Balance monitor started, checking every %s", s.Config().BalanceCheckInterval)
+
+	for {
+		s.checkBalance(ctx)
+
+		// Read the interval each time so reloads take effect
+		timer := time.NewTimer(s.Config().BalanceCheckInterval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+// checkBalance fetches the current balance and passes it to the monitor.
+func (s *Server) checkBalance(ctx context.Context) {
+	cfg := s.Config()
+
+	balance, err := s.fetchBalance(ctx, cfg.TokenSymbol)
+	if err != nil {
+		logger.Warnf("Balance monitor could not fetch %s balance: %v", cfg.TokenSymbol, err)
+		return
+	}
+
+	sent, err := s.balanceMonitor.Check(ctx, cfg.TokenSymbol, balance, cfg.StandardTipAmountDecimal, alertThresholds(cfg))
+	if err != nil {
+		logger.Errorf("Balance alert delivery failed: %v", err)
+	}
+	if sent != nil {
+		logger.Warnf("Balance alert sent: %s", sent.Message)
+	}
+}
+
+func alertThresholds(cfg *config.Config) alert.Thresholds {
+	return alert.Thresholds{
+		WarningTips:  cfg.BalanceWarningTips,
+		CriticalTips: cfg.BalanceCriticalTips,
+	}
+}
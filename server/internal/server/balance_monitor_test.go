@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/alert"
+	"faucet-server/internal/config"
+)
+
+func TestBalanceMonitor(t *testing.T) {
+	messages := make(chan string, 10)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload struct {
+			Text string `json:"text"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&payload))
+		messages <- payload.Text
+	}))
+	defer webhook.Close()
+
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		// The mock holds 1000000000 usdc, which covers 10 tips of this size
+		cfg.StandardTipAmountDecimal = decimal.NewFromInt(100000000)
+		cfg.BalanceCheckInterval = time.Hour
+		cfg.BalanceWarningTips = 50
+		cfg.BalanceCriticalTips = 10
+		cfg.AlertSlackWebhookURLs = []string{webhook.URL}
+		cfg.AdminToken = testAdminToken
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.MonitorBalance(ctx)
+		close(done)
+	}()
+
+	select {
+	case message := <-messages:
+		assert.Contains(t, message, "[CRITICAL]")
+		assert.Contains(t, message, "10 tips remaining")
+	case <-time.After(5 * time.Second):
+		t.Fatal("no alert was sent")
+	}
+
+	// The level is recorded once the webhook response has been read
+	require.Eventually(t, func() bool {
+		return server.balanceMonitor.Level("usdc") == alert.LevelCritical
+	}, 5*time.Second, 10*time.Millisecond)
+
+	cancel()
+	<-done
+
+	w := doJSON(t, server, "GET", "/admin/status", nil, map[string]string{"Authorization": "Bearer " + testAdminToken})
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var status AdminStatusResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+	assert.Equal(t, alert.LevelCritical, status.BalanceLevel)
+}
+
+func TestBalanceMonitorDisabledWithoutWebhooks(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+
+	done := make(chan struct{})
+	go func() {
+		server.MonitorBalance(context.Background())
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("MonitorBalance should return immediately without webhooks")
+	}
+}
@@ -0,0 +1,101 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+)
+
+// budgetLimits collects the configured budgets for asset.
+func budgetLimits(cfg *config.Config, asset string) store.BudgetLimits {
+	asset = strings.ToLower(asset)
+	limits := make(store.BudgetLimits)
+	if limit, ok := cfg.HourlyBudgetDecimal[asset]; ok {
+		limits[store.Hourly] = limit
+	}
+	if limit, ok := cfg.DailyBudgetDecimal[asset]; ok {
+		limits[store.Daily] = limit
+	}
+	return limits
+}
+
+// errBudgetNotRecorded means a budget reservation could not be written to
+// the store.
+var errBudgetNotRecorded = errors.New("failed to record budget usage")
+
+// reserveBudget counts amount against the asset's budgets. On success it
+// returns the reservation time, which releaseBudget needs if the transfer
+// fails.
+func (s *Server) reserveBudget(cfg *config.Config, asset string, amount decimal.Decimal, userAddress string) (time.Time, error) {
+	now := time.Now()
+	err := s.store.ReserveBudget(asset, amount, budgetLimits(cfg, asset), now)
+
+	var exhausted *store.BudgetExhaustedError
+	switch {
+	case err == nil:
+		return now, nil
+	case errors.As(err, &exhausted):
+		logger.Warnf("Rejected request for %s: %v", userAddress, err)
+		return time.Time{}, err
+	default:
+		logger.Errorf("Failed to record budget usage for %s: %v", userAddress, err)
+		return time.Time{}, fmt.Errorf("%w: %w", errBudgetNotRecorded, err)
+	}
+}
+
+// writeBudgetError writes the error response for a failed reserveBudget.
+func writeBudgetError(c *gin.Context, err error) {
+	var exhausted *store.BudgetExhaustedError
+	if !errors.As(err, &exhausted) {
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, ErrServiceUnavailable))
+		return
+	}
+
+	retryAfter := int(time.Until(exhausted.ResetsAt).Seconds()) + 1
+	c.Header("Retry-After", strconv.Itoa(retryAfter))
+	response := newErrorResponse(CodeBudgetExhausted,
+		fmt.Sprintf(ErrBudgetExhausted, budgetPeriodName(exhausted.Period), exhausted.ResetsAt.Format(time.RFC3339)))
+	response.RetryAfter = retryAfter
+	c.JSON(http.StatusTooManyRequests, response)
+}
+
+// releaseBudget returns a reservation after a failed transfer.
+func (s *Server) releaseBudget(asset string, amount decimal.Decimal, reservedAt time.Time) {
+	s.store.ReleaseBudget(asset, amount, reservedAt)
+}
+
+// budgetInfo reports the remaining budget per period for /info, or nil when
+// no budget is configured for asset.
+func (s *Server) budgetInfo(cfg *config.Config, asset string) gin.H {
+	limits := budgetLimits(cfg, asset)
+	if len(limits) == 0 {
+		return nil
+	}
+
+	info := gin.H{}
+	for period, status := range s.store.BudgetStatus(asset, limits, time.Now()) {
+		info[string(period)] = gin.H{
+			"limit":     status.Limit.String(),
+			"remaining": status.Remaining.String(),
+			"resets_at": status.ResetsAt.Format(time.RFC3339),
+		}
+	}
+	return info
+}
+
+func budgetPeriodName(period store.BudgetPeriod) string {
+	if period == store.Daily {
+		return "Daily"
+	}
+	return "Hourly"
+}
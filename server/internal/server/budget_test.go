@@ -0,0 +1,70 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+)
+
+func TestBudgets(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.HourlyBudgetDecimal = map[string]decimal.Decimal{"usdc": decimal.NewFromInt(25)}
+		cfg.DailyBudgetDecimal = map[string]decimal.Decimal{"usdc": decimal.NewFromInt(100)}
+	})
+
+	request := FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}
+
+	for i := 0; i < 2; i++ {
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+	}
+
+	t.Run("rejects requests once the budget is exhausted", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Retry-After"))
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Contains(t, errorResponse.Error, "Hourly budget exhausted, resets at ")
+		assert.Equal(t, CodeBudgetExhausted, errorResponse.Code)
+		assert.True(t, errorResponse.Retryable)
+		assert.Positive(t, errorResponse.RetryAfter)
+	})
+
+	t.Run("info reports remaining budget", func(t *testing.T) {
+		w := doJSON(t, server, "GET", "/info", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var info struct {
+			Budget map[string]struct {
+				Limit     string `json:"limit"`
+				Remaining string `json:"remaining"`
+				ResetsAt  string `json:"resets_at"`
+			} `json:"budget"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+
+		assert.Equal(t, "25", info.Budget["hourly"].Limit)
+		assert.Equal(t, "5", info.Budget["hourly"].Remaining)
+		assert.Equal(t, "80", info.Budget["daily"].Remaining)
+		assert.NotEmpty(t, info.Budget["daily"].ResetsAt)
+	})
+}
+
+func TestInfoOmitsBudgetWhenUnlimited(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+
+	w := doJSON(t, server, "GET", "/info", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.NotContains(t, info, "budget")
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+)
+
+func TestCaptchaVerification(t *testing.T) {
+	siteVerify := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil || r.PostForm.Get("secret") != "captcha-secret" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.PostForm.Get("response") {
+		case "valid-token":
+			w.Write([]byte(`{"success": true}`))
+		case "provider-error":
+			w.WriteHeader(http.StatusBadGateway)
+		default:
+			w.Write([]byte(`{"success": false, "error-codes": ["invalid-input-response"]}`))
+		}
+	}))
+	defer siteVerify.Close()
+
+	server, mockClearnode := newTestServer(t, func(cfg *config.Config) {
+		cfg.CaptchaSecret = "captcha-secret"
+		cfg.CaptchaVerifyURL = siteVerify.URL
+	})
+
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	tests := []struct {
+		name       string
+		token      string
+		wantStatus int
+		wantError  string
+	}{
+		{"missing token", "", http.StatusBadRequest, ErrCaptchaRequired},
+		{"rejected token", "forged-token", http.StatusForbidden, ErrCaptchaFailed},
+		{"provider failure", "provider-error", http.StatusServiceUnavailable, ErrCaptchaUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address, CaptchaToken: tt.token}, nil)
+			assert.Equal(t, tt.wantStatus, w.Code)
+
+			var errorResponse ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+			assert.Equal(t, tt.wantError, errorResponse.Error)
+			assert.Nil(t, mockClearnode.LastTransfer())
+		})
+	}
+
+	t.Run("valid token", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address, CaptchaToken: "valid-token"}, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotNil(t, mockClearnode.LastTransfer())
+	})
+}
@@ -0,0 +1,153 @@
+package server
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/challenge"
+	"faucet-server/internal/logger"
+)
+
+const (
+	// spentChallengeKey is the gin context key holding the nonce of the
+	// challenge spent by the current request.
+	spentChallengeKey = "spentChallenge"
+
+	// maxSpentChallengesPerClient bounds the unexpired challenges one client
+	// network may redeem, which bounds the memory for spent challenges
+	// without letting one client lock everyone else out.
+	maxSpentChallengesPerClient = 1000
+)
+
+type ChallengeResponse struct {
+	Challenge  string `json:"challenge"`
+	Nonce      string `json:"nonce"`
+	Difficulty int    `json:"difficulty"`
+	ExpiresAt  int64  `json:"expiresAt"`
+}
+
+// setupChallenges prepares the challenge issuer when proof of work or signed
+// requests are enabled; both use the nonce it issues.
+func (s *Server) setupChallenges() error {
+	cfg := s.Config()
+	if !cfg.PowEnabled && !cfg.RequireSignature {
+		return nil
+	}
+
+	secret := []byte(cfg.ChallengeSecret)
+	if len(secret) == 0 {
+		secret = make([]byte, 32)
+		if _, err := rand.Read(secret); err != nil {
+			return fmt.Errorf("failed to generate challenge secret: %w", err)
+		}
+		logger.Warn("CHALLENGE_SECRET is not set, using a random secret; challenges are not accepted by other replicas or after a restart")
+	}
+
+	s.challengeIssuer = challenge.NewIssuer(secret, cfg.ChallengeTTL, maxSpentChallengesPerClient)
+	if cfg.PowEnabled {
+		s.requestRate = challenge.NewRateTracker(cfg.PowWindow)
+	}
+	return nil
+}
+
+// currentDifficulty scales the configured difficulty with recent request
+// volume. It is 0 when only signed requests use the challenge.
+func (s *Server) currentDifficulty() int {
+	cfg := s.Config()
+	if !cfg.PowEnabled {
+		return 0
+	}
+	return challenge.AdjustDifficulty(cfg.PowDifficulty, cfg.PowMaxDifficulty, s.requestRate.Count(time.Now()), cfg.PowTargetRate)
+}
+
+func (s *Server) getChallenge(c *gin.Context) {
+	issued, err := s.challengeIssuer.Issue(s.currentDifficulty())
+	if err != nil {
+		logger.Errorf("Failed to issue challenge: %v", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeServiceUnavailable, ErrServiceUnavailable))
+		return
+	}
+
+	c.JSON(http.StatusOK, ChallengeResponse{
+		Challenge:  issued.Token,
+		Nonce:      issued.Nonce,
+		Difficulty: issued.Difficulty,
+		ExpiresAt:  issued.ExpiresAt.Unix(),
+	})
+}
+
+// verifyProofOfWork checks the request's challenge solution and writes the
+// error response if it is missing or invalid.
+func (s *Server) verifyProofOfWork(c *gin.Context, req FaucetRequest, userAddress string) bool {
+	token := strings.TrimSpace(req.Challenge)
+	solution := strings.TrimSpace(req.Solution)
+	if token == "" || solution == "" {
+		logger.Warnf("Missing proof of work for %s", userAddress)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeProofOfWorkRequired, ErrProofOfWorkRequired))
+		return false
+	}
+
+	issued, err := s.challengeIssuer.VerifySolution(token, userAddress, solution)
+	if err != nil {
+		logger.Warnf("Proof of work rejected for %s: %v", userAddress, err)
+		if errors.Is(err, challenge.ErrInvalidSolution) {
+			c.JSON(http.StatusForbidden, newErrorResponse(CodeInvalidSolution, ErrInvalidSolution))
+		} else {
+			c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidChallenge, ErrInvalidChallenge))
+		}
+		return false
+	}
+
+	return s.spendChallenge(c, issued, userAddress)
+}
+
+// spendChallenge marks a verified challenge as used and writes the error
+// response if it was used before, so one solved or signed challenge can't be
+// replayed for further token requests until it expires. Proof of work and
+// the ownership signature may share the challenge of a request.
+func (s *Server) spendChallenge(c *gin.Context, issued *challenge.Challenge, userAddress string) bool {
+	if c.GetString(spentChallengeKey) == issued.Nonce {
+		return true
+	}
+
+	client := challengeClient(c.ClientIP())
+	err := s.challengeIssuer.Spend(issued, client)
+	switch {
+	case err == nil:
+		c.Set(spentChallengeKey, issued.Nonce)
+		return true
+	case errors.Is(err, challenge.ErrChallengeUsed):
+		logger.Warnf("Rejected reused challenge for %s", userAddress)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidChallenge, ErrChallengeUsed))
+	case errors.Is(err, challenge.ErrTooManyChallenges):
+		logger.Warnf("Rejected challenge for %s: too many challenges spent from %s", userAddress, client)
+		retryAfter := int(s.Config().ChallengeTTL.Seconds())
+		c.Header("Retry-After", strconv.Itoa(retryAfter))
+		response := newErrorResponse(CodeTooManyChallenges, ErrTooManyChallenges)
+		response.RetryAfter = retryAfter
+		c.JSON(http.StatusTooManyRequests, response)
+	default:
+		logger.Errorf("Failed to spend challenge for %s: %v", userAddress, err)
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeServiceUnavailable, ErrServiceUnavailable))
+	}
+	return false
+}
+
+// challengeClient returns the key spent challenges are counted under for a
+// client IP. IPv6 clients are grouped by /64, the smallest prefix commonly
+// assigned to a single host or site.
+func challengeClient(ip string) string {
+	parsed := net.ParseIP(ip)
+	if parsed == nil || parsed.To4() != nil {
+		return ip
+	}
+	return parsed.Mask(net.CIDRMask(64, 128)).String() + "/64"
+}
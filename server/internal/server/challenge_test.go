@@ -0,0 +1,184 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/challenge"
+	"faucet-server/internal/config"
+)
+
+func enableProofOfWork(cfg *config.Config) {
+	cfg.PowEnabled = true
+	cfg.ChallengeSecret = "challenge-secret"
+	cfg.PowDifficulty = 4
+	cfg.PowMaxDifficulty = 8
+	cfg.PowTargetRate = 2
+	cfg.PowWindow = time.Minute
+	cfg.ChallengeTTL = time.Minute
+}
+
+func fetchChallenge(t *testing.T, server *Server) ChallengeResponse {
+	t.Helper()
+
+	w := doJSON(t, server, "GET", "/challenge", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var response ChallengeResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	return response
+}
+
+func TestProofOfWork(t *testing.T) {
+	server, mockClearnode := newTestServer(t, enableProofOfWork)
+
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	t.Run("challenge endpoint issues signed nonce", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		assert.NotEmpty(t, response.Challenge)
+		assert.NotEmpty(t, response.Nonce)
+		assert.Equal(t, 4, response.Difficulty)
+		assert.Greater(t, response.ExpiresAt, time.Now().Unix())
+	})
+
+	t.Run("missing solution", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, ErrProofOfWorkRequired, errorResponse.Error)
+	})
+
+	t.Run("forged challenge", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{
+			UserAddress: address,
+			Challenge:   "forged.challenge",
+			Solution:    "1",
+		}, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, ErrInvalidChallenge, errorResponse.Error)
+	})
+
+	t.Run("valid solution", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		solution := challenge.Solve(response.Nonce, address, response.Difficulty)
+
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{
+			UserAddress: address,
+			Challenge:   response.Challenge,
+			Solution:    solution,
+		}, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.NotNil(t, mockClearnode.LastTransfer())
+	})
+
+	t.Run("solution can't be replayed", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		request := FaucetRequest{
+			UserAddress: address,
+			Challenge:   response.Challenge,
+			Solution:    challenge.Solve(response.Nonce, address, response.Difficulty),
+		}
+
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		transfers := len(mockClearnode.Transfers())
+
+		w = doJSON(t, server, "POST", "/requestTokens", request, nil)
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Len(t, mockClearnode.Transfers(), transfers)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, CodeInvalidChallenge, errorResponse.Code)
+		assert.Equal(t, ErrChallengeUsed, errorResponse.Error)
+	})
+
+	t.Run("difficulty rises with request volume", func(t *testing.T) {
+		for i := 0; i < 10; i++ {
+			doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+		}
+
+		response := fetchChallenge(t, server)
+		assert.Greater(t, response.Difficulty, 4)
+		assert.LessOrEqual(t, response.Difficulty, 8)
+	})
+}
+
+func TestProofOfWorkWithCaptcha(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		enableProofOfWork(cfg)
+		cfg.CaptchaSecret = "captcha-secret"
+		cfg.CaptchaVerifyURL = "http://127.0.0.1:1/siteverify"
+	})
+
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+	response := fetchChallenge(t, server)
+
+	// Headless clients may submit a proof of work instead of a CAPTCHA token
+	w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{
+		UserAddress: address,
+		Challenge:   response.Challenge,
+		Solution:    challenge.Solve(response.Nonce, address, response.Difficulty),
+	}, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestChallengeEndpointDisabledByDefault(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+
+	w := doJSON(t, server, "GET", "/challenge", nil, nil)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestSpentChallengesLimitedPerClient(t *testing.T) {
+	server, mockClearnode := newTestServer(t, func(cfg *config.Config) {
+		enableProofOfWork(cfg)
+		cfg.TrustedProxies = []string{"192.0.2.0/24"}
+	})
+	server.challengeIssuer = challenge.NewIssuer([]byte("challenge-secret"), time.Minute, 2)
+
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+	request := func(clientIP string) *httptest.ResponseRecorder {
+		response := fetchChallenge(t, server)
+		return doJSON(t, server, "POST", "/requestTokens", FaucetRequest{
+			UserAddress: address,
+			Challenge:   response.Challenge,
+			Solution:    challenge.Solve(response.Nonce, address, response.Difficulty),
+		}, map[string]string{"X-Forwarded-For": clientIP})
+	}
+
+	for i := 0; i < 2; i++ {
+		require.Equal(t, http.StatusOK, request("203.0.113.7").Code)
+	}
+	transfers := len(mockClearnode.Transfers())
+
+	w := request("203.0.113.7")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, "60", w.Header().Get("Retry-After"))
+	assert.Len(t, mockClearnode.Transfers(), transfers)
+
+	var errorResponse ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+	assert.Equal(t, CodeTooManyChallenges, errorResponse.Code)
+
+	// Other clients still redeem challenges while one is at its limit
+	assert.Equal(t, http.StatusOK, request("198.51.100.7").Code)
+
+	// IPv6 clients are counted per /64
+	require.Equal(t, http.StatusOK, request("2001:db8::1").Code)
+	require.Equal(t, http.StatusOK, request("2001:db8::2").Code)
+	assert.Equal(t, http.StatusTooManyRequests, request("2001:db8::3").Code)
+	assert.Equal(t, http.StatusOK, request("2001:db8:0:1::1").Code)
+}
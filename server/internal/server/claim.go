@@ -0,0 +1,234 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+)
+
+// maxClaimCodesPerBatch keeps a single admin request, and the response
+// listing the codes, to a manageable size.
+const maxClaimCodesPerBatch = 10000
+
+type ClaimRequest struct {
+	Code        string `json:"code" binding:"required"`
+	UserAddress string `json:"userAddress" binding:"required"`
+}
+
+type ClaimBatchRequest struct {
+	Name      string    `json:"name"`
+	Count     int       `json:"count" binding:"required"`
+	Amount    string    `json:"amount"`
+	Asset     string    `json:"asset"`
+	MaxUses   int       `json:"maxUses"`
+	ExpiresAt time.Time `json:"expiresAt" binding:"required"`
+}
+
+type ClaimBatchResponse struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name,omitempty"`
+	Asset       string    `json:"asset"`
+	Amount      string    `json:"amount"`
+	MaxUses     int       `json:"maxUses"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	CreatedAt   time.Time `json:"createdAt"`
+	Codes       int       `json:"codes"`
+	Redemptions int       `json:"redemptions"`
+	CodesUsedUp int       `json:"codesUsedUp"`
+}
+
+// CreateClaimBatchResponse carries the codes, which are not retrievable later.
+type CreateClaimBatchResponse struct {
+	ClaimBatchResponse
+	ClaimCodes []string `json:"claimCodes"`
+}
+
+type ClaimBatchListResponse struct {
+	Batches []ClaimBatchResponse `json:"batches"`
+}
+
+// redeemClaimCode sends the amount of a claim code to the given address.
+// The code itself is the proof of eligibility, so CAPTCHA, proof of work,
+// signatures and the dispensing budgets don't apply; the address lists do.
+func (s *Server) redeemClaimCode(c *gin.Context) {
+	if pause := s.pause.Load(); pause != nil {
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeFaucetPaused, pause.Message))
+		return
+	}
+
+	var req ClaimRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warnf("Invalid claim request format: %v", err)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidClaimFormat))
+		return
+	}
+
+	cfg := s.Config()
+
+	address, ok := s.checkAddress(c, cfg, req.UserAddress)
+	if !ok {
+		return
+	}
+	userAddress := address.Hex()
+
+	batch, err := s.store.RedeemClaimCode(req.Code, userAddress, time.Now())
+	if err != nil {
+		logger.Warnf("Rejected claim code for %s: %v", userAddress, err)
+		c.JSON(claimCodeError(err))
+		return
+	}
+
+	logger.Infof("Redeeming claim code of batch %s for %s", batch.ID, userAddress)
+	s.dispense(c, cfg, dispenseRequest{
+		address: userAddress,
+		asset:   batch.Asset,
+		amount:  batch.Amount,
+		release: func() {
+			if err := s.store.ReleaseClaimCode(req.Code, userAddress); err != nil {
+				logger.Errorf("Failed to release claim code of batch %s: %v", batch.ID, err)
+			}
+		},
+	})
+}
+
+func claimCodeError(err error) (int, ErrorResponse) {
+	switch {
+	case errors.Is(err, store.ErrClaimCodeInvalid):
+		return http.StatusNotFound, newErrorResponse(CodeInvalidClaimCode, ErrInvalidClaimCode)
+	case errors.Is(err, store.ErrClaimCodeExpired):
+		return http.StatusGone, newErrorResponse(CodeClaimCodeExpired, ErrClaimCodeExpired)
+	case errors.Is(err, store.ErrClaimCodeUsedUp):
+		return http.StatusConflict, newErrorResponse(CodeClaimCodeUsedUp, ErrClaimCodeUsedUp)
+	case errors.Is(err, store.ErrClaimCodeAlreadyRedeemed):
+		return http.StatusConflict, newErrorResponse(CodeClaimCodeAlreadyRedeemed, ErrClaimCodeAlreadyRedeemed)
+	default:
+		logger.Errorf("Failed to redeem claim code: %v", err)
+		return http.StatusInternalServerError, newErrorResponse(CodeInternalError, ErrServiceUnavailable)
+	}
+}
+
+func (s *Server) createClaimBatch(c *gin.Context) {
+	var req ClaimBatchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidAdminRequest))
+		return
+	}
+
+	batch, err := s.parseClaimBatchRequest(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, err.Error()))
+		return
+	}
+
+	if !s.checkAsset(c, batch.Asset) {
+		return
+	}
+
+	batch, codes, err := s.store.CreateClaimBatch(batch, req.Count)
+	if err != nil {
+		logger.Errorf("Failed to create claim codes: %v", err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+		return
+	}
+
+	logger.Infof("Created %d claim codes of %s %s in batch %s (%s)", len(codes), batch.Amount, batch.Asset, batch.ID, batch.Name)
+	c.JSON(http.StatusCreated, CreateClaimBatchResponse{
+		ClaimBatchResponse: s.newClaimBatchResponse(batch),
+		ClaimCodes:         codes,
+	})
+}
+
+func (s *Server) parseClaimBatchRequest(req ClaimBatchRequest) (store.ClaimBatch, error) {
+	cfg := s.Config()
+	batch := store.ClaimBatch{
+		Name:      strings.TrimSpace(req.Name),
+		Asset:     normalizeAsset(cfg.TokenSymbol),
+		Amount:    cfg.StandardTipAmountDecimal,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: req.ExpiresAt.UTC(),
+	}
+
+	if req.Count < 1 || req.Count > maxClaimCodesPerBatch {
+		return store.ClaimBatch{}, fmt.Errorf("count must be between 1 and %d", maxClaimCodesPerBatch)
+	}
+
+	if batch.MaxUses == 0 {
+		batch.MaxUses = 1
+	}
+	if batch.MaxUses < 0 {
+		return store.ClaimBatch{}, fmt.Errorf("maxUses must be positive")
+	}
+
+	if !batch.ExpiresAt.After(time.Now()) {
+		return store.ClaimBatch{}, fmt.Errorf("expiresAt must be in the future")
+	}
+
+	if asset := normalizeAsset(req.Asset); asset != "" {
+		batch.Asset = asset
+	}
+
+	if value := strings.TrimSpace(req.Amount); value != "" {
+		amount, err := decimal.NewFromString(value)
+		if err != nil || !amount.IsPositive() {
+			return store.ClaimBatch{}, fmt.Errorf("amount must be a positive number")
+		}
+		batch.Amount = amount
+	}
+
+	return batch, nil
+}
+
+func (s *Server) listClaimBatches(c *gin.Context) {
+	batches := s.store.ListClaimBatches()
+
+	response := ClaimBatchListResponse{Batches: make([]ClaimBatchResponse, 0, len(batches))}
+	for _, batch := range batches {
+		response.Batches = append(response.Batches, s.newClaimBatchResponse(batch))
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (s *Server) deleteClaimBatch(c *gin.Context) {
+	id := c.Param("id")
+	deleted, err := s.store.DeleteClaimBatch(id)
+	if err != nil {
+		logger.Errorf("Failed to revoke claim codes of batch %s: %v", id, err)
+		c.JSON(http.StatusInternalServerError, newErrorResponse(CodeInternalError, err.Error()))
+		return
+	}
+
+	if !deleted {
+		c.JSON(http.StatusNotFound, newErrorResponse(CodeNotFound, ErrClaimBatchNotFound))
+		return
+	}
+
+	logger.Infof("Revoked claim codes of batch %s", id)
+	c.JSON(http.StatusOK, AdminActionResponse{
+		Success: true,
+		Message: "Revoked claim codes of batch " + id,
+	})
+}
+
+func (s *Server) newClaimBatchResponse(batch store.ClaimBatch) ClaimBatchResponse {
+	usage := s.store.ClaimBatchUsage(batch.ID)
+	return ClaimBatchResponse{
+		ID:          batch.ID,
+		Name:        batch.Name,
+		Asset:       batch.Asset,
+		Amount:      batch.Amount.String(),
+		MaxUses:     batch.MaxUses,
+		ExpiresAt:   batch.ExpiresAt,
+		CreatedAt:   batch.CreatedAt,
+		Codes:       batch.Codes,
+		Redemptions: usage.Redemptions,
+		CodesUsedUp: usage.CodesUsedUp,
+	}
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnodetest"
+	"faucet-server/internal/config"
+)
+
+func TestClaimCodes(t *testing.T) {
+	server, mockClearnode := newTestServer(t, func(cfg *config.Config) {
+		enableProofOfWork(cfg)
+		cfg.AdminToken = testAdminToken
+		cfg.HourlyBudgetDecimal = map[string]decimal.Decimal{"usdc": decimal.NewFromInt(10)}
+	})
+
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	createBatch := func(t *testing.T, req ClaimBatchRequest) CreateClaimBatchResponse {
+		t.Helper()
+		w := doJSON(t, server, "POST", "/admin/claim-codes", req, auth)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateClaimBatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		require.Len(t, created.ClaimCodes, req.Count)
+		return created
+	}
+
+	claim := func(t *testing.T, code, userAddress string) (int, ErrorCode) {
+		t.Helper()
+		w := doJSON(t, server, "POST", "/claim", ClaimRequest{Code: code, UserAddress: userAddress}, nil)
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return w.Code, response.Code
+	}
+
+	expiresAt := time.Now().Add(24 * time.Hour)
+
+	t.Run("validates new batches", func(t *testing.T) {
+		for _, req := range []ClaimBatchRequest{
+			{Count: 0, ExpiresAt: expiresAt},
+			{Count: maxClaimCodesPerBatch + 1, ExpiresAt: expiresAt},
+			{Count: 1, ExpiresAt: time.Now().Add(-time.Minute)},
+			{Count: 1, ExpiresAt: expiresAt, Amount: "free"},
+			{Count: 1, ExpiresAt: expiresAt, MaxUses: -1},
+		} {
+			w := doJSON(t, server, "POST", "/admin/claim-codes", req, auth)
+			assert.Equal(t, http.StatusBadRequest, w.Code, "%+v", req)
+		}
+	})
+
+	t.Run("rejects unsupported assets", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/claim-codes", ClaimBatchRequest{Count: 1, Asset: "DOGE", ExpiresAt: expiresAt}, auth)
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeAssetNotAllowed, response.Code)
+		assert.Empty(t, server.store.ListClaimBatches())
+	})
+
+	t.Run("redeems without proof of work or budget", func(t *testing.T) {
+		batch := createBatch(t, ClaimBatchRequest{Name: "Workshop", Count: 2, Amount: "25", Asset: "ETH", ExpiresAt: expiresAt})
+		assert.Equal(t, "eth", batch.Asset)
+		assert.Equal(t, 1, batch.MaxUses)
+
+		status, _ := claim(t, batch.ClaimCodes[0], address)
+		require.Equal(t, http.StatusOK, status)
+		transfer := mockClearnode.LastTransfer()
+		assert.Equal(t, "eth", transfer.Asset)
+		assert.True(t, transfer.Amount.Equal(decimal.NewFromInt(25)))
+
+		status, code := claim(t, batch.ClaimCodes[0], address)
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, CodeClaimCodeAlreadyRedeemed, code)
+
+		status, code = claim(t, batch.ClaimCodes[0], "0x8ba1f109551bD432803012645Ac136ddd64DBA72")
+		assert.Equal(t, http.StatusConflict, status)
+		assert.Equal(t, CodeClaimCodeUsedUp, code)
+
+		// Dispensing budgets don't limit claim codes
+		batch = createBatch(t, ClaimBatchRequest{Count: 1, Amount: "50", ExpiresAt: expiresAt})
+		status, _ = claim(t, batch.ClaimCodes[0], address)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("rejects unknown codes and denylisted addresses", func(t *testing.T) {
+		status, code := claim(t, "AAAA-BBBB-CCCC", address)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, CodeInvalidClaimCode, code)
+
+		batch := createBatch(t, ClaimBatchRequest{Count: 1, ExpiresAt: expiresAt})
+		denied := "0x0000000000000000000000000000000000000bad"
+		require.Equal(t, http.StatusOK, doJSON(t, server, "PUT", "/admin/denylist/"+denied, nil, auth).Code)
+
+		status, code = claim(t, batch.ClaimCodes[0], denied)
+		assert.Equal(t, http.StatusForbidden, status)
+		assert.Equal(t, CodeAddressDenied, code)
+
+		// The rejected request did not use up the code
+		status, _ = claim(t, batch.ClaimCodes[0], address)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("failed transfers release the code", func(t *testing.T) {
+		batch := createBatch(t, ClaimBatchRequest{Count: 1, ExpiresAt: expiresAt})
+
+		mockClearnode.InjectFault(clearnodetest.MethodTransfer, clearnodetest.Fault{Error: "transfer rejected"})
+		status, _ := claim(t, batch.ClaimCodes[0], address)
+		mockClearnode.ClearFaults()
+		require.Equal(t, http.StatusInternalServerError, status)
+
+		status, _ = claim(t, batch.ClaimCodes[0], address)
+		assert.Equal(t, http.StatusOK, status)
+	})
+
+	t.Run("concurrent redemptions respect max uses", func(t *testing.T) {
+		batch := createBatch(t, ClaimBatchRequest{Count: 1, MaxUses: 3, ExpiresAt: expiresAt})
+
+		var (
+			wg        sync.WaitGroup
+			mu        sync.Mutex
+			successes int
+		)
+		for i := range 10 {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				userAddress := common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+				w := doJSON(t, server, "POST", "/claim", ClaimRequest{Code: batch.ClaimCodes[0], UserAddress: userAddress}, nil)
+				if w.Code == http.StatusOK {
+					mu.Lock()
+					successes++
+					mu.Unlock()
+				}
+			}(i)
+		}
+		wg.Wait()
+		assert.Equal(t, 3, successes)
+
+		w := doJSON(t, server, "GET", "/admin/claim-codes", nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var list ClaimBatchListResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &list))
+		require.NotEmpty(t, list.Batches)
+		assert.Equal(t, batch.ID, list.Batches[0].ID)
+		assert.Equal(t, 3, list.Batches[0].Redemptions)
+		assert.Equal(t, 1, list.Batches[0].CodesUsedUp)
+	})
+
+	t.Run("revoked batches can't be redeemed", func(t *testing.T) {
+		batch := createBatch(t, ClaimBatchRequest{Count: 1, ExpiresAt: expiresAt})
+
+		w := doJSON(t, server, "DELETE", "/admin/claim-codes/"+batch.ID, nil, auth)
+		require.Equal(t, http.StatusOK, w.Code)
+		w = doJSON(t, server, "DELETE", "/admin/claim-codes/"+batch.ID, nil, auth)
+		assert.Equal(t, http.StatusNotFound, w.Code)
+
+		status, code := claim(t, batch.ClaimCodes[0], address)
+		assert.Equal(t, http.StatusNotFound, status)
+		assert.Equal(t, CodeInvalidClaimCode, code)
+	})
+}
+
+func TestClaimBatchDefaultAsset(t *testing.T) {
+	server := newFakeBackedServer(t, &fakeClearnode{}, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		cfg.TokenSymbol = "USDC"
+	})
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+
+	// The configured token is stored like an explicitly requested one, so
+	// usage of both is tracked under the same symbol
+	for _, asset := range []string{"", "usdc"} {
+		w := doJSON(t, server, "POST", "/admin/claim-codes", ClaimBatchRequest{Count: 1, Asset: asset, ExpiresAt: time.Now().Add(time.Hour)}, auth)
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateClaimBatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		assert.Equal(t, "usdc", created.Asset)
+	}
+}
@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+
+	"faucet-server/internal/clearnode"
+)
+
+// ClearnodeBackend dispenses tokens from the faucet's Clearnode ledger. The
+// contexts passed to it are not used: the client bounds every request with
+// its own response timeout.
+type ClearnodeBackend struct {
+	client *clearnode.Client
+}
+
+var (
+	_ Backend           = (*ClearnodeBackend)(nil)
+	_ ConnectionManager = (*ClearnodeBackend)(nil)
+	_ AssetLister       = (*ClearnodeBackend)(nil)
+)
+
+func NewClearnodeBackend(client *clearnode.Client) *ClearnodeBackend {
+	return &ClearnodeBackend{client: client}
+}
+
+// Transfer returns the ID of the ledger transaction.
+func (b *ClearnodeBackend) Transfer(_ context.Context, destination, asset string, amount decimal.Decimal) (string, error) {
+	result, err := b.client.Transfer(destination, asset, amount)
+	if err != nil || len(result.Transactions) == 0 {
+		return "", err
+	}
+	return fmt.Sprintf("%d", result.Transactions[0].Id), nil
+}
+
+func (b *ClearnodeBackend) Balance(_ context.Context, asset string) (decimal.Decimal, error) {
+	balance, err := b.client.GetFaucetBalance(asset)
+	if err != nil {
+		return decimal.Decimal{}, err
+	}
+	return balance.Amount, nil
+}
+
+// CheckOperational reconnects if the connection was lost and checks that
+// Clearnode supports asset.
+func (b *ClearnodeBackend) CheckOperational(_ context.Context, asset string) error {
+	if err := b.client.EnsureConnected(); err != nil {
+		return fmt.Errorf("%w: %w", clearnode.ErrNotConnected, err)
+	}
+
+	if err := b.client.ValidateTokenSupport(asset); err != nil {
+		return fmt.Errorf("token validation failed: %w", err)
+	}
+	return nil
+}
+
+// Address returns the session key address, which Clearnode transfers are
+// sent from.
+func (b *ClearnodeBackend) Address() common.Address {
+	return b.client.GetSessionKeyAddress()
+}
+
+func (b *ClearnodeBackend) Status() clearnode.ConnectionStatus {
+	return b.client.Status()
+}
+
+func (b *ClearnodeBackend) Reconnect() error {
+	return b.client.Reconnect()
+}
+
+// Assets returns the assets listed by the last GetAssets call, which every
+// operational check makes.
+func (b *ClearnodeBackend) Assets() []Asset {
+	var assets []Asset
+	for _, asset := range b.client.LastAssets() {
+		assets = append(assets, Asset{
+			Symbol:   asset.Symbol,
+			Token:    asset.Token,
+			ChainID:  uint64(asset.ChainID),
+			Decimals: asset.Decimals,
+		})
+	}
+	return assets
+}
@@ -0,0 +1,281 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/erc7824/nitrolite/clearnode/pkg/rpc"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/config"
+)
+
+// fakeClearnode is an in-memory Backend for handler tests that don't need
+// the wire protocol. Each *Err field makes the corresponding method fail.
+type fakeClearnode struct {
+	connectErr     error
+	operationalErr error
+	transferErr    error
+	balanceErr     error
+	balance        decimal.Decimal
+	assets         []Asset
+	// When set, transfers signal transferStarted and wait until
+	// transferGate is closed or their context is done
+	transferStarted chan struct{}
+	transferGate    chan struct{}
+
+	mu        sync.Mutex
+	transfers []rpc.TransferRequest
+}
+
+var (
+	_ Backend           = (*fakeClearnode)(nil)
+	_ ConnectionManager = (*fakeClearnode)(nil)
+	_ AssetLister       = (*fakeClearnode)(nil)
+)
+
+func (f *fakeClearnode) Transfer(ctx context.Context, destination, asset string, amount decimal.Decimal) (string, error) {
+	if f.transferErr != nil {
+		return "", f.transferErr
+	}
+	if f.transferGate != nil {
+		f.transferStarted <- struct{}{}
+		select {
+		case <-f.transferGate:
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.transfers = append(f.transfers, rpc.TransferRequest{
+		Destination: destination,
+		Allocations: []rpc.TransferAllocation{{AssetSymbol: asset, Amount: amount}},
+	})
+	return fmt.Sprintf("%d", len(f.transfers)), nil
+}
+
+func (f *fakeClearnode) Balance(context.Context, string) (decimal.Decimal, error) {
+	if f.balanceErr != nil {
+		return decimal.Decimal{}, f.balanceErr
+	}
+	return f.balance, nil
+}
+
+func (f *fakeClearnode) CheckOperational(context.Context, string) error {
+	if f.connectErr != nil {
+		return fmt.Errorf("%w: %w", clearnode.ErrNotConnected, f.connectErr)
+	}
+	return f.operationalErr
+}
+
+func (f *fakeClearnode) Address() common.Address {
+	return common.HexToAddress("0x02")
+}
+
+func (f *fakeClearnode) Status() clearnode.ConnectionStatus {
+	return clearnode.ConnectionStatus{
+		Connected:         f.connectErr == nil,
+		Authenticated:     f.connectErr == nil,
+		OwnerAddress:      common.HexToAddress("0x01").Hex(),
+		SessionKeyAddress: f.Address().Hex(),
+	}
+}
+
+func (f *fakeClearnode) Reconnect() error {
+	return f.connectErr
+}
+
+func (f *fakeClearnode) Assets() []Asset {
+	return f.assets
+}
+
+// newFakeBackedServer creates a Server backed by fake instead of a Clearnode
+// connection.
+func newFakeBackedServer(t *testing.T, fake *fakeClearnode, mutate func(cfg *config.Config), opts ...Option) *Server {
+	t.Helper()
+
+	initTestLogger(t)
+
+	cfg := &config.Config{
+		TokenSymbol:              "usdc",
+		StandardTipAmount:        "10",
+		StandardTipAmountDecimal: decimal.NewFromInt(10),
+		MinTransferCount:         1,
+		AirdropConcurrency:       4,
+		AirdropMaxRows:           1000,
+		LogLevel:                 "debug",
+	}
+	if mutate != nil {
+		mutate(cfg)
+	}
+
+	server, err := NewServer(cfg, fake, newMemoryStore(t), opts...)
+	require.NoError(t, err)
+	return server
+}
+
+func TestRequestTokensBackendErrors(t *testing.T) {
+	funded := decimal.NewFromInt(1000)
+	tests := []struct {
+		name   string
+		fake   *fakeClearnode
+		status int
+		code   ErrorCode
+	}{
+		{"success", &fakeClearnode{balance: funded}, http.StatusOK, ""},
+		{"connection failed", &fakeClearnode{connectErr: errors.New("dial refused")}, http.StatusServiceUnavailable, CodeClearnodeUnavailable},
+		{"balance below minimum", &fakeClearnode{balance: decimal.NewFromInt(9)}, http.StatusServiceUnavailable, CodeInsufficientFaucetBalance},
+		{"balance unavailable", &fakeClearnode{balanceErr: fmt.Errorf("get_ledger_balances failed: %w", clearnode.ErrTimeout)}, http.StatusServiceUnavailable, CodeClearnodeUnavailable},
+		{"token not supported", &fakeClearnode{operationalErr: fmt.Errorf("token validation failed: %w", clearnode.ErrTokenNotSupported)}, http.StatusServiceUnavailable, CodeServiceUnavailable},
+		{"transfer timeout", &fakeClearnode{balance: funded, transferErr: fmt.Errorf("transfer %w", clearnode.ErrTimeout)}, http.StatusGatewayTimeout, CodeTransferTimeout},
+		{"transfer connection lost", &fakeClearnode{balance: funded, transferErr: clearnode.ErrNotConnected}, http.StatusServiceUnavailable, CodeClearnodeUnavailable},
+		{"transfer rejected", &fakeClearnode{balance: funded, transferErr: &clearnode.RPCError{Method: "transfer", Message: "invalid destination"}}, http.StatusInternalServerError, CodeTransferFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeBackedServer(t, tt.fake, nil)
+			address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+			w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+			require.Equal(t, tt.status, w.Code, w.Body.String())
+
+			if tt.code == "" {
+				var response FaucetResponse
+				require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, "1", response.TxID)
+				require.Len(t, tt.fake.transfers, 1)
+				assert.Equal(t, address, tt.fake.transfers[0].Destination)
+				return
+			}
+
+			var response ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+			assert.Equal(t, tt.code, response.Code)
+			assert.Empty(t, tt.fake.transfers)
+		})
+	}
+}
+
+func TestAdminBackendChecks(t *testing.T) {
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	withAdmin := func(cfg *config.Config) { cfg.AdminToken = testAdminToken }
+
+	tests := []struct {
+		name   string
+		fake   *fakeClearnode
+		path   string
+		status int
+	}{
+		{"check passes", &fakeClearnode{balance: decimal.NewFromInt(10)}, "/admin/check", http.StatusOK},
+		{"check balance below minimum", &fakeClearnode{balance: decimal.NewFromInt(9)}, "/admin/check", http.StatusServiceUnavailable},
+		{"check not operational", &fakeClearnode{operationalErr: clearnode.ErrInsufficientBalance}, "/admin/check", http.StatusServiceUnavailable},
+		{"check disconnected", &fakeClearnode{connectErr: clearnode.ErrNotConnected}, "/admin/check", http.StatusServiceUnavailable},
+		{"reconnect", &fakeClearnode{}, "/admin/reconnect", http.StatusOK},
+		{"reconnect fails", &fakeClearnode{connectErr: clearnode.ErrAuthenticationFailed}, "/admin/reconnect", http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeBackedServer(t, tt.fake, withAdmin)
+			w := doJSON(t, server, "POST", tt.path, nil, auth)
+			assert.Equal(t, tt.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestInfoFromBackend(t *testing.T) {
+	fake := &fakeClearnode{
+		balance: decimal.NewFromInt(95),
+		assets:  []Asset{{Symbol: "usdc", ChainID: 1, Token: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48", Decimals: 6}},
+	}
+	server := newFakeBackedServer(t, fake, nil)
+
+	_, err := server.fetchBalance(context.Background(), "usdc")
+	require.NoError(t, err)
+
+	w := doJSON(t, server, "GET", "/info", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, strings.ToLower(fake.Address().Hex()), info["faucet_address"])
+	assert.Equal(t, "95", info["balance"])
+	assert.EqualValues(t, 9, info["tips_remaining"])
+	assert.Len(t, info["assets"], 1)
+}
+
+func TestBackendWithoutConnection(t *testing.T) {
+	initTestLogger(t)
+
+	// Embedding only the interface hides the optional methods of the fake
+	backend := struct{ Backend }{&fakeClearnode{balance: decimal.NewFromInt(10)}}
+	server, err := NewServer(&config.Config{
+		TokenSymbol:              "usdc",
+		StandardTipAmountDecimal: decimal.NewFromInt(10),
+		MinTransferCount:         1,
+		AdminToken:               testAdminToken,
+	}, backend, newMemoryStore(t))
+	require.NoError(t, err)
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+
+	w := doJSON(t, server, "POST", "/admin/reconnect", nil, auth)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+
+	w = doJSON(t, server, "GET", "/info", nil, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	var info map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+	assert.Equal(t, backend.Address().Hex(), info["session_key_address"])
+	assert.Equal(t, map[string]interface{}{"connected": true, "authenticated": true}, info["clearnode"])
+	assert.Empty(t, info["assets"])
+}
+
+func TestDrainWaitsForTransfers(t *testing.T) {
+	fake := &fakeClearnode{
+		balance:         decimal.NewFromInt(1000),
+		transferStarted: make(chan struct{}, 1),
+		transferGate:    make(chan struct{}),
+	}
+	server := newFakeBackedServer(t, fake, nil)
+	request := FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}
+
+	responded := make(chan int, 1)
+	go func() { responded <- doJSON(t, server, "POST", "/requestTokens", request, nil).Code }()
+	<-fake.transferStarted
+
+	drained := make(chan struct{})
+	go func() {
+		server.Drain()
+		close(drained)
+	}()
+
+	select {
+	case <-drained:
+		t.Fatal("Drain returned while a transfer was in flight")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(fake.transferGate)
+	<-drained
+	assert.Equal(t, http.StatusOK, <-responded)
+
+	// Requests reaching a transfer after Drain are turned away
+	w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+	assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+	assert.Len(t, fake.transfers, 1)
+}
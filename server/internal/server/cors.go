@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+)
+
+// corsMiddleware applies the CORS policy of the configuration in effect, so
+// it follows reloads. Disallowed origins get no CORS headers, and are
+// rejected outright on the token endpoints so that other sites can't request
+// tokens through their visitors' browsers. /info stays readable from any
+// origin.
+func (s *Server) corsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if origin := c.GetHeader("Origin"); origin != "" {
+			cfg := s.Config()
+			path := strings.TrimPrefix(c.Request.URL.Path, APIVersionPrefix)
+			c.Writer.Header().Add("Vary", "Origin")
+
+			switch {
+			case originAllowed(cfg.CORSAllowedOrigins, origin):
+				setCORSHeaders(c, cfg, origin)
+			case path == "/info":
+				c.Header("Access-Control-Allow-Origin", "*")
+			case path == "/requestTokens" || path == "/claim":
+				logger.Warnf("Rejected %s %s from disallowed origin %s", c.Request.Method, c.Request.URL.Path, origin)
+				c.AbortWithStatusJSON(http.StatusForbidden, newErrorResponse(CodeOriginNotAllowed, ErrOriginNotAllowed))
+				return
+			}
+		}
+
+		if c.Request.Method == http.MethodOptions {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func setCORSHeaders(c *gin.Context, cfg *config.Config, origin string) {
+	if cfg.CORSAllowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+	c.Header("Access-Control-Allow-Origin", origin)
+
+	// Preflight
+	if c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != "" {
+		c.Header("Access-Control-Allow-Methods", strings.Join(cfg.CORSAllowedMethods, ", "))
+		c.Header("Access-Control-Allow-Headers", strings.Join(cfg.CORSAllowedHeaders, ", "))
+		if seconds := int(cfg.CORSMaxAge.Seconds()); seconds > 0 {
+			c.Header("Access-Control-Max-Age", strconv.Itoa(seconds))
+		}
+	}
+}
+
+// originAllowed matches origin against exact origins, "*" and wildcard
+// subdomain patterns such as https://*.example.com, which match any
+// subdomain but not example.com itself.
+func originAllowed(patterns []string, origin string) bool {
+	origin = strings.ToLower(origin)
+
+	for _, pattern := range patterns {
+		pattern = strings.ToLower(strings.TrimSpace(pattern))
+		if pattern == "*" || pattern == origin {
+			return true
+		}
+
+		scheme, domain, ok := strings.Cut(pattern, "://*.")
+		if !ok {
+			continue
+		}
+
+		subdomain, ok := strings.CutPrefix(origin, scheme+"://")
+		if !ok {
+			continue
+		}
+		subdomain, ok = strings.CutSuffix(subdomain, "."+domain)
+		if ok && subdomain != "" && !strings.ContainsAny(subdomain, ":/@") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+)
+
+func TestOriginAllowed(t *testing.T) {
+	patterns := []string{"https://app.example.com", "https://*.example.org", "http://*.localhost:3000"}
+
+	tests := []struct {
+		origin  string
+		allowed bool
+	}{
+		{"https://app.example.com", true},
+		{"https://APP.example.com", true},
+		{"http://app.example.com", false},
+		{"https://other.example.com", false},
+		{"https://a.example.org", true},
+		{"https://a.b.example.org", true},
+		{"https://example.org", false},
+		{"https://evil.com/.example.org", false},
+		{"https://evilexample.org", false},
+		{"http://dev.localhost:3000", true},
+		{"http://dev.localhost:4000", false},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.allowed, originAllowed(patterns, tt.origin), tt.origin)
+	}
+
+	assert.True(t, originAllowed([]string{"*"}, "https://anything.example"))
+	assert.False(t, originAllowed(nil, "https://app.example.com"))
+}
+
+func TestCORS(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.CORSAllowedOrigins = []string{"https://app.example.com", "https://*.example.org"}
+		cfg.CORSAllowedMethods = []string{"GET", "POST", "OPTIONS"}
+		cfg.CORSAllowedHeaders = []string{"Content-Type"}
+		cfg.CORSAllowCredentials = true
+		cfg.CORSMaxAge = 10 * time.Minute
+	})
+	request := FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}
+
+	t.Run("echoes allowed origins", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", request, map[string]string{"Origin": "https://faucet.example.org"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://faucet.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Contains(t, w.Header().Values("Vary"), "Origin")
+	})
+
+	t.Run("answers preflight requests", func(t *testing.T) {
+		w := doJSON(t, server, "OPTIONS", "/v1/requestTokens", nil, map[string]string{
+			"Origin":                        "https://app.example.com",
+			"Access-Control-Request-Method": "POST",
+		})
+		require.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("rejects disallowed origins on the token endpoint", func(t *testing.T) {
+		for _, method := range []string{"POST", "OPTIONS"} {
+			w := doJSON(t, server, method, "/requestTokens", request, map[string]string{"Origin": "https://evil.example.com"})
+			require.Equal(t, http.StatusForbidden, w.Code, method)
+			assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+			var errorResponse ErrorResponse
+			require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+			assert.Equal(t, CodeOriginNotAllowed, errorResponse.Code)
+		}
+	})
+
+	t.Run("keeps info public", func(t *testing.T) {
+		w := doJSON(t, server, "GET", "/v1/info", nil, map[string]string{"Origin": "https://evil.example.com"})
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("serves requests without an origin", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/onchain"
+	"faucet-server/internal/store"
+)
+
+// ErrorCode is a stable, machine-readable identifier for an error response.
+// Clients should branch on the code rather than on the message text.
+type ErrorCode string
+
+const (
+	CodeInvalidRequest            ErrorCode = "INVALID_REQUEST"
+	CodeInvalidAddress            ErrorCode = "INVALID_ADDRESS"
+	CodeAddressDenied             ErrorCode = "ADDRESS_DENIED"
+	CodeAddressNotAllowlisted     ErrorCode = "ADDRESS_NOT_ALLOWLISTED"
+	CodeCaptchaRequired           ErrorCode = "CAPTCHA_REQUIRED"
+	CodeCaptchaFailed             ErrorCode = "CAPTCHA_FAILED"
+	CodeCaptchaUnavailable        ErrorCode = "CAPTCHA_UNAVAILABLE"
+	CodeProofOfWorkRequired       ErrorCode = "PROOF_OF_WORK_REQUIRED"
+	CodeInvalidChallenge          ErrorCode = "INVALID_CHALLENGE"
+	CodeInvalidSolution           ErrorCode = "INVALID_SOLUTION"
+	CodeTooManyChallenges         ErrorCode = "TOO_MANY_CHALLENGES"
+	CodeSignatureRequired         ErrorCode = "SIGNATURE_REQUIRED"
+	CodeInvalidSignature          ErrorCode = "INVALID_SIGNATURE"
+	CodeBudgetExhausted           ErrorCode = "BUDGET_EXHAUSTED"
+	CodeFaucetPaused              ErrorCode = "FAUCET_PAUSED"
+	CodeClearnodeUnavailable      ErrorCode = "CLEARNODE_UNAVAILABLE"
+	CodeInsufficientFaucetBalance ErrorCode = "INSUFFICIENT_FAUCET_BALANCE"
+	CodeServiceUnavailable        ErrorCode = "SERVICE_UNAVAILABLE"
+	CodeTransferTimeout           ErrorCode = "TRANSFER_TIMEOUT"
+	CodeTransferFailed            ErrorCode = "TRANSFER_FAILED"
+	CodeInternalError             ErrorCode = "INTERNAL_ERROR"
+	CodeOriginNotAllowed          ErrorCode = "ORIGIN_NOT_ALLOWED"
+	CodeInvalidAPIKey             ErrorCode = "INVALID_API_KEY"
+	CodeIPNotAllowed              ErrorCode = "IP_NOT_ALLOWED"
+	CodeAssetNotAllowed           ErrorCode = "ASSET_NOT_ALLOWED"
+	CodeQuotaExceeded             ErrorCode = "QUOTA_EXCEEDED"
+	CodeAirdropInProgress         ErrorCode = "AIRDROP_IN_PROGRESS"
+	CodeInvalidClaimCode          ErrorCode = "INVALID_CLAIM_CODE"
+	CodeClaimCodeExpired          ErrorCode = "CLAIM_CODE_EXPIRED"
+	CodeClaimCodeUsedUp           ErrorCode = "CLAIM_CODE_USED_UP"
+	CodeClaimCodeAlreadyRedeemed  ErrorCode = "CLAIM_CODE_ALREADY_REDEEMED"
+	CodeUnauthorized              ErrorCode = "UNAUTHORIZED"
+	CodeNotFound                  ErrorCode = "NOT_FOUND"
+)
+
+// retryableCodes are the codes for which resending the same request later
+// may succeed. A timed-out transfer is deliberately not retryable: it may
+// still complete, and a blind retry could pay out twice.
+var retryableCodes = map[ErrorCode]bool{
+	CodeCaptchaUnavailable:   true,
+	CodeBudgetExhausted:      true,
+	CodeQuotaExceeded:        true,
+	CodeFaucetPaused:         true,
+	CodeClearnodeUnavailable: true,
+	CodeServiceUnavailable:   true,
+	CodeInternalError:        true,
+}
+
+func newErrorResponse(code ErrorCode, message string) ErrorResponse {
+	return ErrorResponse{
+		Error:     message,
+		Code:      code,
+		Retryable: retryableCodes[code],
+	}
+}
+
+// backendError maps a failed backend connection or operational check to the
+// response status and body.
+func backendError(err error) (int, ErrorResponse) {
+	switch {
+	case errors.Is(err, clearnode.ErrInsufficientBalance), errors.Is(err, onchain.ErrInsufficientBalance):
+		return http.StatusServiceUnavailable, newErrorResponse(CodeInsufficientFaucetBalance, ErrInsufficientFaucetBalance)
+	case errors.Is(err, clearnode.ErrNotConnected),
+		errors.Is(err, clearnode.ErrAuthenticationFailed),
+		errors.Is(err, clearnode.ErrTimeout):
+		return http.StatusServiceUnavailable, newErrorResponse(CodeClearnodeUnavailable, ErrClearnodeConnectionFailed)
+	default:
+		return http.StatusServiceUnavailable, newErrorResponse(CodeServiceUnavailable, ErrServiceUnavailable)
+	}
+}
+
+// errShuttingDown is returned for transfers requested once Drain was called.
+var errShuttingDown = errors.New("server is shutting down")
+
+// notSentError wraps an error of sendTransfer from before the transfer was
+// sent, so the caller knows nothing left the faucet.
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string { return e.err.Error() }
+func (e *notSentError) Unwrap() error { return e.err }
+
+// writeSendError writes the error response for a failed sendTransfer.
+func writeSendError(c *gin.Context, err error) {
+	var notSent *notSentError
+	var exhausted *store.BudgetExhaustedError
+	switch {
+	case !errors.As(err, &notSent):
+		c.JSON(transferError(err))
+	case errors.As(err, &exhausted), errors.Is(err, errBudgetNotRecorded):
+		writeBudgetError(c, err)
+	default:
+		c.JSON(backendError(err))
+	}
+}
+
+// transferError maps a failed transfer to the response status and body.
+func transferError(err error) (int, ErrorResponse) {
+	switch {
+	case transferTimedOut(err):
+		return http.StatusGatewayTimeout, newErrorResponse(CodeTransferTimeout, ErrTransferTimeout)
+	case errors.Is(err, clearnode.ErrNotConnected):
+		return http.StatusServiceUnavailable, newErrorResponse(CodeClearnodeUnavailable, ErrClearnodeConnectionFailed)
+	case errors.Is(err, clearnode.ErrInsufficientBalance), errors.Is(err, onchain.ErrInsufficientBalance):
+		return http.StatusServiceUnavailable, newErrorResponse(CodeInsufficientFaucetBalance, ErrInsufficientFaucetBalance)
+	case errors.Is(err, onchain.ErrFeeTooHigh):
+		return http.StatusServiceUnavailable, newErrorResponse(CodeServiceUnavailable, ErrServiceUnavailable)
+	default:
+		return http.StatusInternalServerError, newErrorResponse(CodeTransferFailed, ErrTransferFailed)
+	}
+}
+
+// transferTimedOut reports whether err leaves the outcome of a transfer
+// unknown: it was sent but not confirmed in time and may still go through.
+func transferTimedOut(err error) bool {
+	return errors.Is(err, clearnode.ErrTimeout) || errors.Is(err, onchain.ErrReceiptTimeout)
+}
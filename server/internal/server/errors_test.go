@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/clearnodetest"
+)
+
+func TestErrorClassification(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		status    int
+		code      ErrorCode
+		retryable bool
+	}{
+		{"timeout", fmt.Errorf("transfer failed: %w", clearnode.ErrTimeout), http.StatusGatewayTimeout, CodeTransferTimeout, false},
+		{"connection lost", fmt.Errorf("transfer failed: %w", clearnode.ErrNotConnected), http.StatusServiceUnavailable, CodeClearnodeUnavailable, true},
+		{"insufficient funds", &clearnode.RPCError{Method: "transfer", Message: "Insufficient funds"}, http.StatusServiceUnavailable, CodeInsufficientFaucetBalance, false},
+		{"rejected", &clearnode.RPCError{Method: "transfer", Message: "invalid destination"}, http.StatusInternalServerError, CodeTransferFailed, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, response := transferError(tt.err)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.code, response.Code)
+			assert.Equal(t, tt.retryable, response.Retryable)
+		})
+	}
+
+	t.Run("operational check", func(t *testing.T) {
+		_, response := backendError(fmt.Errorf("balance check failed: %w", clearnode.ErrInsufficientBalance))
+		assert.Equal(t, CodeInsufficientFaucetBalance, response.Code)
+
+		_, response = backendError(fmt.Errorf("auth_verify %w", clearnode.ErrTimeout))
+		assert.Equal(t, CodeClearnodeUnavailable, response.Code)
+		assert.True(t, response.Retryable)
+
+		_, response = backendError(fmt.Errorf("token validation failed: %w", clearnode.ErrTokenNotSupported))
+		assert.Equal(t, CodeServiceUnavailable, response.Code)
+	})
+}
+
+func TestTransferErrorCodes(t *testing.T) {
+	server, mockClearnode := newTestServer(t, nil)
+	request := FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}
+
+	t.Run("invalid address", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: "invalid"}, nil)
+		require.Equal(t, http.StatusBadRequest, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, CodeInvalidAddress, errorResponse.Code)
+		assert.False(t, errorResponse.Retryable)
+	})
+
+	t.Run("transfer rejected for lack of funds", func(t *testing.T) {
+		mockClearnode.InjectFault(clearnodetest.MethodTransfer, clearnodetest.Fault{Error: "insufficient funds: usdc"})
+
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, CodeInsufficientFaucetBalance, errorResponse.Code)
+		assert.Equal(t, ErrInsufficientFaucetBalance, errorResponse.Error)
+	})
+
+	t.Run("transfer rejected", func(t *testing.T) {
+		mockClearnode.InjectFault(clearnodetest.MethodTransfer, clearnodetest.Fault{Error: "destination is blocked"})
+
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		require.Equal(t, http.StatusInternalServerError, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, CodeTransferFailed, errorResponse.Code)
+		assert.Equal(t, ErrTransferFailed, errorResponse.Error)
+	})
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/store"
+	"faucet-server/internal/version"
+)
+
+func (s *Server) getInfo(c *gin.Context) {
+	cfg := s.Config()
+
+	status := s.connectionStatus()
+
+	info := gin.H{
+		"service":             "Nitrolite Faucet Server",
+		"version":             version.Version,
+		"commit":              version.Commit,
+		"faucet_address":      s.backendFor(cfg.TokenSymbol).Address(),
+		"owner_address":       status.OwnerAddress,
+		"session_key_address": status.SessionKeyAddress,
+		"standard_tip_amount": cfg.StandardTipAmountDecimal.String(),
+		"token_symbol":        cfg.TokenSymbol,
+		"paused":              s.pause.Load() != nil,
+		"clearnode": gin.H{
+			"connected":     status.Connected,
+			"authenticated": status.Authenticated,
+		},
+		"verification": s.verificationInfo(cfg),
+		"assets":       s.assetInfo(cfg.TokenSymbol),
+		"endpoints":    s.publicEndpoints(),
+	}
+
+	if version.BuildTime != "" {
+		info["build_time"] = version.BuildTime
+	}
+
+	// Reported from the last operational check to avoid a Clearnode round trip
+	if balance, ok := s.balances.load(cfg.TokenSymbol); ok {
+		info["balance"] = balance.Amount.String()
+		info["balance_updated_at"] = balance.FetchedAt.UTC()
+		if cfg.StandardTipAmountDecimal.IsPositive() {
+			info["tips_remaining"] = balance.Amount.Div(cfg.StandardTipAmountDecimal).Floor().IntPart()
+		}
+	}
+
+	if onchain := s.onchainInfo(); onchain != nil {
+		info["onchain"] = onchain
+	}
+
+	if budget := s.budgetInfo(cfg, cfg.TokenSymbol); budget != nil {
+		info["budget"] = budget
+	}
+
+	if apiKey := s.apiKeyInfo(c); apiKey != nil {
+		info["api_key"] = apiKey
+	}
+
+	c.JSON(http.StatusOK, info)
+}
+
+// verificationInfo reports the enabled bot deterrents and the parameters
+// that scale the proof-of-work difficulty with request volume.
+func (s *Server) verificationInfo(cfg *config.Config) gin.H {
+	verification := gin.H{
+		"captcha":        s.captchaVerifier != nil,
+		"proof_of_work":  cfg.PowEnabled,
+		"signature":      cfg.RequireSignature,
+		"pow_difficulty": s.currentDifficulty(),
+	}
+
+	if cfg.PowEnabled {
+		verification["pow_target_rate"] = cfg.PowTargetRate
+		verification["pow_window_seconds"] = int64(cfg.PowWindow.Seconds())
+	}
+	return verification
+}
+
+// apiKeyInfo reports the daily quota of the API key the request carries, if
+// any. Invalid keys are ignored, as /info is public.
+func (s *Server) apiKeyInfo(c *gin.Context) gin.H {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		return nil
+	}
+
+	key, err := s.store.AuthenticateAPIKey(strings.TrimSpace(token))
+	if err != nil {
+		return nil
+	}
+
+	now := time.Now()
+	return gin.H{
+		"name":           key.Name,
+		"daily_quota":    key.DailyQuota,
+		"requests_today": key.RequestsToday(now),
+		"resets_at":      store.Daily.WindowEnd(now).Format(time.RFC3339),
+	}
+}
+
+// assetInfo lists the metadata of tokenSymbol on every chain it is available
+// on, as of the backend's last fetch.
+func (s *Server) assetInfo(tokenSymbol string) []gin.H {
+	assets := []gin.H{}
+	lister, ok := s.backendFor(tokenSymbol).(AssetLister)
+	if !ok {
+		return assets
+	}
+
+	for _, asset := range lister.Assets() {
+		if !strings.EqualFold(asset.Symbol, tokenSymbol) {
+			continue
+		}
+
+		assets = append(assets, gin.H{
+			"symbol":   asset.Symbol,
+			"token":    asset.Token,
+			"chain_id": asset.ChainID,
+			"decimals": asset.Decimals,
+		})
+	}
+	return assets
+}
+
+// publicEndpoints lists the registered non-admin routes. Every route is also
+// served under APIVersionPrefix, so only the unprefixed paths are reported.
+func (s *Server) publicEndpoints() []string {
+	var endpoints []string
+	for _, route := range s.router.Routes() {
+		if strings.HasPrefix(route.Path, APIVersionPrefix+"/") || strings.HasPrefix(route.Path, "/admin") ||
+			slices.Contains(endpoints, route.Path) {
+			continue
+		}
+		endpoints = append(endpoints, route.Path)
+	}
+
+	slices.Sort(endpoints)
+	return endpoints
+}
@@ -0,0 +1,119 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/version"
+)
+
+func TestInfo(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		enableActivityFeed(cfg)
+		cfg.RequireSignature = true
+		cfg.ChallengeSecret = "challenge-secret"
+		cfg.ChallengeTTL = time.Minute
+	})
+
+	getInfo := func(t *testing.T) map[string]interface{} {
+		t.Helper()
+		w := doJSON(t, server, "GET", "/info", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var info map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		return info
+	}
+
+	t.Run("reports build, addresses and connectivity", func(t *testing.T) {
+		info := getInfo(t)
+
+		assert.Equal(t, version.Version, info["version"])
+		assert.Contains(t, info, "commit")
+		assert.Equal(t, server.connectionStatus().OwnerAddress, info["owner_address"])
+		assert.Equal(t, server.connectionStatus().SessionKeyAddress, info["session_key_address"])
+		assert.Equal(t, false, info["paused"])
+		assert.Equal(t, map[string]interface{}{"connected": true, "authenticated": true}, info["clearnode"])
+
+		verification := info["verification"].(map[string]interface{})
+		assert.Equal(t, true, verification["signature"])
+		assert.Equal(t, false, verification["captcha"])
+		assert.Equal(t, false, verification["proof_of_work"])
+		assert.NotContains(t, verification, "pow_target_rate")
+
+		assert.ElementsMatch(t, []interface{}{"/challenge", "/claim", "/events", "/info", "/openapi.json", "/recent", "/requestTokens"}, info["endpoints"])
+	})
+
+	t.Run("omits balance before the first operational check", func(t *testing.T) {
+		info := getInfo(t)
+		assert.NotContains(t, info, "balance")
+		assert.Empty(t, info["assets"])
+	})
+
+	t.Run("reports cached balance and asset metadata", func(t *testing.T) {
+		cfg := server.Config()
+		require.NoError(t, server.ensureOperational(context.Background(), cfg, cfg.TokenSymbol, cfg.StandardTipAmountDecimal))
+
+		info := getInfo(t)
+		assert.Equal(t, "1000000000", info["balance"])
+		assert.Equal(t, float64(100000000), info["tips_remaining"])
+		assert.NotEmpty(t, info["balance_updated_at"])
+
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"symbol":   "usdc",
+			"token":    "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+			"chain_id": float64(1),
+			"decimals": float64(6),
+		}}, info["assets"])
+	})
+}
+
+func TestInfoLimits(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		enableProofOfWork(cfg)
+		cfg.PowTargetRate = 30
+		cfg.PowWindow = 2 * time.Minute
+		cfg.AdminToken = testAdminToken
+	})
+
+	getInfo := func(t *testing.T, headers map[string]string) map[string]interface{} {
+		t.Helper()
+		w := doJSON(t, server, "GET", "/info", nil, headers)
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var info map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		return info
+	}
+
+	t.Run("reports proof-of-work rate parameters", func(t *testing.T) {
+		verification := getInfo(t, nil)["verification"].(map[string]interface{})
+		assert.Equal(t, float64(30), verification["pow_target_rate"])
+		assert.Equal(t, float64(120), verification["pow_window_seconds"])
+	})
+
+	t.Run("reports the quota of the presented API key", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/admin/api-keys", APIKeyRequest{Name: "partner", DailyQuota: 5},
+			map[string]string{"Authorization": "Bearer " + testAdminToken})
+		require.Equal(t, http.StatusCreated, w.Code, w.Body.String())
+
+		var created CreateAPIKeyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+
+		apiKey := getInfo(t, map[string]string{"Authorization": "Bearer " + created.Token})["api_key"].(map[string]interface{})
+		assert.Equal(t, "partner", apiKey["name"])
+		assert.Equal(t, float64(5), apiKey["daily_quota"])
+		assert.Equal(t, float64(0), apiKey["requests_today"])
+		assert.NotEmpty(t, apiKey["resets_at"])
+
+		assert.NotContains(t, getInfo(t, nil), "api_key")
+		assert.NotContains(t, getInfo(t, map[string]string{"Authorization": "Bearer fk_unknown"}), "api_key")
+	})
+}
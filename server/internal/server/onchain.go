@@ -0,0 +1,92 @@
+package server
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/onchain"
+)
+
+// Option configures optional parts of a Server.
+type Option func(*Server)
+
+// WithAssetBackend dispenses assets from backend instead of the default
+// backend.
+func WithAssetBackend(backend Backend, assets ...string) Option {
+	return func(s *Server) {
+		if s.assetBackends == nil {
+			s.assetBackends = make(map[string]Backend)
+		}
+		for _, asset := range assets {
+			s.assetBackends[strings.ToLower(asset)] = backend
+		}
+	}
+}
+
+// WithOnchainSender dispenses the assets handled by sender as on-chain
+// transactions instead of through Clearnode.
+func WithOnchainSender(sender *onchain.Sender) Option {
+	backend := &onchainBackend{Sender: sender}
+
+	var symbols []string
+	for _, token := range sender.Tokens() {
+		symbols = append(symbols, token.Symbol)
+	}
+	return WithAssetBackend(backend, symbols...)
+}
+
+// onchainBackend adapts an onchain.Sender, which already implements the
+// transfer, balance and operational calls of Backend, to AssetLister.
+type onchainBackend struct {
+	*onchain.Sender
+}
+
+var (
+	_ Backend     = (*onchainBackend)(nil)
+	_ AssetLister = (*onchainBackend)(nil)
+)
+
+// Assets returns the dispensed tokens. Their metadata is fetched once, when
+// the sender is created.
+func (b *onchainBackend) Assets() []Asset {
+	chainID := b.ChainID().Uint64()
+
+	var assets []Asset
+	for _, token := range b.Tokens() {
+		assets = append(assets, Asset{
+			Symbol:   token.Symbol,
+			Token:    token.Address.Hex(),
+			ChainID:  chainID,
+			Decimals: uint8(token.Decimals),
+		})
+	}
+	return assets
+}
+
+// info describes the sender for GET /info.
+func (b *onchainBackend) info() gin.H {
+	symbols := []string{}
+	for _, token := range b.Tokens() {
+		symbols = append(symbols, token.Symbol)
+	}
+
+	return gin.H{
+		"address":  b.Address().Hex(),
+		"chain_id": b.ChainID(),
+		"assets":   symbols,
+	}
+}
+
+// onchainInfo describes the on-chain sender for GET /info, or returns nil
+// when no asset is dispensed on-chain.
+func (s *Server) onchainInfo() gin.H {
+	for _, asset := range slices.Sorted(maps.Keys(s.assetBackends)) {
+		if backend, ok := s.assetBackends[asset].(*onchainBackend); ok {
+			return backend.info()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/core/types"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/ethclient/simulated"
+	"github.com/ethereum/go-ethereum/params"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/onchain"
+)
+
+// autoMiningClient mines a block after every sent transaction.
+type autoMiningClient struct {
+	simulated.Client
+	backend *simulated.Backend
+}
+
+func (a *autoMiningClient) SendTransaction(ctx context.Context, tx *types.Transaction) error {
+	if err := a.Client.SendTransaction(ctx, tx); err != nil {
+		return err
+	}
+	a.backend.Commit()
+	return nil
+}
+
+// newOnchainSender returns a sender dispensing native "eth" from an account
+// holding 10 ETH on a simulated chain. Unless mining is false, every
+// transaction is mined right away.
+func newOnchainSender(t *testing.T, mining bool) (*onchain.Sender, simulated.Client) {
+	t.Helper()
+
+	key, err := crypto.HexToECDSA(testSignerKey)
+	require.NoError(t, err)
+
+	chain := simulated.NewBackend(types.GenesisAlloc{
+		crypto.PubkeyToAddress(key.PublicKey): {Balance: new(big.Int).Mul(big.NewInt(10), big.NewInt(params.Ether))},
+	})
+	t.Cleanup(func() { chain.Close() })
+
+	var backend onchain.Backend = chain.Client()
+	if mining {
+		backend = &autoMiningClient{Client: chain.Client(), backend: chain}
+	}
+
+	sender, err := onchain.NewSender(context.Background(), backend, testSignerKey, map[string]common.Address{"eth": {}}, onchain.Options{
+		ReceiptTimeout: 100 * time.Millisecond,
+		PollInterval:   10 * time.Millisecond,
+	})
+	require.NoError(t, err)
+	return sender, chain.Client()
+}
+
+func TestOnchainDispensing(t *testing.T) {
+	sender, client := newOnchainSender(t, true)
+	fake := &fakeClearnode{balance: decimal.NewFromInt(1000), operationalErr: errors.New("must not be checked for on-chain assets")}
+	server := newFakeBackedServer(t, fake, func(cfg *config.Config) {
+		cfg.TokenSymbol = "eth"
+		cfg.StandardTipAmount = "0.5"
+		cfg.StandardTipAmountDecimal = decimal.RequireFromString("0.5")
+	}, WithOnchainSender(sender))
+	address := common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890")
+
+	t.Run("sends a transaction", func(t *testing.T) {
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address.Hex()}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var response FaucetResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "0.5", response.Amount)
+		assert.Equal(t, "eth", response.Asset)
+
+		receipt, err := client.TransactionReceipt(context.Background(), common.HexToHash(response.TxID))
+		require.NoError(t, err)
+		assert.Equal(t, types.ReceiptStatusSuccessful, receipt.Status)
+
+		balance, err := client.BalanceAt(context.Background(), address, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "500000000000000000", balance.String())
+		assert.Empty(t, fake.transfers)
+	})
+
+	t.Run("reports the sender in info", func(t *testing.T) {
+		w := doJSON(t, server, "GET", APIVersionPrefix+"/info", nil, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		validateResponse(t, loadOpenAPIRouter(t), "GET", APIVersionPrefix+"/info", w)
+
+		var info map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &info))
+		assert.Equal(t, strings.ToLower(sender.Address().Hex()), info["faucet_address"])
+		assert.Equal(t, map[string]interface{}{
+			"address":  sender.Address().Hex(),
+			"chain_id": float64(1337),
+			"assets":   []interface{}{"eth"},
+		}, info["onchain"])
+		assert.Equal(t, []interface{}{map[string]interface{}{
+			"symbol":   "eth",
+			"token":    common.Address{}.Hex(),
+			"chain_id": float64(1337),
+			"decimals": float64(18),
+		}}, info["assets"])
+		assert.NotEmpty(t, info["balance"])
+	})
+
+	reload := func(t *testing.T, mutate func(cfg *config.Config)) {
+		t.Helper()
+		cfg := *server.Config()
+		mutate(&cfg)
+		require.NoError(t, server.Reload(&cfg))
+	}
+
+	t.Run("other assets go through Clearnode", func(t *testing.T) {
+		fake.operationalErr = nil
+		reload(t, func(cfg *config.Config) { cfg.TokenSymbol = "usdc" })
+
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address.Hex()}, nil)
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Len(t, fake.transfers, 1)
+	})
+
+	t.Run("insufficient balance", func(t *testing.T) {
+		reload(t, func(cfg *config.Config) {
+			cfg.TokenSymbol = "eth"
+			cfg.StandardTipAmountDecimal = decimal.NewFromInt(100)
+		})
+
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address.Hex()}, nil)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeInsufficientFaucetBalance, response.Code)
+	})
+
+	t.Run("balance below minimum transfer count", func(t *testing.T) {
+		reload(t, func(cfg *config.Config) {
+			cfg.TokenSymbol = "eth"
+			cfg.StandardTipAmountDecimal = decimal.NewFromInt(4)
+			cfg.MinTransferCount = 3
+		})
+
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address.Hex()}, nil)
+		require.Equal(t, http.StatusServiceUnavailable, w.Code, w.Body.String())
+
+		var response ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, CodeInsufficientFaucetBalance, response.Code)
+
+		balance, err := client.BalanceAt(context.Background(), address, nil)
+		require.NoError(t, err)
+		assert.Equal(t, "500000000000000000", balance.String())
+	})
+}
+
+func TestOnchainReceiptTimeout(t *testing.T) {
+	sender, _ := newOnchainSender(t, false)
+	server := newFakeBackedServer(t, &fakeClearnode{}, func(cfg *config.Config) {
+		cfg.TokenSymbol = "eth"
+		cfg.StandardTipAmountDecimal = decimal.NewFromInt(1)
+	}, WithOnchainSender(sender))
+
+	w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}, nil)
+	require.Equal(t, http.StatusGatewayTimeout, w.Code, w.Body.String())
+
+	var response ErrorResponse
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, CodeTransferTimeout, response.Code)
+	assert.False(t, response.Retryable)
+}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec documents the versioned API. It is maintained by hand; the
+// tests validate real handler responses against it.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+func serveOpenAPI(c *gin.Context) {
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPISpec)
+}
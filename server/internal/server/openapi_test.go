@@ -0,0 +1,202 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/challenge"
+	"faucet-server/internal/config"
+)
+
+func loadOpenAPIRouter(t *testing.T) routers.Router {
+	t.Helper()
+
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	router, err := legacy.NewRouter(doc)
+	require.NoError(t, err)
+	return router
+}
+
+// validateResponse checks that the recorded response for method and path is
+// documented in the OpenAPI spec.
+func validateResponse(t *testing.T, router routers.Router, method, path string, w *httptest.ResponseRecorder) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, "http://localhost"+path, nil)
+	route, pathParams, err := router.FindRoute(req)
+	require.NoError(t, err, "%s %s is not documented", method, path)
+
+	input := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: &openapi3filter.RequestValidationInput{
+			Request:    req,
+			PathParams: pathParams,
+			Route:      route,
+		},
+		Status: w.Code,
+		Header: w.Header(),
+		Body:   io.NopCloser(bytes.NewReader(w.Body.Bytes())),
+		Options: &openapi3filter.Options{
+			IncludeResponseStatus: true,
+		},
+	}
+	require.NoError(t, openapi3filter.ValidateResponse(context.Background(), input), "%s %s returned %d: %s", method, path, w.Code, w.Body.String())
+}
+
+func TestOpenAPISpec(t *testing.T) {
+	router := loadOpenAPIRouter(t)
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		enableActivityFeed(cfg)
+		enableProofOfWork(cfg)
+		cfg.AdminToken = testAdminToken
+		cfg.HourlyBudgetDecimal = map[string]decimal.Decimal{"usdc": decimal.NewFromInt(10)}
+	})
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+
+	check := func(t *testing.T, method, path string, body interface{}, headers map[string]string, expectedStatus int) *httptest.ResponseRecorder {
+		t.Helper()
+		w := doJSON(t, server, method, APIVersionPrefix+path, body, headers)
+		require.Equal(t, expectedStatus, w.Code, w.Body.String())
+		validateResponse(t, router, method, APIVersionPrefix+path, w)
+		return w
+	}
+
+	t.Run("serves the spec", func(t *testing.T) {
+		w := check(t, "GET", "/openapi.json", nil, nil, http.StatusOK)
+		assert.JSONEq(t, string(openAPISpec), w.Body.String())
+	})
+
+	t.Run("info", func(t *testing.T) {
+		check(t, "GET", "/info", nil, nil, http.StatusOK)
+		cfg := server.Config()
+		require.NoError(t, server.ensureOperational(context.Background(), cfg, cfg.TokenSymbol, cfg.StandardTipAmountDecimal))
+		check(t, "GET", "/info", nil, nil, http.StatusOK)
+	})
+
+	solved := func(t *testing.T) FaucetRequest {
+		t.Helper()
+		w := check(t, "GET", "/challenge", nil, nil, http.StatusOK)
+
+		var response ChallengeResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		return FaucetRequest{
+			UserAddress: address,
+			Challenge:   response.Challenge,
+			Solution:    challenge.Solve(response.Nonce, address, response.Difficulty),
+		}
+	}
+
+	t.Run("request tokens", func(t *testing.T) {
+		check(t, "POST", "/requestTokens", map[string]string{"address": address}, nil, http.StatusBadRequest)
+		check(t, "POST", "/requestTokens", FaucetRequest{UserAddress: "invalid"}, nil, http.StatusBadRequest)
+		check(t, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil, http.StatusBadRequest)
+		check(t, "POST", "/requestTokens", solved(t), nil, http.StatusOK)
+	})
+
+	t.Run("budget exhausted", func(t *testing.T) {
+		check(t, "POST", "/requestTokens", solved(t), nil, http.StatusTooManyRequests)
+		check(t, "GET", "/info", nil, nil, http.StatusOK)
+	})
+
+	t.Run("recent", func(t *testing.T) {
+		check(t, "GET", "/recent", nil, nil, http.StatusOK)
+	})
+
+	t.Run("admin", func(t *testing.T) {
+		check(t, "GET", "/admin/status", nil, nil, http.StatusUnauthorized)
+		check(t, "GET", "/admin/status", nil, auth, http.StatusOK)
+		check(t, "POST", "/admin/pause", PauseRequest{Message: "maintenance"}, auth, http.StatusOK)
+		check(t, "POST", "/admin/resume", nil, auth, http.StatusOK)
+		check(t, "PUT", "/admin/tip-amount", TipAmountRequest{Amount: "5"}, auth, http.StatusOK)
+		check(t, "PUT", "/admin/denylist/"+address, AddressListRequest{Reason: "abuse"}, auth, http.StatusOK)
+		check(t, "GET", "/admin/denylist", nil, auth, http.StatusOK)
+		check(t, "POST", "/requestTokens", solved(t), nil, http.StatusForbidden)
+		check(t, "GET", "/admin/allowlist", nil, auth, http.StatusOK)
+		check(t, "DELETE", "/admin/denylist/"+address, nil, auth, http.StatusOK)
+		check(t, "DELETE", "/admin/denylist/"+address, nil, auth, http.StatusNotFound)
+	})
+
+	t.Run("api keys", func(t *testing.T) {
+		check(t, "POST", "/admin/api-keys", APIKeyRequest{}, auth, http.StatusBadRequest)
+		w := check(t, "POST", "/admin/api-keys", APIKeyRequest{Name: "partner", DailyQuota: 1, Assets: []string{"eth"}, TipAmount: "0.5"}, auth, http.StatusCreated)
+
+		var created CreateAPIKeyResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &created))
+		keyAuth := map[string]string{"Authorization": "Bearer " + created.Token}
+
+		check(t, "POST", "/requestTokens", FaucetRequest{UserAddress: address, Asset: "usdc"}, keyAuth, http.StatusBadRequest)
+		check(t, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, keyAuth, http.StatusOK)
+		check(t, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, keyAuth, http.StatusTooManyRequests)
+		check(t, "GET", "/info", nil, keyAuth, http.StatusOK)
+		check(t, "GET", "/admin/api-keys", nil, auth, http.StatusOK)
+		check(t, "GET", "/admin/api-keys/"+created.ID, nil, auth, http.StatusOK)
+		check(t, "DELETE", "/admin/api-keys/"+created.ID, nil, auth, http.StatusOK)
+		check(t, "GET", "/admin/api-keys/"+created.ID, nil, auth, http.StatusNotFound)
+		check(t, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, keyAuth, http.StatusUnauthorized)
+	})
+
+	t.Run("airdrops", func(t *testing.T) {
+		check(t, "POST", "/admin/airdrops", []string{"0x1234"}, auth, http.StatusBadRequest)
+		check(t, "POST", "/admin/airdrops?amount=2000000000", []string{address}, auth, http.StatusServiceUnavailable)
+		w := check(t, "POST", "/admin/airdrops", []interface{}{address, AirdropRowRequest{Address: address, Amount: "2"}}, auth, http.StatusAccepted)
+
+		var airdrop AirdropResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &airdrop))
+
+		waitForAirdrop(t, server, airdrop.ID)
+
+		check(t, "GET", "/admin/airdrops", nil, auth, http.StatusOK)
+		check(t, "GET", "/admin/airdrops/"+airdrop.ID, nil, auth, http.StatusOK)
+		check(t, "POST", "/admin/airdrops/"+airdrop.ID+"/resume", nil, auth, http.StatusOK)
+		check(t, "POST", "/admin/airdrops/missing/resume", nil, auth, http.StatusNotFound)
+	})
+
+	t.Run("claim codes", func(t *testing.T) {
+		check(t, "POST", "/admin/claim-codes", ClaimBatchRequest{Count: 1}, auth, http.StatusBadRequest)
+		w := check(t, "POST", "/admin/claim-codes", ClaimBatchRequest{Name: "workshop", Count: 2, Amount: "1", ExpiresAt: time.Now().Add(time.Hour)}, auth, http.StatusCreated)
+
+		var batch CreateClaimBatchResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &batch))
+
+		check(t, "POST", "/claim", ClaimRequest{Code: batch.ClaimCodes[0], UserAddress: address}, nil, http.StatusOK)
+		check(t, "POST", "/claim", ClaimRequest{Code: batch.ClaimCodes[0], UserAddress: address}, nil, http.StatusConflict)
+		check(t, "POST", "/claim", ClaimRequest{Code: "unknown", UserAddress: address}, nil, http.StatusNotFound)
+		check(t, "GET", "/admin/claim-codes", nil, auth, http.StatusOK)
+		check(t, "DELETE", "/admin/claim-codes/"+batch.ID, nil, auth, http.StatusOK)
+	})
+}
+
+func TestUnversionedAliases(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+	})
+	auth := map[string]string{"Authorization": "Bearer " + testAdminToken}
+
+	for _, path := range []string{"/info", "/openapi.json"} {
+		assert.Equal(t, http.StatusOK, doJSON(t, server, "GET", path, nil, nil).Code, path)
+		assert.Equal(t, http.StatusOK, doJSON(t, server, "GET", APIVersionPrefix+path, nil, nil).Code, path)
+	}
+	assert.Equal(t, http.StatusOK, doJSON(t, server, "GET", "/admin/status", nil, auth).Code)
+	assert.Equal(t, http.StatusOK, doJSON(t, server, "GET", APIVersionPrefix+"/admin/status", nil, auth).Code)
+
+	request := FaucetRequest{UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"}
+	assert.Equal(t, http.StatusOK, doJSON(t, server, "POST", APIVersionPrefix+"/requestTokens", request, nil).Code)
+	assert.Equal(t, http.StatusOK, doJSON(t, server, "POST", "/requestTokens", request, nil).Code)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/logger"
+)
+
+// OwnershipMessage is the text a wallet signs with personal_sign (EIP-191) to
+// prove control of address for the given challenge nonce. Wallets supporting
+// eth_signTypedData_v4 may sign clearnode.FaucetClaimTypedData instead.
+func OwnershipMessage(address common.Address, nonce string) string {
+	return fmt.Sprintf("Nitrolite Faucet token request\nAddress: %s\nNonce: %s", address.Hex(), nonce)
+}
+
+// verifyOwnership checks that the request carries a signature by address over
+// a challenge nonce and writes the error response if it does not. The
+// challenge is spent, so each signature is accepted once.
+func (s *Server) verifyOwnership(c *gin.Context, req FaucetRequest, address common.Address) bool {
+	token := strings.TrimSpace(req.Challenge)
+	signatureHex := strings.TrimSpace(req.Signature)
+	if token == "" || signatureHex == "" {
+		logger.Warnf("Missing ownership signature for %s", address.Hex())
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeSignatureRequired, ErrSignatureRequired))
+		return false
+	}
+
+	issued, err := s.challengeIssuer.Verify(token)
+	if err != nil {
+		logger.Warnf("Ownership challenge rejected for %s: %v", address.Hex(), err)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidChallenge, ErrInvalidChallenge))
+		return false
+	}
+
+	signature, err := hexutil.Decode(signatureHex)
+	if err != nil || !signedBy(address, issued.Nonce, signature) {
+		logger.Warnf("Ownership signature rejected for %s", address.Hex())
+		c.JSON(http.StatusForbidden, newErrorResponse(CodeInvalidSignature, ErrInvalidSignature))
+		return false
+	}
+
+	return s.spendChallenge(c, issued, address.Hex())
+}
+
+// signedBy reports whether signature is an EIP-712 or EIP-191 signature by
+// address over nonce.
+func signedBy(address common.Address, nonce string, signature []byte) bool {
+	signer, err := clearnode.RecoverTypedDataSigner(clearnode.FaucetClaimTypedData(address, nonce), signature)
+	if err == nil && signer == address {
+		return true
+	}
+
+	signer, err = clearnode.RecoverSigner(accounts.TextHash([]byte(OwnershipMessage(address, nonce))), signature)
+	return err == nil && signer == address
+}
@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/accounts"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/challenge"
+	"faucet-server/internal/clearnode"
+	"faucet-server/internal/config"
+)
+
+func requireSignature(cfg *config.Config) {
+	cfg.RequireSignature = true
+	cfg.ChallengeSecret = "challenge-secret"
+	cfg.ChallengeTTL = time.Minute
+}
+
+func TestOwnershipSignature(t *testing.T) {
+	server, mockClearnode := newTestServer(t, requireSignature)
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	otherKey, err := crypto.GenerateKey()
+	require.NoError(t, err)
+
+	signEIP712 := func(t *testing.T, nonce string) string {
+		signature, err := clearnode.NewEIP712Signer(key).SignTypedData(clearnode.FaucetClaimTypedData(address, nonce))
+		require.NoError(t, err)
+		return hexutil.Encode(signature)
+	}
+
+	assertError := func(t *testing.T, req FaucetRequest, status int, message string) {
+		t.Helper()
+		w := doJSON(t, server, "POST", "/requestTokens", req, nil)
+		assert.Equal(t, status, w.Code)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &errorResponse))
+		assert.Equal(t, message, errorResponse.Error)
+	}
+
+	t.Run("challenge has no proof-of-work difficulty", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		assert.NotEmpty(t, response.Nonce)
+		assert.Zero(t, response.Difficulty)
+	})
+
+	t.Run("missing signature", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		assertError(t, FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+		}, http.StatusBadRequest, ErrSignatureRequired)
+	})
+
+	t.Run("forged challenge", func(t *testing.T) {
+		assertError(t, FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   "forged.challenge",
+			Signature:   signEIP712(t, "forged"),
+		}, http.StatusBadRequest, ErrInvalidChallenge)
+	})
+
+	t.Run("signature by another key", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		signature, err := crypto.Sign(accounts.TextHash([]byte(OwnershipMessage(address, response.Nonce))), otherKey)
+		require.NoError(t, err)
+
+		assertError(t, FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+			Signature:   hexutil.Encode(signature),
+		}, http.StatusForbidden, ErrInvalidSignature)
+	})
+
+	t.Run("signature over another nonce", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		assertError(t, FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+			Signature:   signEIP712(t, "another-nonce"),
+		}, http.StatusForbidden, ErrInvalidSignature)
+	})
+
+	t.Run("malformed signature", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		assertError(t, FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+			Signature:   "0xzz",
+		}, http.StatusForbidden, ErrInvalidSignature)
+	})
+
+	t.Run("valid EIP-712 signature", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+			Signature:   signEIP712(t, response.Nonce),
+		}, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+		require.NotNil(t, mockClearnode.LastTransfer())
+		assert.Equal(t, address.Hex(), mockClearnode.LastTransfer().ToAccount)
+	})
+
+	t.Run("valid EIP-191 signature", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		signature, err := crypto.Sign(accounts.TextHash([]byte(OwnershipMessage(address, response.Nonce))), key)
+		require.NoError(t, err)
+		// Wallets return recovery IDs of 27/28 for personal_sign
+		signature[64] += 27
+
+		w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+			Signature:   hexutil.Encode(signature),
+		}, nil)
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+
+	t.Run("signature can't be replayed", func(t *testing.T) {
+		response := fetchChallenge(t, server)
+		request := FaucetRequest{
+			UserAddress: address.Hex(),
+			Challenge:   response.Challenge,
+			Signature:   signEIP712(t, response.Nonce),
+		}
+
+		w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+		require.Equal(t, http.StatusOK, w.Code)
+		transfers := len(mockClearnode.Transfers())
+
+		assertError(t, request, http.StatusBadRequest, ErrChallengeUsed)
+		assert.Len(t, mockClearnode.Transfers(), transfers)
+	})
+}
+
+func TestOwnershipSignatureWithProofOfWork(t *testing.T) {
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		enableProofOfWork(cfg)
+		requireSignature(cfg)
+	})
+
+	key, err := crypto.GenerateKey()
+	require.NoError(t, err)
+	address := crypto.PubkeyToAddress(key.PublicKey)
+
+	// One challenge carries both the solution and the signature
+	response := fetchChallenge(t, server)
+	signature, err := clearnode.NewEIP712Signer(key).SignTypedData(clearnode.FaucetClaimTypedData(address, response.Nonce))
+	require.NoError(t, err)
+	request := FaucetRequest{
+		UserAddress: address.Hex(),
+		Challenge:   response.Challenge,
+		Solution:    challenge.Solve(response.Nonce, address.Hex(), response.Difficulty),
+		Signature:   hexutil.Encode(signature),
+	}
+
+	w := doJSON(t, server, "POST", "/requestTokens", request, nil)
+	assert.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	w = doJSON(t, server, "POST", "/requestTokens", request, nil)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
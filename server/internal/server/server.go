@@ -1,16 +1,27 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/gin-gonic/gin"
+	"github.com/shopspring/decimal"
 
-	"faucet-server/internal/clearnode"
+	"faucet-server/internal/activity"
+	"faucet-server/internal/alert"
+	"faucet-server/internal/captcha"
+	"faucet-server/internal/challenge"
 	"faucet-server/internal/config"
 	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+	"faucet-server/internal/webhook"
 )
 
 // Error message constants
@@ -20,17 +31,108 @@ const (
 	ErrClearnodeConnectionFailed = "Failed to connect to Clearnode."
 	ErrServiceUnavailable        = "Faucet service is currently unavailable."
 	ErrTransferFailed            = "Failed to send tokens."
+	ErrTransferTimeout           = "Timed out waiting for the transfer to complete. It may still succeed; check your balance before retrying."
+	ErrInsufficientFaucetBalance = "The faucet does not have enough funds to send tokens."
+	ErrAddressDenied             = "This address is not allowed to request tokens."
+	ErrAddressNotAllowlisted     = "This address is not on the faucet allowlist."
+	ErrCaptchaRequired           = "CAPTCHA verification is required."
+	ErrCaptchaFailed             = "CAPTCHA verification failed."
+	ErrCaptchaUnavailable        = "CAPTCHA verification is currently unavailable."
+	ErrProofOfWorkRequired       = "A proof-of-work solution is required. Request a challenge from GET /challenge."
+	ErrInvalidChallenge          = "Invalid or expired challenge."
+	ErrChallengeUsed             = "Challenge has already been used. Request a new one from GET /challenge."
+	ErrInvalidSolution           = "Proof-of-work solution is invalid."
+	ErrTooManyChallenges         = "Too many challenges redeemed from your network. Please try again later."
+	ErrSignatureRequired         = "A signature proving control of the address is required. Request a nonce from GET /challenge."
+	ErrInvalidSignature          = "Signature does not match the requested address."
+	ErrBudgetExhausted           = "%s budget exhausted, resets at %s."
+	ErrFaucetPaused              = "Faucet is paused for maintenance. Please try again later."
+	ErrUnauthorized              = "Unauthorized."
+	ErrInvalidAdminRequest       = "Invalid admin request format."
+	ErrAddressNotListed          = "Address is not on the list."
+	ErrOriginNotAllowed          = "Requests from this origin are not allowed."
+	ErrClientCertRequired        = "A trusted client certificate is required."
+	ErrInvalidAPIKey             = "Invalid API key."
+	ErrIPNotAllowed              = "This API key cannot be used from your IP address."
+	ErrAssetNotAllowed           = "The requested asset is not available."
+	ErrQuotaExceeded             = "Daily API key quota exhausted, resets at %s."
+	ErrAPIKeyNotFound            = "API key not found."
+	ErrAirdropEmpty              = "Airdrop contains no addresses."
+	ErrAirdropTooLarge           = "Airdrop exceeds the limit of %d addresses."
+	ErrAirdropRowsInvalid        = "Airdrop contains invalid rows."
+	ErrAirdropExceedsBalance     = "Faucet balance of %s %s does not cover the airdrop total of %s."
+	ErrAirdropNotFound           = "Airdrop not found."
+	ErrAirdropInProgress         = "Airdrop is already running."
+	ErrInvalidClaimFormat        = "Invalid request format. Expected JSON with 'code' and 'userAddress' fields."
+	ErrInvalidClaimCode          = "Unknown claim code."
+	ErrClaimCodeExpired          = "This claim code has expired."
+	ErrClaimCodeUsedUp           = "This claim code has already been fully redeemed."
+	ErrClaimCodeAlreadyRedeemed  = "This claim code was already redeemed for this address."
+	ErrClaimBatchNotFound        = "Claim code batch not found."
+	ErrNoConnection              = "The faucet backend has no connection to manage."
 	MsgTokensSentSuccessfully    = "Tokens sent successfully"
 )
 
 type Server struct {
-	config          *config.Config
-	clearnodeClient *clearnode.Client
-	router          *gin.Engine
+	config atomic.Pointer[config.Config]
+	// Serialises configuration changes from SIGHUP and the admin API
+	reloadMu sync.Mutex
+	// Set while the tip amount comes from the admin API; guarded by reloadMu
+	tipAmountOverridden bool
+
+	backend Backend
+	store   *store.Store
+	router  *gin.Engine
+
+	// Last balance fetched per asset
+	balances balanceCache
+
+	// Backends of the assets not dispensed from backend, keyed by lowercase
+	// asset symbol
+	assetBackends map[string]Backend
+
+	// Nil when CAPTCHA verification is disabled
+	captchaVerifier captcha.Verifier
+
+	// Nil when neither proof of work nor signed requests are enabled
+	challengeIssuer *challenge.Issuer
+	requestRate     *challenge.RateTracker
+
+	// Nil when no alert webhooks are configured
+	balanceMonitor *alert.Monitor
+
+	// Nil when no event webhooks are configured
+	eventWebhooks *webhook.Dispatcher
+
+	// Nil when the activity feed is disabled
+	activity *activity.Feed
+
+	// Set while dispensing is paused via the admin API
+	pause atomic.Pointer[pauseState]
+
+	// IDs of the airdrops being run, so a resume can't send a row twice
+	airdropsMu      sync.Mutex
+	runningAirdrops map[string]struct{}
+
+	// Cancelled once the server shuts down, which stops airdrops running in
+	// the background and ends event streams
+	shutdownCtx context.Context
+	shutdown    context.CancelFunc
+
+	// Transfers and airdrop runs in flight, which Drain waits for
+	inFlightMu sync.Mutex
+	draining   bool
+	inFlight   sync.WaitGroup
 }
 
 type FaucetRequest struct {
-	UserAddress string `json:"userAddress" binding:"required"`
+	UserAddress  string `json:"userAddress" binding:"required"`
+	CaptchaToken string `json:"captchaToken,omitempty"`
+	Challenge    string `json:"challenge,omitempty"`
+	Solution     string `json:"solution,omitempty"`
+	Signature    string `json:"signature,omitempty"`
+	// Only partners with an API key may choose among several assets
+	Asset string `json:"asset,omitempty"`
 }
 
 type FaucetResponse struct {
@@ -43,10 +145,16 @@ type FaucetResponse struct {
 }
 
 type ErrorResponse struct {
-	Error string `json:"error"`
+	Error     string    `json:"error"`
+	Code      ErrorCode `json:"code"`
+	Retryable bool      `json:"retryable"`
+	// Seconds until the request may succeed, when known
+	RetryAfter int `json:"retryAfter,omitempty"`
 }
 
-func NewServer(cfg *config.Config, client *clearnode.Client) *Server {
+// NewServer creates a server dispensing from backend, normally a
+// ClearnodeBackend.
+func NewServer(cfg *config.Config, backend Backend, st *store.Store, opts ...Option) (*Server, error) {
 	if cfg.LogLevel == "debug" {
 		gin.SetMode(gin.DebugMode)
 	} else {
@@ -54,125 +162,386 @@ func NewServer(cfg *config.Config, client *clearnode.Client) *Server {
 	}
 
 	router := gin.New()
+	// gin trusts X-Forwarded-For from anyone by default, which would let
+	// clients choose the IP that API key allowlists and CAPTCHA checks see
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		return nil, fmt.Errorf("invalid trusted proxies: %w", err)
+	}
 
 	// Add middleware
 	router.Use(gin.Recovery())
 	router.Use(requestLogger())
-	router.Use(corsMiddleware())
 
 	server := &Server{
-		config:          cfg,
-		clearnodeClient: client,
+		backend:         backend,
+		store:           st,
 		router:          router,
+		runningAirdrops: make(map[string]struct{}),
+	}
+	server.shutdownCtx, server.shutdown = context.WithCancel(context.Background())
+	for _, opt := range opts {
+		opt(server)
+	}
+	server.config.Store(cfg)
+	router.Use(server.corsMiddleware())
+
+	if cfg.CaptchaSecret != "" {
+		server.captchaVerifier = captcha.NewSiteVerifier(cfg.CaptchaVerifyURL, cfg.CaptchaSecret)
+	}
+	if err := server.setupChallenges(); err != nil {
+		return nil, err
 	}
+	server.setupBalanceMonitor()
+	server.setupEventWebhooks()
+	server.setupActivityFeed()
 
 	server.setupRoutes()
-	return server
+	return server, nil
 }
 
+// Config returns the configuration currently in effect.
+func (s *Server) Config() *config.Config {
+	return s.config.Load()
+}
+
+// Reload atomically replaces the running configuration with cfg.
+// Only tunables may change; see config.Config.CheckReloadable. A tip amount
+// set via the admin API is replaced by the one in cfg.
+func (s *Server) Reload(cfg *config.Config) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	current := s.Config()
+	if err := s.reload(cfg); err != nil {
+		return err
+	}
+
+	if s.tipAmountOverridden {
+		s.tipAmountOverridden = false
+		if !cfg.StandardTipAmountDecimal.Equal(current.StandardTipAmountDecimal) {
+			logger.Warnf("Tip amount %s set via the admin API replaced by the configured %s",
+				current.StandardTipAmountDecimal.String(), cfg.StandardTipAmountDecimal.String())
+		}
+	}
+	return nil
+}
+
+// reload applies cfg. The caller must hold s.reloadMu.
+func (s *Server) reload(cfg *config.Config) error {
+	if err := s.Config().CheckReloadable(cfg); err != nil {
+		return err
+	}
+
+	if err := logger.SetLevel(cfg.LogLevel); err != nil {
+		return fmt.Errorf("failed to apply log level: %w", err)
+	}
+
+	s.config.Store(cfg)
+
+	logger.Infof("Configuration reloaded: token=%s, tip amount=%s, min transfer count=%d",
+		cfg.TokenSymbol, cfg.StandardTipAmountDecimal.String(), cfg.MinTransferCount)
+	return nil
+}
+
+// APIVersionPrefix is the path prefix of the current API version. The same
+// routes are also served without it for existing clients.
+const APIVersionPrefix = "/v1"
+
 func (s *Server) setupRoutes() {
-	s.router.POST("/requestTokens", s.requestTokens)
-	s.router.GET("/info", s.getInfo)
-}
-
-func (s *Server) getInfo(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"service":             "Nitrolite Faucet Server",
-		"version":             "1.0.0",
-		"faucet_address":      s.clearnodeClient.GetSessionKeyAddress(),
-		"standard_tip_amount": s.config.StandardTipAmountDecimal.String(),
-		"token_symbol":        s.config.TokenSymbol,
-		"endpoints":           []string{"/requestTokens"},
-	})
+	s.registerRoutes(s.router.Group(APIVersionPrefix))
+	s.registerRoutes(&s.router.RouterGroup)
+}
+
+func (s *Server) registerRoutes(routes *gin.RouterGroup) {
+	routes.POST("/requestTokens", s.requestTokens)
+	routes.POST("/claim", s.redeemClaimCode)
+	routes.GET("/info", s.getInfo)
+	routes.GET("/openapi.json", serveOpenAPI)
+
+	if s.challengeIssuer != nil {
+		routes.GET("/challenge", s.getChallenge)
+	}
+
+	if s.activity != nil {
+		routes.GET("/recent", s.getRecent)
+		routes.GET("/events", s.streamEvents)
+	}
+
+	if s.Config().AdminToken != "" {
+		s.registerAdminRoutes(routes)
+	}
 }
 
 func (s *Server) requestTokens(c *gin.Context) {
+	if pause := s.pause.Load(); pause != nil {
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeFaucetPaused, pause.Message))
+		return
+	}
+
+	apiKey, ok := s.authenticateAPIKey(c)
+	if !ok {
+		return
+	}
+
 	var req FaucetRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Warnf("Invalid request format: %v", err)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: ErrInvalidRequestFormat,
-		})
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidRequest, ErrInvalidRequestFormat))
 		return
 	}
 
-	// Validate the user address
-	userAddress := strings.TrimSpace(req.UserAddress)
-	if !common.IsHexAddress(userAddress) {
-		logger.Warnf("Invalid address format: %s", userAddress)
-		c.JSON(http.StatusBadRequest, ErrorResponse{
-			Error: ErrInvalidAddressFormat,
-		})
+	// Use a single snapshot so a concurrent reload can't mix settings
+	cfg := s.Config()
+
+	address, ok := s.checkAddress(c, cfg, req.UserAddress)
+	if !ok {
 		return
 	}
+	userAddress := address.Hex()
 
-	userAddress = common.HexToAddress(userAddress).Hex()
-
-	logger.Infof("Processing faucet request for address: %s", userAddress)
+	asset, amount, ok := dispenseParams(cfg, apiKey, req.Asset)
+	if !ok {
+		logger.Warnf("Rejected request for unavailable asset %q: %s", req.Asset, userAddress)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeAssetNotAllowed, ErrAssetNotAllowed))
+		return
+	}
 
-	// Ensure client is connected
-	if err := s.clearnodeClient.EnsureConnected(); err != nil {
-		logger.Errorf("Connection failed for %s: %v", userAddress, err)
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error: ErrClearnodeConnectionFailed,
-		})
+	// Partners are trusted not to be bots
+	if apiKey == nil && !s.verifyHuman(c, req, userAddress) {
 		return
 	}
 
-	// Ensure client is operational
-	if err := s.clearnodeClient.EnsureOperational(); err != nil {
-		logger.Errorf("Service not operational for %s: %v", userAddress, err)
-		c.JSON(http.StatusServiceUnavailable, ErrorResponse{
-			Error: ErrServiceUnavailable,
-		})
+	if cfg.RequireSignature && !s.verifyOwnership(c, req, address) {
 		return
 	}
 
-	// Perform the transfer
-	result, err := s.clearnodeClient.Transfer(
-		userAddress,
-		s.config.TokenSymbol,
-		s.config.StandardTipAmountDecimal,
-	)
-	if err != nil {
-		logger.Errorf("Transfer failed for %s: %v", userAddress, err)
-		c.JSON(http.StatusInternalServerError, ErrorResponse{
-			Error: ErrTransferFailed,
-		})
+	keyReservedAt, ok := s.reserveAPIKeyRequest(c, apiKey)
+	if !ok {
 		return
 	}
 
-	// Extract transaction info from the response
-	var txID string
-	var amount string
-	var asset string
-	if len(result.Transactions) > 0 {
-		tx := result.Transactions[0]
-		txID = fmt.Sprintf("%d", tx.Id)
-		amount = tx.Amount.String()
-		asset = tx.Asset
-	} else {
-		amount = s.config.StandardTipAmountDecimal.String()
-		asset = s.config.TokenSymbol
+	s.dispense(c, cfg, dispenseRequest{
+		address:  userAddress,
+		asset:    asset,
+		amount:   amount,
+		budgeted: true,
+		release:  func() { s.releaseAPIKeyRequest(apiKey, keyReservedAt) },
+		record:   func() { s.recordAPIKeyTransfer(apiKey, asset, amount) },
+	})
+}
+
+// checkAddress validates the destination of a request against its format
+// and the address lists, writing the error response if it is rejected.
+func (s *Server) checkAddress(c *gin.Context, cfg *config.Config, rawAddress string) (common.Address, bool) {
+	userAddress := strings.TrimSpace(rawAddress)
+	if !common.IsHexAddress(userAddress) {
+		logger.Warnf("Invalid address format: %s", userAddress)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeInvalidAddress, ErrInvalidAddressFormat))
+		return common.Address{}, false
 	}
 
-	logger.Infof("Successfully sent %s %s to %s (txID: %s)",
-		amount, asset, userAddress, txID)
+	address := common.HexToAddress(userAddress)
+
+	if s.store.HasAddress(store.Denylist, address) {
+		logger.Warnf("Rejected request for denylisted address: %s", address.Hex())
+		c.JSON(http.StatusForbidden, newErrorResponse(CodeAddressDenied, ErrAddressDenied))
+		return common.Address{}, false
+	}
+
+	if cfg.AllowlistOnly && !s.store.HasAddress(store.Allowlist, address) {
+		logger.Warnf("Rejected request for address not on allowlist: %s", address.Hex())
+		c.JSON(http.StatusForbidden, newErrorResponse(CodeAddressNotAllowlisted, ErrAddressNotAllowlisted))
+		return common.Address{}, false
+	}
+
+	return address, true
+}
+
+// dispenseRequest is a transfer that passed the checks of its endpoint.
+type dispenseRequest struct {
+	address string
+	asset   string
+	amount  decimal.Decimal
+	// Whether the transfer counts against the dispensing budgets
+	budgeted bool
+	// Optional; undoes the caller's reservations if nothing was sent
+	release func()
+	// Optional; called after a successful transfer
+	record func()
+}
+
+// dispense sends a transfer and writes the response. It is the transfer
+// path shared by every endpoint that hands out tokens.
+func (s *Server) dispense(c *gin.Context, cfg *config.Config, req dispenseRequest) {
+	txID, err := s.sendTransfer(c.Request.Context(), cfg, req)
+	if err != nil {
+		writeSendError(c, err)
+		return
+	}
 
 	c.JSON(http.StatusOK, FaucetResponse{
 		Success:     true,
 		Message:     MsgTokensSentSuccessfully,
 		TxID:        txID,
-		Amount:      amount,
-		Asset:       asset,
-		Destination: userAddress,
+		Amount:      req.amount.String(),
+		Asset:       req.asset,
+		Destination: req.address,
 	})
 }
 
-func (s *Server) Start() error {
-	addr := ":" + s.config.ServerPort
-	logger.Infof("Starting HTTP server on port %s", s.config.ServerPort)
-	return s.router.Run(addr)
+// sendTransfer checks that the backend is operational, reserves the budget
+// and sends the transfer, so that every way of handing out tokens, airdrops
+// included, emits the same events and appears in the activity feed. Errors
+// from before the transfer are a *notSentError; anything else is an error
+// of the transfer itself.
+func (s *Server) sendTransfer(ctx context.Context, cfg *config.Config, req dispenseRequest) (string, error) {
+	release := func() {
+		if req.release != nil {
+			req.release()
+		}
+	}
+
+	if !s.beginWork() {
+		release()
+		return "", &notSentError{errShuttingDown}
+	}
+	defer s.inFlight.Done()
+
+	logger.Infof("Processing faucet request for address: %s", req.address)
+
+	if err := s.ensureOperational(ctx, cfg, req.asset, req.amount); err != nil {
+		logger.Errorf("Service not operational for %s: %v", req.address, err)
+		release()
+		return "", &notSentError{err}
+	}
+
+	var reservedAt time.Time
+	if req.budgeted {
+		var err error
+		reservedAt, err = s.reserveBudget(cfg, req.asset, req.amount, req.address)
+		if err != nil {
+			release()
+			return "", &notSentError{err}
+		}
+	}
+
+	// Only requests that passed every check are reported as accepted
+	s.emitEvent(webhook.EventRequestAccepted, TransferEvent{
+		Address: req.address,
+		Asset:   req.asset,
+		Amount:  req.amount.String(),
+	})
+
+	// Perform the transfer
+	txID, err := s.backendFor(req.asset).Transfer(ctx, req.address, req.asset, req.amount)
+	if err != nil {
+		logger.Errorf("Transfer failed for %s: %v", req.address, err)
+		// A timed-out transfer may still go through, so it keeps counting
+		// against the budget
+		if !transferTimedOut(err) {
+			if req.budgeted {
+				s.releaseBudget(req.asset, req.amount, reservedAt)
+			}
+			release()
+		}
+		s.emitEvent(webhook.EventTransferFailed, TransferEvent{
+			Address: req.address,
+			Asset:   req.asset,
+			Amount:  req.amount.String(),
+			Error:   err.Error(),
+		})
+		return "", err
+	}
+
+	if req.record != nil {
+		req.record()
+	}
+
+	logger.Infof("Successfully sent %s %s to %s (txID: %s)",
+		req.amount, req.asset, req.address, txID)
+	s.emitEvent(webhook.EventTransferSucceeded, TransferEvent{
+		Address: req.address,
+		Asset:   req.asset,
+		Amount:  req.amount.String(),
+		TxID:    txID,
+	})
+	s.publishActivity(req.address, req.amount.String(), req.asset, txID)
+
+	return txID, nil
+}
+
+// beginWork registers a transfer or airdrop run that Drain must wait for.
+// It returns false once the server is draining; the caller must then not
+// start the work. Otherwise the caller calls s.inFlight.Done when finished.
+func (s *Server) beginWork() bool {
+	s.inFlightMu.Lock()
+	defer s.inFlightMu.Unlock()
+
+	if s.draining {
+		return false
+	}
+	s.inFlight.Add(1)
+	return true
+}
+
+// Drain stops running airdrops, ends event streams and waits until the
+// airdrops and the transfers of open requests have finished and been
+// recorded. Transfers may outlive the HTTP shutdown timeout, e.g. while
+// waiting for an on-chain receipt, so Drain must return before the store is
+// flushed. Airdrop rows not yet started stay pending for a resume after
+// restart.
+func (s *Server) Drain() {
+	s.inFlightMu.Lock()
+	s.draining = true
+	s.inFlightMu.Unlock()
+
+	s.shutdown()
+	s.inFlight.Wait()
+}
+
+// verifyHuman applies the enabled bot deterrents. When both CAPTCHA and proof
+// of work are enabled either one is sufficient, so headless clients that can't
+// solve a CAPTCHA can fall back to proof of work.
+func (s *Server) verifyHuman(c *gin.Context, req FaucetRequest, userAddress string) bool {
+	if s.requestRate != nil {
+		s.requestRate.Record(time.Now())
+	}
+
+	powEnabled := s.requestRate != nil
+
+	switch {
+	case s.captchaVerifier != nil && (req.CaptchaToken != "" || !powEnabled):
+		return s.verifyCaptcha(c, req.CaptchaToken, userAddress)
+	case powEnabled:
+		return s.verifyProofOfWork(c, req, userAddress)
+	default:
+		return true
+	}
+}
+
+// verifyCaptcha checks the request's CAPTCHA token and writes the error
+// response if it is missing or invalid.
+func (s *Server) verifyCaptcha(c *gin.Context, token, userAddress string) bool {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		logger.Warnf("Missing CAPTCHA token for %s", userAddress)
+		c.JSON(http.StatusBadRequest, newErrorResponse(CodeCaptchaRequired, ErrCaptchaRequired))
+		return false
+	}
+
+	if err := s.captchaVerifier.Verify(c.Request.Context(), token, c.ClientIP()); err != nil {
+		if errors.Is(err, captcha.ErrVerificationFailed) {
+			logger.Warnf("CAPTCHA rejected for %s: %v", userAddress, err)
+			c.JSON(http.StatusForbidden, newErrorResponse(CodeCaptchaFailed, ErrCaptchaFailed))
+			return false
+		}
+
+		logger.Errorf("CAPTCHA verification unavailable for %s: %v", userAddress, err)
+		c.JSON(http.StatusServiceUnavailable, newErrorResponse(CodeCaptchaUnavailable, ErrCaptchaUnavailable))
+		return false
+	}
+
+	return true
 }
 
 // Middleware functions
@@ -186,18 +555,3 @@ func requestLogger() gin.HandlerFunc {
 		logger.Debugf("%s %s - %d", c.Request.Method, c.Request.URL.Path, c.Writer.Status())
 	}
 }
-
-func corsMiddleware() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		c.Header("Access-Control-Allow-Origin", "*")
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Origin, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
-			return
-		}
-
-		c.Next()
-	}
-}
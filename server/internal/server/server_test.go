@@ -5,222 +5,70 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"strings"
+	"strconv"
 	"testing"
 	"time"
 
+	"github.com/erc7824/nitrolite/clearnode/pkg/rpc"
 	"github.com/ethereum/go-ethereum/common"
-	"github.com/gorilla/websocket"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"faucet-server/internal/clearnode"
+	"faucet-server/internal/clearnodetest"
 	"faucet-server/internal/config"
 	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+	"faucet-server/internal/version"
 )
 
-// MockClearnodeServer represents a mock Clearnode WebSocket server
-type MockClearnodeServer struct {
-	server          *httptest.Server
-	upgrader        websocket.Upgrader
-	receivedMessage *clearnode.RPCMessage
-	responseData    map[string]interface{}
-	transferRequest *TransferCapture
-}
-
-// TransferCapture captures the transfer request parameters
-type TransferCapture struct {
-	Destination string
-	Asset       string
-	Amount      decimal.Decimal
-	RequestID   uint64
-}
-
-func NewMockClearnodeServer() *MockClearnodeServer {
-	mock := &MockClearnodeServer{
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
-		},
-	}
-
-	mock.server = httptest.NewServer(http.HandlerFunc(mock.handleWebSocket))
-	return mock
-}
-
-func (m *MockClearnodeServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := m.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		return
-	}
-	defer conn.Close()
-
-	for {
-		var message clearnode.RPCMessage
-		err := conn.ReadJSON(&message)
-		if err != nil {
-			break
-		}
-
-		m.receivedMessage = &message
-
-		// Handle different request types
-		if len(message.Req) >= 4 {
-			requestID := message.Req[0]
-			method := message.Req[1].(string)
-			params := message.Req[2].(map[string]interface{})
-			timestamp := message.Req[3]
-
-			switch method {
-			case "auth_request":
-				m.sendAuthChallenge(conn, requestID, timestamp)
-			case "auth_verify":
-				m.sendAuthVerifyResponse(conn, requestID, timestamp)
-			case "get_assets":
-				m.sendAssetsResponse(conn, requestID, timestamp)
-			case "get_ledger_balances":
-				m.sendBalancesResponse(conn, requestID, timestamp)
-			case "transfer":
-				m.handleTransfer(conn, requestID, timestamp, params)
-			}
-		}
-	}
-}
-
-func (m *MockClearnodeServer) sendAuthChallenge(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"auth_challenge",
-			map[string]interface{}{
-				"challenge_message": "test-challenge-123",
-			},
-			timestamp,
-		},
-	}
-	conn.WriteJSON(response)
-}
-
-func (m *MockClearnodeServer) sendAuthVerifyResponse(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"auth_verify",
-			map[string]interface{}{
-				"success":   true,
-				"jwt_token": "mock-jwt-token",
-			},
-			timestamp,
-		},
-	}
-	conn.WriteJSON(response)
-}
+const (
+	testOwnerKey  = "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890"
+	testSignerKey = "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321"
+)
 
-func (m *MockClearnodeServer) sendAssetsResponse(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"get_assets",
-			map[string]interface{}{
-				"assets": []interface{}{
-					map[string]interface{}{
-						"token":    "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
-						"symbol":   "usdc",
-						"decimals": float64(6),
-						"chain_id": float64(1),
-					},
-				},
-			},
-			timestamp,
-		},
-	}
-	conn.WriteJSON(response)
-}
+// initTestLogger initializes the logger once per test binary. Reinitializing
+// it would race with clients of earlier tests still logging.
+func initTestLogger(t *testing.T) {
+	t.Helper()
 
-func (m *MockClearnodeServer) sendBalancesResponse(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"get_ledger_balances",
-			map[string]interface{}{
-				"ledger_balances": []interface{}{
-					map[string]interface{}{
-						"asset":  "usdc",
-						"amount": "1000000000", // 1000 USDC with 6 decimals
-					},
-				},
-			},
-			timestamp,
-		},
+	if logger.Log == nil {
+		require.NoError(t, logger.Initialize("debug"))
 	}
-	conn.WriteJSON(response)
 }
 
-func (m *MockClearnodeServer) handleTransfer(conn *websocket.Conn, requestID, timestamp interface{}, params map[string]interface{}) {
-	// Capture transfer request details
-	destination := params["destination"].(string)
-	allocations := params["allocations"].([]interface{})
-	allocation := allocations[0].(map[string]interface{})
-
-	asset := allocation["asset"].(string)
-	amountStr := allocation["amount"].(string)
-	amount, _ := decimal.NewFromString(amountStr)
-
-	m.transferRequest = &TransferCapture{
-		Destination: destination,
-		Asset:       asset,
-		Amount:      amount,
-		RequestID:   uint64(requestID.(float64)),
-	}
-
-	// Send successful transfer response
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"transfer",
-			map[string]interface{}{
-				"transactions": []interface{}{
-					map[string]interface{}{
-						"id":          float64(12345), // Use number instead of string for ID
-						"asset":       asset,
-						"amount":      amountStr,
-						"to_account":  destination,
-						"from_account": "0x9fc51BEE23Fb53569c46CcF013400f0E19524bd2",
-						"tx_type":     "transfer",
-						"created_at":  time.Now().Format(time.RFC3339),
-					},
-				},
-			},
-			timestamp,
-		},
-	}
-	conn.WriteJSON(response)
-}
+// newFakeClearnode starts a fake Clearnode in which the faucet account of
+// testOwnerKey holds 1000000000 usdc and 1000 eth.
+func newFakeClearnode(t *testing.T) *clearnodetest.Server {
+	t.Helper()
 
-func (m *MockClearnodeServer) GetURL() string {
-	return "ws" + strings.TrimPrefix(m.server.URL, "http")
-}
+	owner, err := crypto.HexToECDSA(testOwnerKey)
+	require.NoError(t, err)
 
-func (m *MockClearnodeServer) Close() {
-	m.server.Close()
+	fake := clearnodetest.NewServer()
+	t.Cleanup(fake.Close)
+	fake.Fund(crypto.PubkeyToAddress(owner.PublicKey), "usdc", decimal.NewFromInt(1000000000))
+	fake.Fund(crypto.PubkeyToAddress(owner.PublicKey), "eth", decimal.NewFromInt(1000))
+	return fake
 }
 
-func (m *MockClearnodeServer) GetTransferRequest() *TransferCapture {
-	return m.transferRequest
+// txID formats the ID of tx like the faucet reports it.
+func txID(tx *rpc.LedgerTransaction) string {
+	return strconv.FormatUint(uint64(tx.Id), 10)
 }
 
 func TestFaucetServerIntegration(t *testing.T) {
-	err := logger.Initialize("debug")
-	require.NoError(t, err)
+	initTestLogger(t)
 
-	mockClearnode := NewMockClearnodeServer()
-	defer mockClearnode.Close()
+	mockClearnode := newFakeClearnode(t)
 
 	cfg := &config.Config{
 		ServerPort:               "0", // Use random port
-		OwnerPrivateKey:          "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
-		SignerPrivateKey:         "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321",
-		ClearnodeURL:             mockClearnode.GetURL(),
+		OwnerPrivateKey:          testOwnerKey,
+		SignerPrivateKey:         testSignerKey,
+		ClearnodeURL:             mockClearnode.URL(),
 		TokenSymbol:              "usdc",
 		StandardTipAmount:        "10", // 10 USDC in decimal format
 		StandardTipAmountDecimal: decimal.RequireFromString("10.0"),
@@ -239,7 +87,8 @@ func TestFaucetServerIntegration(t *testing.T) {
 	err = client.Authenticate()
 	require.NoError(t, err)
 
-	server := NewServer(cfg, client)
+	server, err := NewServer(cfg, NewClearnodeBackend(client), newMemoryStore(t))
+	require.NoError(t, err)
 
 	t.Run("successful token request", func(t *testing.T) {
 		testAddress := common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890").Hex() // this check-sums the address
@@ -261,20 +110,17 @@ func TestFaucetServerIntegration(t *testing.T) {
 		err = json.Unmarshal(w.Body.Bytes(), &response)
 		require.NoError(t, err)
 
+		// Verify the transfer was booked on the Clearnode ledger
+		tx := mockClearnode.AssertTransfer(t, common.HexToAddress(testAddress), "usdc", decimal.RequireFromString("10.0"))
+		mockClearnode.AssertSignedBy(t, clearnodetest.MethodTransfer, client.GetSessionKeyAddress())
+
 		// Verify response structure
 		assert.True(t, response.Success)
 		assert.Equal(t, MsgTokensSentSuccessfully, response.Message)
-		assert.Equal(t, "12345", response.TxID)
+		assert.Equal(t, txID(&tx), response.TxID)
 		assert.Equal(t, "10", response.Amount)
 		assert.Equal(t, "usdc", response.Asset)
 		assert.Equal(t, testAddress, response.Destination)
-
-		// Verify transfer request sent to mock Clearnode
-		transferReq := mockClearnode.GetTransferRequest()
-		require.NotNil(t, transferReq)
-		assert.Equal(t, testAddress, transferReq.Destination)
-		assert.Equal(t, "usdc", transferReq.Asset)
-		assert.True(t, decimal.RequireFromString("10.0").Equal(transferReq.Amount))
 	})
 
 	t.Run("invalid address format", func(t *testing.T) {
@@ -331,7 +177,7 @@ func TestFaucetServerIntegration(t *testing.T) {
 		err := json.Unmarshal(w.Body.Bytes(), &infoResponse)
 		require.NoError(t, err)
 		assert.Equal(t, "Nitrolite Faucet Server", infoResponse["service"])
-		assert.Equal(t, "1.0.0", infoResponse["version"])
+		assert.Equal(t, version.Version, infoResponse["version"])
 		assert.Equal(t, "10", infoResponse["standard_tip_amount"])
 		assert.Equal(t, "usdc", infoResponse["token_symbol"])
 		assert.Contains(t, infoResponse["endpoints"], "/requestTokens")
@@ -353,8 +199,7 @@ func TestFaucetServerIntegration(t *testing.T) {
 		server.router.ServeHTTP(w1, req1)
 		assert.Equal(t, http.StatusOK, w1.Code)
 
-		// Clear the transfer request from first call
-		mockClearnode.transferRequest = nil
+		mockClearnode.AssertTransferCount(t, 2)
 
 		// Simulate abrupt connection termination by closing the WebSocket
 		err = client.Close()
@@ -377,36 +222,33 @@ func TestFaucetServerIntegration(t *testing.T) {
 		err = json.Unmarshal(w2.Body.Bytes(), &response)
 		require.NoError(t, err)
 
+		// Verify the transfer was booked after reconnection
+		mockClearnode.AssertTransferCount(t, 3)
+		tx := mockClearnode.AssertTransfer(t, common.HexToAddress(testAddress), "usdc", decimal.RequireFromString("10.0"))
+		mockClearnode.AssertRequestCount(t, clearnodetest.MethodAuthVerify, 2)
+
 		// Verify response structure
 		assert.True(t, response.Success)
 		assert.Equal(t, MsgTokensSentSuccessfully, response.Message)
-		assert.Equal(t, "12345", response.TxID)
+		assert.Equal(t, txID(&tx), response.TxID)
 		assert.Equal(t, "10", response.Amount)
 		assert.Equal(t, "usdc", response.Asset)
 		assert.Equal(t, testAddress, response.Destination)
 
-		// Verify the transfer request was sent after reconnection
-		transferReq := mockClearnode.GetTransferRequest()
-		require.NotNil(t, transferReq)
-		assert.Equal(t, testAddress, transferReq.Destination)
-		assert.Equal(t, "usdc", transferReq.Asset)
-		assert.True(t, decimal.RequireFromString("10.0").Equal(transferReq.Amount))
-
 		// Verify connection is restored
 		assert.True(t, client.IsConnected())
 	})
 }
 
 func TestServerConnectionAndOperationalErrors(t *testing.T) {
-	err := logger.Initialize("debug")
-	require.NoError(t, err)
+	initTestLogger(t)
 
 	t.Run("connection failure returns connection failed", func(t *testing.T) {
 		// Create client with invalid URL to simulate connection failure
 		cfg := &config.Config{
 			ServerPort:               "0",
-			OwnerPrivateKey:          "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
-			SignerPrivateKey:         "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321",
+			OwnerPrivateKey:          testOwnerKey,
+			SignerPrivateKey:         testSignerKey,
 			ClearnodeURL:             "ws://invalid-url:9999",
 			TokenSymbol:              "usdc",
 			StandardTipAmount:        "10",
@@ -417,7 +259,8 @@ func TestServerConnectionAndOperationalErrors(t *testing.T) {
 		client, err := clearnode.NewClient(cfg.OwnerPrivateKey, cfg.SignerPrivateKey, cfg.ClearnodeURL, cfg.TokenSymbol, cfg.StandardTipAmountDecimal, 1)
 		require.NoError(t, err)
 
-		server := NewServer(cfg, client)
+		server, err := NewServer(cfg, NewClearnodeBackend(client), newMemoryStore(t))
+		require.NoError(t, err)
 
 		testAddress := common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890").Hex()
 		requestBody := FaucetRequest{
@@ -444,14 +287,15 @@ func TestServerConnectionAndOperationalErrors(t *testing.T) {
 		// This test requires a mock server that responds correctly to connection/auth
 		// but provides wrong assets/balance data to trigger EnsureOperational failure
 
-		mockClearnode := NewMockOperationalFailureServer()
-		defer mockClearnode.Close()
+		// Connection and authentication work, but Clearnode lists no assets
+		mockClearnode := newFakeClearnode(t)
+		mockClearnode.SetAssets(nil)
 
 		cfg := &config.Config{
 			ServerPort:               "0",
-			OwnerPrivateKey:          "abcdef1234567890abcdef1234567890abcdef1234567890abcdef1234567890",
-			SignerPrivateKey:         "fedcba0987654321fedcba0987654321fedcba0987654321fedcba0987654321",
-			ClearnodeURL:             mockClearnode.GetURL(),
+			OwnerPrivateKey:          testOwnerKey,
+			SignerPrivateKey:         testSignerKey,
+			ClearnodeURL:             mockClearnode.URL(),
 			TokenSymbol:              "unsupported-token", // This will cause operational failure
 			StandardTipAmount:        "10",
 			StandardTipAmountDecimal: decimal.RequireFromString("10.0"),
@@ -468,7 +312,8 @@ func TestServerConnectionAndOperationalErrors(t *testing.T) {
 		err = client.Authenticate()
 		require.NoError(t, err)
 
-		server := NewServer(cfg, client)
+		server, err := NewServer(cfg, NewClearnodeBackend(client), newMemoryStore(t))
+		require.NoError(t, err)
 
 		testAddress := common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890").Hex()
 		requestBody := FaucetRequest{
@@ -493,117 +338,136 @@ func TestServerConnectionAndOperationalErrors(t *testing.T) {
 	})
 }
 
-// MockOperationalFailureServer simulates a server that allows connection but fails operational checks
-type MockOperationalFailureServer struct {
-	server   *httptest.Server
-	upgrader websocket.Upgrader
-}
+func TestServerReload(t *testing.T) {
+	initTestLogger(t)
+
+	mockClearnode := newFakeClearnode(t)
 
-func NewMockOperationalFailureServer() *MockOperationalFailureServer {
-	mock := &MockOperationalFailureServer{
-		upgrader: websocket.Upgrader{
-			CheckOrigin: func(r *http.Request) bool { return true },
-		},
+	cfg := &config.Config{
+		ServerPort:               "0",
+		OwnerPrivateKey:          testOwnerKey,
+		SignerPrivateKey:         testSignerKey,
+		ClearnodeURL:             mockClearnode.URL(),
+		TokenSymbol:              "usdc",
+		StandardTipAmount:        "10",
+		StandardTipAmountDecimal: decimal.RequireFromString("10.0"),
+		MinTransferCount:         1,
+		AirdropConcurrency:       4,
+		AirdropMaxRows:           1000,
+		LogLevel:                 "debug",
 	}
 
-	mock.server = httptest.NewServer(http.HandlerFunc(mock.handleWebSocket))
-	return mock
-}
+	client, err := clearnode.NewClient(cfg.OwnerPrivateKey, cfg.SignerPrivateKey, cfg.ClearnodeURL, cfg.TokenSymbol, cfg.StandardTipAmountDecimal, cfg.MinTransferCount)
+	require.NoError(t, err)
 
-func (m *MockOperationalFailureServer) handleWebSocket(w http.ResponseWriter, r *http.Request) {
-	conn, err := m.upgrader.Upgrade(w, r, nil)
-	if err != nil {
-		return
-	}
-	defer conn.Close()
+	server, err := NewServer(cfg, NewClearnodeBackend(client), newMemoryStore(t))
+	require.NoError(t, err)
 
-	for {
-		var message clearnode.RPCMessage
-		err := conn.ReadJSON(&message)
-		if err != nil {
-			break
-		}
+	t.Run("applies new tip amount", func(t *testing.T) {
+		newCfg := *cfg
+		newCfg.StandardTipAmount = "2.5"
+		newCfg.StandardTipAmountDecimal = decimal.RequireFromString("2.5")
 
-		if len(message.Req) >= 4 {
-			requestID := message.Req[0]
-			method := message.Req[1].(string)
-			timestamp := message.Req[3]
-
-			switch method {
-			case "auth_request":
-				m.sendAuthChallenge(conn, requestID, timestamp)
-			case "auth_verify":
-				m.sendAuthVerifyResponse(conn, requestID, timestamp)
-			case "get_assets":
-				m.sendEmptyAssetsResponse(conn, requestID, timestamp) // No supported assets
-			case "get_ledger_balances":
-				m.sendEmptyBalancesResponse(conn, requestID, timestamp)
-			}
-		}
-	}
-}
+		require.NoError(t, server.Reload(&newCfg))
+		assert.Equal(t, "2.5", server.Config().StandardTipAmountDecimal.String())
 
-func (m *MockOperationalFailureServer) sendAuthChallenge(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"auth_challenge",
-			map[string]interface{}{
-				"challenge_message": "test-challenge-123",
-			},
-			timestamp,
-		},
-	}
-	conn.WriteJSON(response)
+		jsonBody, err := json.Marshal(FaucetRequest{
+			UserAddress: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890",
+		})
+		require.NoError(t, err)
+
+		req := httptest.NewRequest("POST", "/requestTokens", bytes.NewReader(jsonBody))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		server.router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+
+		mockClearnode.AssertTransfer(t, common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890"), "usdc", decimal.RequireFromString("2.5"))
+	})
+
+	t.Run("rejects Clearnode URL change", func(t *testing.T) {
+		newCfg := *server.Config()
+		newCfg.ClearnodeURL = "ws://other-clearnode:8000/ws"
+		newCfg.StandardTipAmountDecimal = decimal.RequireFromString("99")
+
+		err := server.Reload(&newCfg)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "CLEARNODE_URL")
+		assert.Equal(t, "2.5", server.Config().StandardTipAmountDecimal.String())
+	})
 }
 
-func (m *MockOperationalFailureServer) sendAuthVerifyResponse(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"auth_verify",
-			map[string]interface{}{
-				"success":   true,
-				"jwt_token": "mock-jwt-token",
-			},
-			timestamp,
-		},
+// newTestServer creates a Server backed by a connected and authenticated client
+// talking to a fresh fake Clearnode (see newFakeClearnode). mutate may adjust the config before
+// the server is built.
+func newTestServer(t *testing.T, mutate func(cfg *config.Config)) (*Server, *clearnodetest.Server) {
+	t.Helper()
+
+	initTestLogger(t)
+
+	mockClearnode := newFakeClearnode(t)
+
+	cfg := &config.Config{
+		ServerPort:               "0",
+		OwnerPrivateKey:          testOwnerKey,
+		SignerPrivateKey:         testSignerKey,
+		ClearnodeURL:             mockClearnode.URL(),
+		TokenSymbol:              "usdc",
+		StandardTipAmount:        "10",
+		StandardTipAmountDecimal: decimal.RequireFromString("10.0"),
+		MinTransferCount:         1,
+		AirdropConcurrency:       4,
+		AirdropMaxRows:           1000,
+		LogLevel:                 "debug",
 	}
-	conn.WriteJSON(response)
+	if mutate != nil {
+		mutate(cfg)
+	}
+
+	client, err := clearnode.NewClient(cfg.OwnerPrivateKey, cfg.SignerPrivateKey, cfg.ClearnodeURL, cfg.TokenSymbol, cfg.StandardTipAmountDecimal, cfg.MinTransferCount)
+	require.NoError(t, err)
+	require.NoError(t, client.Connect())
+	require.NoError(t, client.Authenticate())
+	t.Cleanup(func() { client.Close() })
+
+	server, err := NewServer(cfg, NewClearnodeBackend(client), newMemoryStore(t))
+	require.NoError(t, err)
+	t.Cleanup(server.Drain)
+	return server, mockClearnode
 }
 
-func (m *MockOperationalFailureServer) sendEmptyAssetsResponse(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"get_assets",
-			map[string]interface{}{
-				"assets": []interface{}{}, // No assets - will cause operational failure
-			},
-			timestamp,
-		},
+// doJSON performs a request against the server's router and returns the recorder.
+func doJSON(t *testing.T, server *Server, method, path string, body interface{}, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		jsonBody, err := json.Marshal(body)
+		require.NoError(t, err)
+		reader = bytes.NewReader(jsonBody)
+	} else {
+		reader = bytes.NewReader(nil)
 	}
-	conn.WriteJSON(response)
-}
 
-func (m *MockOperationalFailureServer) sendEmptyBalancesResponse(conn *websocket.Conn, requestID, timestamp interface{}) {
-	response := clearnode.RPCMessage{
-		Res: []interface{}{
-			requestID,
-			"get_ledger_balances",
-			map[string]interface{}{
-				"ledger_balances": []interface{}{}, // No balances
-			},
-			timestamp,
-		},
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
 	}
-	conn.WriteJSON(response)
-}
 
-func (m *MockOperationalFailureServer) GetURL() string {
-	return "ws" + strings.TrimPrefix(m.server.URL, "http")
+	w := httptest.NewRecorder()
+	server.router.ServeHTTP(w, req)
+	return w
 }
 
-func (m *MockOperationalFailureServer) Close() {
-	m.server.Close()
+func newMemoryStore(t *testing.T) *store.Store {
+	t.Helper()
+
+	st, err := store.Open("")
+	require.NoError(t, err)
+	return st
 }
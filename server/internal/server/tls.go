@@ -0,0 +1,137 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"faucet-server/internal/certreload"
+	"faucet-server/internal/config"
+	"faucet-server/internal/logger"
+)
+
+// shutdownTimeout bounds how long open requests may finish on shutdown.
+const shutdownTimeout = 5 * time.Second
+
+// Start listens on SERVER_PORT and serves until ctx is done.
+func (s *Server) Start(ctx context.Context) error {
+	cfg := s.Config()
+	listener, err := net.Listen("tcp", ":"+cfg.ServerPort)
+	if err != nil {
+		return err
+	}
+
+	if cfg.TLSEnabled() {
+		logger.Infof("Starting HTTPS server on port %s", cfg.ServerPort)
+	} else {
+		logger.Infof("Starting HTTP server on port %s", cfg.ServerPort)
+	}
+	return s.Serve(ctx, listener)
+}
+
+// Serve serves requests on listener until ctx is done and open requests have
+// finished, terminating TLS when a certificate is configured. The certificate
+// files are watched and replacements picked up without a restart.
+func (s *Server) Serve(ctx context.Context, listener net.Listener) error {
+	cfg := s.Config()
+	httpServer := &http.Server{
+		Handler:           s.router,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	if cfg.TLSEnabled() {
+		tlsConfig, certs, err := newTLSConfig(cfg)
+		if err != nil {
+			listener.Close()
+			return err
+		}
+		go certs.Watch(ctx, cfg.TLSReloadInterval)
+
+		httpServer.TLSConfig = tlsConfig
+		listener = tls.NewListener(listener, tlsConfig)
+	}
+
+	// Shut down gracefully once ctx is done; Serve returns only after open
+	// requests have finished or shutdownTimeout has passed. Event streams
+	// never finish on their own, so they are ended as shutdown begins.
+	httpServer.RegisterOnShutdown(s.shutdown)
+	stopped := make(chan struct{})
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+		select {
+		case <-ctx.Done():
+		case <-stopped:
+			return
+		}
+
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+		defer cancel()
+		if err := httpServer.Shutdown(shutdownCtx); err != nil {
+			httpServer.Close()
+		}
+	}()
+
+	err := httpServer.Serve(listener)
+	close(stopped)
+	<-shutdownDone
+
+	if !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func newTLSConfig(cfg *config.Config) (*tls.Config, *certreload.Reloader, error) {
+	certs, err := certreload.New(cfg.TLSCertFile, cfg.TLSKeyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		GetCertificate: certs.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}
+
+	if cfg.TLSClientCAFile != "" {
+		pem, err := os.ReadFile(cfg.TLSClientCAFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read TLS_CLIENT_CA_FILE: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, nil, fmt.Errorf("TLS_CLIENT_CA_FILE contains no PEM certificates")
+		}
+
+		// Public routes stay reachable without a certificate; the admin
+		// routes check for a verified one (see requireClientCert)
+		tlsConfig.ClientCAs = pool
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return tlsConfig, certs, nil
+}
+
+// requireClientCert only admits requests whose connection presented a client
+// certificate verified against TLS_CLIENT_CA_FILE.
+func requireClientCert() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.TLS == nil || len(c.Request.TLS.VerifiedChains) == 0 {
+			logger.Warnf("Admin request without client certificate: %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, newErrorResponse(CodeUnauthorized, ErrClientCertRequired))
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,236 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+// issueCert creates a certificate for commonName, signed by parent or
+// self-signed when parent is nil.
+func issueCert(t *testing.T, commonName string, parent *testCert, isCA bool) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: commonName},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  isCA,
+	}
+	if isCA {
+		template.KeyUsage = x509.KeyUsageCertSign
+	}
+
+	signerCert, signerKey := template, key
+	if parent != nil {
+		signerCert, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signerCert, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return &testCert{cert: cert, key: key}
+}
+
+func (c *testCert) certPEM() []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.cert.Raw})
+}
+
+func (c *testCert) keyPEM(t *testing.T) []byte {
+	der, err := x509.MarshalECPrivateKey(c.key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	cert, err := tls.X509KeyPair(c.certPEM(), c.keyPEM(t))
+	require.NoError(t, err)
+	return cert
+}
+
+func writeCert(t *testing.T, cert *testCert, certFile, keyFile string, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(certFile, cert.certPEM(), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, cert.keyPEM(t), 0o600))
+	require.NoError(t, os.Chtimes(certFile, modTime, modTime))
+	require.NoError(t, os.Chtimes(keyFile, modTime, modTime))
+}
+
+func TestTLS(t *testing.T) {
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	caFile := filepath.Join(dir, "ca.crt")
+
+	ca := issueCert(t, "Test CA", nil, true)
+	require.NoError(t, os.WriteFile(caFile, ca.certPEM(), 0o600))
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, issueCert(t, "first.faucet.test", ca, false), certFile, keyFile, start)
+
+	server, _ := newTestServer(t, func(cfg *config.Config) {
+		cfg.AdminToken = testAdminToken
+		cfg.TLSCertFile = certFile
+		cfg.TLSKeyFile = keyFile
+		cfg.TLSReloadInterval = 10 * time.Millisecond
+		cfg.TLSClientCAFile = caFile
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	baseURL := "https://" + listener.Addr().String()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener) }()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+	}
+	get := func(t *testing.T, client *http.Client, path string) *http.Response {
+		t.Helper()
+		req, err := http.NewRequest("GET", baseURL+path, nil)
+		require.NoError(t, err)
+		req.Header.Set("Authorization", "Bearer "+testAdminToken)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		t.Cleanup(func() { resp.Body.Close() })
+		return resp
+	}
+
+	t.Run("serves public routes without a client certificate", func(t *testing.T) {
+		resp := get(t, newClient(), "/info")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("requires a client certificate for admin routes", func(t *testing.T) {
+		resp := get(t, newClient(), "/admin/status")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+
+		var errorResponse ErrorResponse
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&errorResponse))
+		assert.Equal(t, ErrClientCertRequired, errorResponse.Error)
+	})
+
+	t.Run("rejects client certificates from other CAs", func(t *testing.T) {
+		other := issueCert(t, "Other CA", nil, true)
+		client := newClient(issueCert(t, "admin", other, false).tlsCertificate(t))
+
+		resp := get(t, client, "/v1/admin/status")
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	t.Run("admits trusted client certificates", func(t *testing.T) {
+		client := newClient(issueCert(t, "admin", ca, false).tlsCertificate(t))
+		resp := get(t, client, "/v1/admin/status")
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("picks up a replaced certificate", func(t *testing.T) {
+		writeCert(t, issueCert(t, "second.faucet.test", ca, false), certFile, keyFile, start.Add(time.Minute))
+
+		require.Eventually(t, func() bool {
+			conn, err := tls.Dial("tcp", listener.Addr().String(), &tls.Config{RootCAs: roots})
+			if err != nil {
+				return false
+			}
+			defer conn.Close()
+			return conn.ConnectionState().PeerCertificates[0].Subject.CommonName == "second.faucet.test"
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	cancel()
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(shutdownTimeout + time.Second):
+		t.Fatal("server did not shut down")
+	}
+}
+
+func TestServeWaitsForOpenRequests(t *testing.T) {
+	server, _ := newTestServer(t, nil)
+
+	entered := make(chan struct{})
+	finish := make(chan struct{})
+	server.router.GET("/slow", func(c *gin.Context) {
+		close(entered)
+		<-finish
+		c.Status(http.StatusNoContent)
+	})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	served := make(chan error, 1)
+	go func() { served <- server.Serve(ctx, listener) }()
+
+	responses := make(chan *http.Response, 1)
+	go func() {
+		resp, err := http.Get("http://" + listener.Addr().String() + "/slow")
+		if assert.NoError(t, err) {
+			resp.Body.Close()
+		}
+		responses <- resp
+	}()
+
+	<-entered
+	cancel()
+
+	select {
+	case <-served:
+		t.Fatal("Serve returned while a request was open")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	close(finish)
+	select {
+	case err := <-served:
+		assert.NoError(t, err)
+	case <-time.After(shutdownTimeout + time.Second):
+		t.Fatal("server did not shut down")
+	}
+
+	if resp := <-responses; resp != nil {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode)
+	}
+}
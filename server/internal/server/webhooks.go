@@ -0,0 +1,61 @@
+package server
+
+import (
+	"context"
+	"time"
+
+	"faucet-server/internal/logger"
+	"faucet-server/internal/webhook"
+)
+
+// TransferEvent is the data of request and transfer webhook events.
+type TransferEvent struct {
+	Address string `json:"address"`
+	Asset   string `json:"asset"`
+	Amount  string `json:"amount"`
+	TxID    string `json:"txId,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// PauseEvent is the data of faucet.paused webhook events.
+type PauseEvent struct {
+	Message  string    `json:"message"`
+	PausedAt time.Time `json:"pausedAt"`
+}
+
+// setupEventWebhooks prepares the event dispatcher when webhooks are configured.
+func (s *Server) setupEventWebhooks() {
+	cfg := s.Config()
+	if len(cfg.EventWebhookURLs) == 0 {
+		return
+	}
+
+	s.eventWebhooks = webhook.NewDispatcher(s.store, cfg.EventWebhookURLs, cfg.EventWebhookSecret, webhook.Options{
+		MaxAttempts:    cfg.EventWebhookMaxAttempts,
+		InitialBackoff: cfg.EventWebhookInitialBackoff,
+		MaxBackoff:     cfg.EventWebhookMaxBackoff,
+	})
+}
+
+// DeliverEvents sends queued webhook events until ctx is cancelled. It
+// returns immediately when no event webhooks are configured.
+func (s *Server) DeliverEvents(ctx context.Context) {
+	if s.eventWebhooks == nil {
+		return
+	}
+
+	logger.Infof("Event webhooks enabled for %d endpoints (%d deliveries queued)",
+		len(s.Config().EventWebhookURLs), s.store.PendingWebhooks())
+	s.eventWebhooks.Run(ctx)
+}
+
+// emitEvent queues a webhook event if event webhooks are enabled.
+func (s *Server) emitEvent(eventType string, data interface{}) {
+	if s.eventWebhooks == nil {
+		return
+	}
+
+	if err := s.eventWebhooks.Emit(eventType, data); err != nil {
+		logger.Errorf("Failed to emit webhook event: %v", err)
+	}
+}
@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/config"
+	"faucet-server/internal/webhook"
+)
+
+func TestEventWebhooks(t *testing.T) {
+	const secret = "event-webhook-secret"
+
+	events := make(chan webhook.Event, 10)
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.NoError(t, webhook.VerifySignature([]byte(secret), r.Header.Get(webhook.HeaderSignature), body, time.Minute))
+
+		var event webhook.Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		events <- event
+	}))
+	defer endpoint.Close()
+
+	server, mockClearnode := newTestServer(t, func(cfg *config.Config) {
+		cfg.EventWebhookURLs = []string{endpoint.URL}
+		cfg.EventWebhookSecret = secret
+		cfg.EventWebhookMaxAttempts = 3
+		cfg.EventWebhookInitialBackoff = 10 * time.Millisecond
+		cfg.EventWebhookMaxBackoff = 10 * time.Millisecond
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		server.DeliverEvents(ctx)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	nextEvent := func(t *testing.T) webhook.Event {
+		t.Helper()
+		select {
+		case event := <-events:
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatal("no webhook event received")
+			return webhook.Event{}
+		}
+	}
+
+	address := "0x742D35CC6634c0532925a3B8c17D18fBe3b78890"
+	w := doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+	require.Equal(t, http.StatusOK, w.Code)
+
+	// Deliveries queued together may arrive in either order
+	received := map[string]map[string]interface{}{}
+	for i := 0; i < 2; i++ {
+		event := nextEvent(t)
+		received[event.Type] = event.Data.(map[string]interface{})
+	}
+
+	require.Contains(t, received, webhook.EventRequestAccepted)
+	assert.Equal(t, address, received[webhook.EventRequestAccepted]["address"])
+	assert.Equal(t, "10", received[webhook.EventRequestAccepted]["amount"])
+
+	require.Contains(t, received, webhook.EventTransferSucceeded)
+	assert.Equal(t, txID(mockClearnode.LastTransfer()), received[webhook.EventTransferSucceeded]["txId"])
+
+	// A request rejected by a check in the transfer path is not accepted
+	cfg := *server.Config()
+	cfg.HourlyBudgetDecimal = map[string]decimal.Decimal{"usdc": decimal.NewFromInt(5)}
+	require.NoError(t, server.Reload(&cfg))
+	w = doJSON(t, server, "POST", "/requestTokens", FaucetRequest{UserAddress: address}, nil)
+	require.Equal(t, http.StatusTooManyRequests, w.Code)
+
+	server.Pause("Topping up")
+	event := nextEvent(t)
+	assert.Equal(t, webhook.EventFaucetPaused, event.Type)
+	assert.Equal(t, "Topping up", event.Data.(map[string]interface{})["message"])
+
+	select {
+	case event := <-events:
+		t.Fatalf("unexpected %s event", event.Type)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+)
+
+// AddressList names a persisted set of addresses.
+type AddressList string
+
+const (
+	// Allowlist holds the addresses served when the faucet runs in allowlist-only mode.
+	Allowlist AddressList = "allowlist"
+	// Denylist holds addresses that are never served.
+	Denylist AddressList = "denylist"
+)
+
+type AddressEntry struct {
+	Address string    `json:"address"`
+	Reason  string    `json:"reason,omitempty"`
+	AddedAt time.Time `json:"addedAt"`
+}
+
+// AddAddress adds address to list, replacing the reason of an existing entry.
+func (s *Store) AddAddress(list AddressList, address common.Address, reason string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.addressList(list)
+	previous, exists := entries[address.Hex()]
+	entry := previous
+	if !exists {
+		entry = AddressEntry{Address: address.Hex(), AddedAt: time.Now().UTC()}
+	}
+	entry.Reason = reason
+	entries[address.Hex()] = entry
+
+	if err := s.persist(); err != nil {
+		if exists {
+			entries[address.Hex()] = previous
+		} else {
+			delete(entries, address.Hex())
+		}
+		return err
+	}
+	return nil
+}
+
+// ImportAddresses adds every address not yet on list and returns how many were added.
+func (s *Store) ImportAddresses(list AddressList, addresses []common.Address, reason string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.addressList(list)
+	var added []string
+	for _, address := range addresses {
+		if _, exists := entries[address.Hex()]; exists {
+			continue
+		}
+		entries[address.Hex()] = AddressEntry{
+			Address: address.Hex(),
+			Reason:  reason,
+			AddedAt: time.Now().UTC(),
+		}
+		added = append(added, address.Hex())
+	}
+
+	if len(added) == 0 {
+		return 0, nil
+	}
+
+	if err := s.persist(); err != nil {
+		for _, address := range added {
+			delete(entries, address)
+		}
+		return 0, err
+	}
+	return len(added), nil
+}
+
+// RemoveAddress removes address from list and reports whether it was present.
+func (s *Store) RemoveAddress(list AddressList, address common.Address) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entries := s.addressList(list)
+	entry, exists := entries[address.Hex()]
+	if !exists {
+		return false, nil
+	}
+	delete(entries, address.Hex())
+
+	if err := s.persist(); err != nil {
+		entries[address.Hex()] = entry
+		return false, err
+	}
+	return true, nil
+}
+
+// HasAddress reports whether address is on list.
+func (s *Store) HasAddress(list AddressList, address common.Address) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	_, exists := s.state.AddressLists[list][address.Hex()]
+	return exists
+}
+
+// ListAddresses returns the entries of list sorted by address.
+func (s *Store) ListAddresses(list AddressList) []AddressEntry {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	entries := make([]AddressEntry, 0, len(s.state.AddressLists[list]))
+	for _, entry := range s.state.AddressLists[list] {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Address < entries[j].Address
+	})
+
+	return entries
+}
+
+// addressList returns the entries of list, creating it if needed. The caller must hold s.mu.
+func (s *Store) addressList(list AddressList) map[string]AddressEntry {
+	entries, ok := s.state.AddressLists[list]
+	if !ok {
+		entries = make(map[string]AddressEntry)
+		s.state.AddressLists[list] = entries
+	}
+	return entries
+}
+
+// ReadAddressFile parses a file with one Ethereum address per line.
+// Blank lines and everything after a '#' are ignored.
+func ReadAddressFile(path string) ([]common.Address, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var addresses []common.Address
+	scanner := bufio.NewScanner(file)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+
+		line := scanner.Text()
+		if idx := strings.Index(line, "#"); idx >= 0 {
+			line = line[:idx]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		if !common.IsHexAddress(line) {
+			return nil, fmt.Errorf("%s:%d: invalid address %q", path, lineNumber, line)
+		}
+		addresses = append(addresses, common.HexToAddress(line))
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return addresses, nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var ErrAirdropNotFound = errors.New("airdrop not found")
+
+// AirdropRowStatus is the outcome of one transfer of an airdrop.
+type AirdropRowStatus string
+
+const (
+	// AirdropRowPending has not been attempted yet, e.g. because the run
+	// was interrupted.
+	AirdropRowPending AirdropRowStatus = "pending"
+	// AirdropRowSending is recorded before the transfer starts, so a row
+	// interrupted by a crash is not sent again on resume.
+	AirdropRowSending AirdropRowStatus = "sending"
+	AirdropRowSent    AirdropRowStatus = "sent"
+	AirdropRowFailed  AirdropRowStatus = "failed"
+	// AirdropRowUnknown timed out and may still have gone through, so it is
+	// not retried automatically.
+	AirdropRowUnknown AirdropRowStatus = "unknown"
+)
+
+type AirdropRow struct {
+	// Line of the CSV upload or position in the JSON array the row came
+	// from, counted from 1; 0 for airdrops stored before it was recorded
+	Line    int              `json:"line,omitempty"`
+	Address string           `json:"address"`
+	Amount  decimal.Decimal  `json:"amount"`
+	Status  AirdropRowStatus `json:"status"`
+	TxID    string           `json:"txId,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+// Airdrop is a batch of transfers started through the admin API. Rows are
+// updated as transfers complete so that an interrupted run can be resumed.
+type Airdrop struct {
+	ID        string       `json:"id"`
+	Asset     string       `json:"asset"`
+	Rows      []AirdropRow `json:"rows"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+}
+
+// Remaining returns the indexes of the rows a run should (re)attempt.
+func (a Airdrop) Remaining() []int {
+	var remaining []int
+	for i, row := range a.Rows {
+		if row.Status == AirdropRowPending || row.Status == AirdropRowFailed {
+			remaining = append(remaining, i)
+		}
+	}
+	return remaining
+}
+
+// CreateAirdrop stores a new airdrop with all rows pending.
+func (s *Store) CreateAirdrop(asset string, rows []AirdropRow) (Airdrop, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return Airdrop{}, err
+	}
+
+	now := time.Now().UTC()
+	airdrop := Airdrop{
+		ID:        id,
+		Asset:     asset,
+		Rows:      make([]AirdropRow, len(rows)),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	for i, row := range rows {
+		airdrop.Rows[i] = AirdropRow{Address: row.Address, Amount: row.Amount, Line: row.Line, Status: AirdropRowPending}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.Airdrops[id] = airdrop
+	if err := s.persist(); err != nil {
+		delete(s.state.Airdrops, id)
+		return Airdrop{}, err
+	}
+
+	return airdrop, nil
+}
+
+// GetAirdrop returns the airdrop with the given ID.
+func (s *Store) GetAirdrop(id string) (Airdrop, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	airdrop, ok := s.state.Airdrops[id]
+	if !ok {
+		return Airdrop{}, false
+	}
+	airdrop.Rows = append([]AirdropRow(nil), airdrop.Rows...)
+	return airdrop, true
+}
+
+// ListAirdrops returns all airdrops, newest first.
+func (s *Store) ListAirdrops() []Airdrop {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	airdrops := make([]Airdrop, 0, len(s.state.Airdrops))
+	for _, airdrop := range s.state.Airdrops {
+		airdrop.Rows = append([]AirdropRow(nil), airdrop.Rows...)
+		airdrops = append(airdrops, airdrop)
+	}
+
+	sort.Slice(airdrops, func(i, j int) bool {
+		return airdrops[i].CreatedAt.After(airdrops[j].CreatedAt)
+	})
+	return airdrops
+}
+
+// MarkAirdropRowSending records that the transfer at index is about to
+// start. The transfer must not start unless this succeeds.
+func (s *Store) MarkAirdropRowSending(id string, index int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	airdrop, err := s.airdropRow(id, index)
+	if err != nil {
+		return err
+	}
+
+	previous := airdrop
+	row := airdrop.Rows[index]
+	row.Status = AirdropRowSending
+	row.Error = ""
+	s.setAirdropRow(airdrop, index, row)
+
+	if err := s.persist(); err != nil {
+		s.state.Airdrops[id] = previous
+		return err
+	}
+	return nil
+}
+
+// UpdateAirdropRow records the outcome of the transfer at index. Unlike
+// other mutations it is kept if the write fails, as rolling back a sent row
+// would let a resume send it again; the write is retried in the background.
+func (s *Store) UpdateAirdropRow(id string, index int, row AirdropRow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	airdrop, err := s.airdropRow(id, index)
+	if err != nil {
+		return err
+	}
+
+	s.setAirdropRow(airdrop, index, row)
+
+	if err := s.persist(); err != nil {
+		s.persistLater()
+		return err
+	}
+	return nil
+}
+
+// airdropRow returns the airdrop holding the row at index. The caller must
+// hold s.mu.
+func (s *Store) airdropRow(id string, index int) (Airdrop, error) {
+	airdrop, ok := s.state.Airdrops[id]
+	if !ok {
+		return Airdrop{}, ErrAirdropNotFound
+	}
+	if index < 0 || index >= len(airdrop.Rows) {
+		return Airdrop{}, fmt.Errorf("airdrop %s has no row %d", id, index)
+	}
+	return airdrop, nil
+}
+
+// setAirdropRow replaces the row at index without modifying the rows
+// slice shared with the previous state. The caller must hold s.mu.
+func (s *Store) setAirdropRow(airdrop Airdrop, index int, row AirdropRow) {
+	airdrop.Rows = append([]AirdropRow(nil), airdrop.Rows...)
+	airdrop.Rows[index] = row
+	airdrop.UpdatedAt = time.Now().UTC()
+	s.state.Airdrops[airdrop.ID] = airdrop
+}
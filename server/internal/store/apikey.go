@@ -0,0 +1,248 @@
+package store
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// APIKeyPrefix starts every API key token, e.g. fk_<id>_<secret>.
+const APIKeyPrefix = "fk_"
+
+var (
+	// ErrInvalidAPIKey is returned for malformed, unknown or revoked tokens.
+	ErrInvalidAPIKey = errors.New("invalid API key")
+	// ErrAPIKeyNotFound is returned when no key has the given ID.
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// APIKey is a partner credential. Only a hash of the secret is stored; the
+// token is returned once, by CreateAPIKey.
+type APIKey struct {
+	ID         string `json:"id"`
+	Name       string `json:"name"`
+	SecretHash string `json:"secretHash"`
+
+	// Requests per UTC day; 0 is unlimited
+	DailyQuota int `json:"dailyQuota"`
+	// Assets the key may request; empty means the configured token
+	Assets []string `json:"assets,omitempty"`
+	// Amount per request; nil means the standard tip amount
+	TipAmount *decimal.Decimal `json:"tipAmount,omitempty"`
+	// Client IPs or CIDR ranges the key may be used from; empty means any
+	AllowedIPs []string `json:"allowedIps,omitempty"`
+
+	CreatedAt time.Time   `json:"createdAt"`
+	Usage     APIKeyUsage `json:"usage"`
+}
+
+// APIKeyUsage counts a key's successful and in-flight requests.
+type APIKeyUsage struct {
+	// Start of the day Requests refers to
+	WindowStart   time.Time                  `json:"windowStart"`
+	Requests      int                        `json:"requests"`
+	TotalRequests int                        `json:"totalRequests"`
+	Dispensed     map[string]decimal.Decimal `json:"dispensed,omitempty"`
+	LastUsedAt    *time.Time                 `json:"lastUsedAt,omitempty"`
+}
+
+// RequestsToday returns the number of requests counted in the UTC day
+// containing now.
+func (k APIKey) RequestsToday(now time.Time) int {
+	if !k.Usage.WindowStart.Equal(Daily.WindowStart(now)) {
+		return 0
+	}
+	return k.Usage.Requests
+}
+
+// APIKeyQuotaError is returned by ReserveAPIKeyRequest once a key's daily
+// quota is used up.
+type APIKeyQuotaError struct {
+	Quota    int
+	ResetsAt time.Time
+}
+
+func (e *APIKeyQuotaError) Error() string {
+	return fmt.Sprintf("daily quota of %d requests exhausted, resets at %s", e.Quota, e.ResetsAt.Format(time.RFC3339))
+}
+
+// CreateAPIKey stores key under a new ID and secret and returns the stored
+// key together with its token.
+func (s *Store) CreateAPIKey(key APIKey) (APIKey, string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+	secret, err := randomHex(24)
+	if err != nil {
+		return APIKey{}, "", err
+	}
+
+	token := APIKeyPrefix + id + "_" + secret
+	key.ID = id
+	key.SecretHash = hashSecret(secret)
+	key.CreatedAt = time.Now().UTC()
+	key.Usage = APIKeyUsage{}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.state.APIKeys[id] = key
+	if err := s.persist(); err != nil {
+		delete(s.state.APIKeys, id)
+		return APIKey{}, "", err
+	}
+
+	return key, token, nil
+}
+
+// AuthenticateAPIKey returns the key a token belongs to.
+func (s *Store) AuthenticateAPIKey(token string) (APIKey, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, APIKeyPrefix), "_")
+	if !ok || !strings.HasPrefix(token, APIKeyPrefix) {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	s.mu.RLock()
+	key, exists := s.state.APIKeys[id]
+	s.mu.RUnlock()
+
+	if !exists || subtle.ConstantTimeCompare([]byte(hashSecret(secret)), []byte(key.SecretHash)) != 1 {
+		return APIKey{}, ErrInvalidAPIKey
+	}
+
+	return key, nil
+}
+
+// GetAPIKey returns the key with id.
+func (s *Store) GetAPIKey(id string) (APIKey, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	key, ok := s.state.APIKeys[id]
+	return key, ok
+}
+
+// ListAPIKeys returns all keys, oldest first.
+func (s *Store) ListAPIKeys() []APIKey {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	keys := make([]APIKey, 0, len(s.state.APIKeys))
+	for _, key := range s.state.APIKeys {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].CreatedAt.Before(keys[j].CreatedAt)
+	})
+
+	return keys
+}
+
+// DeleteAPIKey revokes the key with id and reports whether it existed.
+func (s *Store) DeleteAPIKey(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.state.APIKeys[id]
+	if !exists {
+		return false, nil
+	}
+	delete(s.state.APIKeys, id)
+
+	if err := s.persist(); err != nil {
+		s.state.APIKeys[id] = key
+		return false, err
+	}
+	return true, nil
+}
+
+// ReserveAPIKeyRequest counts a request against the key's daily quota,
+// returning an *APIKeyQuotaError if it is used up. Call
+// ReleaseAPIKeyRequest if the request then fails.
+func (s *Store) ReserveAPIKeyRequest(id string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.state.APIKeys[id]
+	if !exists {
+		return ErrAPIKeyNotFound
+	}
+
+	requests := key.RequestsToday(now)
+	if key.DailyQuota > 0 && requests >= key.DailyQuota {
+		return &APIKeyQuotaError{Quota: key.DailyQuota, ResetsAt: Daily.WindowEnd(now)}
+	}
+
+	key.Usage.WindowStart = Daily.WindowStart(now)
+	key.Usage.Requests = requests + 1
+	s.state.APIKeys[id] = key
+
+	s.persistLater()
+	return nil
+}
+
+// ReleaseAPIKeyRequest returns a request reserved at reservedAt, unless the
+// day has changed since.
+func (s *Store) ReleaseAPIKeyRequest(id string, reservedAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.state.APIKeys[id]
+	if !exists || !key.Usage.WindowStart.Equal(Daily.WindowStart(reservedAt)) || key.Usage.Requests == 0 {
+		return
+	}
+
+	key.Usage.Requests--
+	s.state.APIKeys[id] = key
+
+	s.persistLater()
+}
+
+// RecordAPIKeyTransfer adds a successful transfer to the key's totals.
+func (s *Store) RecordAPIKeyTransfer(id, asset string, amount decimal.Decimal, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key, exists := s.state.APIKeys[id]
+	if !exists {
+		return ErrAPIKeyNotFound
+	}
+
+	asset = strings.ToLower(asset)
+	dispensed := make(map[string]decimal.Decimal, len(key.Usage.Dispensed)+1)
+	for a, total := range key.Usage.Dispensed {
+		dispensed[a] = total
+	}
+	dispensed[asset] = dispensed[asset].Add(amount)
+
+	lastUsedAt := now.UTC()
+	key.Usage.Dispensed = dispensed
+	key.Usage.TotalRequests++
+	key.Usage.LastUsedAt = &lastUsedAt
+	s.state.APIKeys[id] = key
+
+	s.persistLater()
+	return nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
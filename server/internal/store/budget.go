@@ -0,0 +1,173 @@
+package store
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// BudgetPeriod is a fixed window over which dispensing is capped.
+type BudgetPeriod string
+
+const (
+	// Hourly windows start on the full hour.
+	Hourly BudgetPeriod = "hourly"
+	// Daily windows start at midnight UTC.
+	Daily BudgetPeriod = "daily"
+)
+
+// BudgetPeriods lists the periods in the order they are checked.
+var BudgetPeriods = []BudgetPeriod{Hourly, Daily}
+
+// WindowStart returns the start of the window containing t.
+func (p BudgetPeriod) WindowStart(t time.Time) time.Time {
+	t = t.UTC()
+	if p == Daily {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	}
+	return t.Truncate(time.Hour)
+}
+
+// WindowEnd returns the time the window containing t resets.
+func (p BudgetPeriod) WindowEnd(t time.Time) time.Time {
+	if p == Daily {
+		return p.WindowStart(t).AddDate(0, 0, 1)
+	}
+	return p.WindowStart(t).Add(time.Hour)
+}
+
+// BudgetLimits maps each period to its cap for a single asset. Periods
+// without an entry are unlimited.
+type BudgetLimits map[BudgetPeriod]decimal.Decimal
+
+// BudgetUsage is the amount of an asset dispensed in the current window.
+type BudgetUsage struct {
+	WindowStart time.Time       `json:"windowStart"`
+	Spent       decimal.Decimal `json:"spent"`
+}
+
+// BudgetStatus describes a period's budget for reporting.
+type BudgetStatus struct {
+	Limit     decimal.Decimal `json:"limit"`
+	Spent     decimal.Decimal `json:"spent"`
+	Remaining decimal.Decimal `json:"remaining"`
+	ResetsAt  time.Time       `json:"resetsAt"`
+}
+
+// BudgetExhaustedError is returned by ReserveBudget when an amount would
+// exceed a period's budget.
+type BudgetExhaustedError struct {
+	Period    BudgetPeriod
+	Asset     string
+	Remaining decimal.Decimal
+	ResetsAt  time.Time
+}
+
+func (e *BudgetExhaustedError) Error() string {
+	return fmt.Sprintf("%s budget for %s exhausted, resets at %s", e.Period, e.Asset, e.ResetsAt.Format(time.RFC3339))
+}
+
+// ReserveBudget records amount of asset as dispensed at now if it fits into
+// every limited period, and returns a *BudgetExhaustedError otherwise.
+// Checking and recording happen atomically, so concurrent requests can't
+// overshoot a budget. Call ReleaseBudget if the transfer then fails.
+func (s *Store) ReserveBudget(asset string, amount decimal.Decimal, limits BudgetLimits, now time.Time) error {
+	if len(limits) == 0 {
+		return nil
+	}
+
+	asset = strings.ToLower(asset)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, period := range BudgetPeriods {
+		limit, ok := limits[period]
+		if !ok {
+			continue
+		}
+
+		spent := s.budgetSpent(period, asset, now)
+		if spent.Add(amount).GreaterThan(limit) {
+			return &BudgetExhaustedError{
+				Period:    period,
+				Asset:     asset,
+				Remaining: decimal.Max(limit.Sub(spent), decimal.Zero),
+				ResetsAt:  period.WindowEnd(now),
+			}
+		}
+	}
+
+	for _, period := range BudgetPeriods {
+		if _, ok := limits[period]; !ok {
+			continue
+		}
+
+		s.state.Budgets[budgetKey(period, asset)] = BudgetUsage{
+			WindowStart: period.WindowStart(now),
+			Spent:       s.budgetSpent(period, asset, now).Add(amount),
+		}
+	}
+
+	s.persistLater()
+	return nil
+}
+
+// ReleaseBudget returns an amount reserved at reservedAt, for windows that
+// haven't reset since.
+func (s *Store) ReleaseBudget(asset string, amount decimal.Decimal, reservedAt time.Time) {
+	asset = strings.ToLower(asset)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, period := range BudgetPeriods {
+		key := budgetKey(period, asset)
+		usage, ok := s.state.Budgets[key]
+		if !ok || !usage.WindowStart.Equal(period.WindowStart(reservedAt)) {
+			continue
+		}
+
+		usage.Spent = decimal.Max(usage.Spent.Sub(amount), decimal.Zero)
+		s.state.Budgets[key] = usage
+	}
+
+	s.persistLater()
+}
+
+// BudgetStatus reports the current window of every limited period.
+func (s *Store) BudgetStatus(asset string, limits BudgetLimits, now time.Time) map[BudgetPeriod]BudgetStatus {
+	asset = strings.ToLower(asset)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	status := make(map[BudgetPeriod]BudgetStatus, len(limits))
+	for period, limit := range limits {
+		spent := s.budgetSpent(period, asset, now)
+		status[period] = BudgetStatus{
+			Limit:     limit,
+			Spent:     spent,
+			Remaining: decimal.Max(limit.Sub(spent), decimal.Zero),
+			ResetsAt:  period.WindowEnd(now),
+		}
+	}
+
+	return status
+}
+
+// budgetSpent returns the amount dispensed in the window containing now.
+// The caller must hold s.mu.
+func (s *Store) budgetSpent(period BudgetPeriod, asset string, now time.Time) decimal.Decimal {
+	usage, ok := s.state.Budgets[budgetKey(period, asset)]
+	if !ok || !usage.WindowStart.Equal(period.WindowStart(now)) {
+		return decimal.Zero
+	}
+	return usage.Spent
+}
+
+func budgetKey(period BudgetPeriod, asset string) string {
+	return string(period) + ":" + asset
+}
@@ -0,0 +1,273 @@
+package store
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+var (
+	ErrClaimCodeInvalid         = errors.New("unknown claim code")
+	ErrClaimCodeExpired         = errors.New("claim code has expired")
+	ErrClaimCodeUsedUp          = errors.New("claim code has been fully redeemed")
+	ErrClaimCodeAlreadyRedeemed = errors.New("claim code was already redeemed for this address")
+)
+
+// claimCodeAlphabet leaves out 0, O, 1 and I, which are easily confused when
+// codes are printed on cards or read out.
+const claimCodeAlphabet = "23456789ABCDEFGHJKLMNPQRSTUVWXYZ"
+
+// claimCodeLength gives 60 bits of entropy, shown as three groups of four.
+const claimCodeLength = 12
+
+// ClaimBatch is a set of claim codes generated together, e.g. for one event.
+type ClaimBatch struct {
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Asset     string          `json:"asset"`
+	Amount    decimal.Decimal `json:"amount"`
+	MaxUses   int             `json:"maxUses"`
+	ExpiresAt time.Time       `json:"expiresAt"`
+	Codes     int             `json:"codes"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
+
+// ClaimCode tracks the redemptions of one code. Codes are keyed by their
+// hash; the plaintext is only returned when the batch is created.
+type ClaimCode struct {
+	BatchID string `json:"batchId"`
+	// Redemption time per checksummed address
+	RedeemedBy map[string]time.Time `json:"redeemedBy,omitempty"`
+}
+
+// ClaimBatchUsage summarises the redemptions of a batch.
+type ClaimBatchUsage struct {
+	Redemptions int
+	CodesUsedUp int
+}
+
+// NormalizeClaimCode uppercases code and strips the separators users may or
+// may not type.
+func NormalizeClaimCode(code string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == ' ' {
+			return -1
+		}
+		return r
+	}, strings.ToUpper(strings.TrimSpace(code)))
+}
+
+// CreateClaimBatch stores batch together with count new codes and returns
+// the batch and the codes in plaintext.
+func (s *Store) CreateClaimBatch(batch ClaimBatch, count int) (ClaimBatch, []string, error) {
+	id, err := randomHex(8)
+	if err != nil {
+		return ClaimBatch{}, nil, err
+	}
+
+	codes := make([]string, count)
+	for i := range codes {
+		if codes[i], err = newClaimCode(); err != nil {
+			return ClaimBatch{}, nil, err
+		}
+	}
+
+	batch.ID = id
+	batch.Codes = count
+	batch.CreatedAt = time.Now().UTC()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hashes := make([]string, 0, count)
+	for _, code := range codes {
+		hash := hashSecret(NormalizeClaimCode(code))
+		if _, exists := s.state.ClaimCodes[hash]; exists {
+			// Astronomically unlikely, but a collision would merge two codes
+			s.removeClaimCodes(hashes)
+			return ClaimBatch{}, nil, fmt.Errorf("generated a duplicate claim code, try again")
+		}
+		s.state.ClaimCodes[hash] = ClaimCode{BatchID: id}
+		hashes = append(hashes, hash)
+	}
+	s.state.ClaimBatches[id] = batch
+
+	if err := s.persist(); err != nil {
+		s.removeClaimCodes(hashes)
+		delete(s.state.ClaimBatches, id)
+		return ClaimBatch{}, nil, err
+	}
+
+	return batch, codes, nil
+}
+
+// ListClaimBatches returns all batches, newest first.
+func (s *Store) ListClaimBatches() []ClaimBatch {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	batches := make([]ClaimBatch, 0, len(s.state.ClaimBatches))
+	for _, batch := range s.state.ClaimBatches {
+		batches = append(batches, batch)
+	}
+
+	sort.Slice(batches, func(i, j int) bool {
+		return batches[i].CreatedAt.After(batches[j].CreatedAt)
+	})
+	return batches
+}
+
+// ClaimBatchUsage counts the redemptions of the batch with the given ID.
+func (s *Store) ClaimBatchUsage(id string) ClaimBatchUsage {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	batch := s.state.ClaimBatches[id]
+	var usage ClaimBatchUsage
+	for _, code := range s.state.ClaimCodes {
+		if code.BatchID != id {
+			continue
+		}
+		usage.Redemptions += len(code.RedeemedBy)
+		if len(code.RedeemedBy) >= batch.MaxUses {
+			usage.CodesUsedUp++
+		}
+	}
+	return usage
+}
+
+// DeleteClaimBatch revokes a batch and all of its codes. It reports whether
+// the batch existed.
+func (s *Store) DeleteClaimBatch(id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	batch, ok := s.state.ClaimBatches[id]
+	if !ok {
+		return false, nil
+	}
+
+	delete(s.state.ClaimBatches, id)
+	removed := make(map[string]ClaimCode)
+	for hash, code := range s.state.ClaimCodes {
+		if code.BatchID == id {
+			removed[hash] = code
+			delete(s.state.ClaimCodes, hash)
+		}
+	}
+
+	if err := s.persist(); err != nil {
+		s.state.ClaimBatches[id] = batch
+		for hash, code := range removed {
+			s.state.ClaimCodes[hash] = code
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// RedeemClaimCode uses up one redemption of code for address and returns
+// the code's batch, which holds what to send. Checking and recording happen
+// under one lock, so concurrent redemptions can never exceed MaxUses.
+func (s *Store) RedeemClaimCode(code, address string, now time.Time) (ClaimBatch, error) {
+	hash := hashSecret(NormalizeClaimCode(code))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claim, ok := s.state.ClaimCodes[hash]
+	if !ok {
+		return ClaimBatch{}, ErrClaimCodeInvalid
+	}
+
+	batch, ok := s.state.ClaimBatches[claim.BatchID]
+	if !ok {
+		return ClaimBatch{}, ErrClaimCodeInvalid
+	}
+
+	if !now.Before(batch.ExpiresAt) {
+		return ClaimBatch{}, ErrClaimCodeExpired
+	}
+
+	if _, redeemed := claim.RedeemedBy[address]; redeemed {
+		return ClaimBatch{}, ErrClaimCodeAlreadyRedeemed
+	}
+
+	if len(claim.RedeemedBy) >= batch.MaxUses {
+		return ClaimBatch{}, ErrClaimCodeUsedUp
+	}
+
+	redeemedBy := make(map[string]time.Time, len(claim.RedeemedBy)+1)
+	for redeemer, at := range claim.RedeemedBy {
+		redeemedBy[redeemer] = at
+	}
+	redeemedBy[address] = now.UTC()
+	s.state.ClaimCodes[hash] = ClaimCode{BatchID: claim.BatchID, RedeemedBy: redeemedBy}
+
+	if err := s.persist(); err != nil {
+		s.state.ClaimCodes[hash] = claim
+		return ClaimBatch{}, err
+	}
+
+	return batch, nil
+}
+
+// ReleaseClaimCode undoes the redemption of code for address after its
+// transfer failed.
+func (s *Store) ReleaseClaimCode(code, address string) error {
+	hash := hashSecret(NormalizeClaimCode(code))
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claim, ok := s.state.ClaimCodes[hash]
+	if !ok {
+		return nil
+	}
+	if _, redeemed := claim.RedeemedBy[address]; !redeemed {
+		return nil
+	}
+
+	redeemedBy := make(map[string]time.Time, len(claim.RedeemedBy))
+	for redeemer, at := range claim.RedeemedBy {
+		if redeemer != address {
+			redeemedBy[redeemer] = at
+		}
+	}
+	s.state.ClaimCodes[hash] = ClaimCode{BatchID: claim.BatchID, RedeemedBy: redeemedBy}
+
+	if err := s.persist(); err != nil {
+		s.state.ClaimCodes[hash] = claim
+		return err
+	}
+	return nil
+}
+
+// removeClaimCodes drops codes by hash. The caller must hold s.mu.
+func (s *Store) removeClaimCodes(hashes []string) {
+	for _, hash := range hashes {
+		delete(s.state.ClaimCodes, hash)
+	}
+}
+
+func newClaimCode() (string, error) {
+	buf := make([]byte, claimCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random bytes: %w", err)
+	}
+
+	var code strings.Builder
+	for i, b := range buf {
+		if i > 0 && i%4 == 0 {
+			code.WriteByte('-')
+		}
+		// 256 is a multiple of the alphabet size, so this is unbiased
+		code.WriteByte(claimCodeAlphabet[int(b)%len(claimCodeAlphabet)])
+	}
+	return code.String(), nil
+}
@@ -0,0 +1,181 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"faucet-server/internal/logger"
+)
+
+// flushDelay is how long counter updates may wait to be written, so that a
+// burst of them costs a single write of the store.
+const flushDelay = time.Second
+
+// Store keeps the faucet's mutable state (address lists and other records
+// managed at runtime) and persists it as a single JSON document.
+// A Store opened with an empty path lives in memory only.
+//
+// Changes made by an administrator, and those that must survive a crash
+// (claim redemptions, queued webhooks), are written before the mutator
+// returns and undone if the write fails. Counters updated on every request
+// (budgets, API key usage, webhook retry bookkeeping) are written within
+// flushDelay instead; call Flush before exiting to write them right away.
+type Store struct {
+	path string
+
+	mu    sync.RWMutex
+	state state
+	// Set while changes are waiting for a deferred write
+	dirty      bool
+	flushTimer *time.Timer
+}
+
+// state is the persisted document. Every collection is keyed so that
+// lookups don't need to scan.
+type state struct {
+	AddressLists map[AddressList]map[string]AddressEntry `json:"addressLists"`
+	Budgets      map[string]BudgetUsage                  `json:"budgets"`
+	Webhooks     map[string]WebhookDelivery              `json:"webhooks"`
+	APIKeys      map[string]APIKey                       `json:"apiKeys"`
+	Airdrops     map[string]Airdrop                      `json:"airdrops"`
+	ClaimBatches map[string]ClaimBatch                   `json:"claimBatches"`
+	ClaimCodes   map[string]ClaimCode                    `json:"claimCodes"`
+}
+
+func newState() state {
+	return state{
+		AddressLists: make(map[AddressList]map[string]AddressEntry),
+		Budgets:      make(map[string]BudgetUsage),
+		Webhooks:     make(map[string]WebhookDelivery),
+		APIKeys:      make(map[string]APIKey),
+		Airdrops:     make(map[string]Airdrop),
+		ClaimBatches: make(map[string]ClaimBatch),
+		ClaimCodes:   make(map[string]ClaimCode),
+	}
+}
+
+// Open loads the store from path, creating an empty one if the file does not
+// exist yet. An empty path returns an in-memory store.
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:  path,
+		state: newState(),
+	}
+
+	if path == "" {
+		return s, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return s, nil
+		}
+		return nil, fmt.Errorf("failed to read store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("failed to parse store %s: %w", path, err)
+	}
+
+	// Collections added after the file was written are missing from it
+	defaults := newState()
+	if s.state.AddressLists == nil {
+		s.state.AddressLists = defaults.AddressLists
+	}
+	if s.state.Budgets == nil {
+		s.state.Budgets = defaults.Budgets
+	}
+	if s.state.Webhooks == nil {
+		s.state.Webhooks = defaults.Webhooks
+	}
+	if s.state.APIKeys == nil {
+		s.state.APIKeys = defaults.APIKeys
+	}
+	if s.state.Airdrops == nil {
+		s.state.Airdrops = defaults.Airdrops
+	}
+	if s.state.ClaimBatches == nil {
+		s.state.ClaimBatches = defaults.ClaimBatches
+	}
+	if s.state.ClaimCodes == nil {
+		s.state.ClaimCodes = defaults.ClaimCodes
+	}
+
+	return s, nil
+}
+
+// Flush writes changes still waiting for a deferred write.
+func (s *Store) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return nil
+	}
+	return s.persist()
+}
+
+// persistLater schedules a write of the current state within flushDelay.
+// The caller must hold s.mu.
+func (s *Store) persistLater() {
+	if s.path == "" || s.dirty {
+		return
+	}
+
+	s.dirty = true
+	s.flushTimer = time.AfterFunc(flushDelay, s.flushDeferred)
+}
+
+// flushDeferred performs a deferred write, retrying after flushDelay if it
+// fails.
+func (s *Store) flushDeferred() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.dirty {
+		return
+	}
+	if err := s.persist(); err != nil {
+		logger.Errorf("Failed to write store, retrying in %s: %v", flushDelay, err)
+		s.flushTimer = time.AfterFunc(flushDelay, s.flushDeferred)
+	}
+}
+
+// persist writes the current state to disk, including changes waiting for a
+// deferred write. The caller must hold s.mu.
+func (s *Store) persist() error {
+	if s.path == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode store: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create store directory: %w", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated store
+	tmpPath := s.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write store: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, s.path); err != nil {
+		return fmt.Errorf("failed to replace store: %w", err)
+	}
+
+	if s.dirty {
+		s.dirty = false
+		s.flushTimer.Stop()
+	}
+	return nil
+}
@@ -0,0 +1,459 @@
+package store
+
+import (
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	addressA = common.HexToAddress("0x742D35CC6634c0532925a3B8c17D18fBe3b78890")
+	addressB = common.HexToAddress("0x9fc51BEE23Fb53569c46CcF013400f0E19524bd2")
+)
+
+func TestStorePersistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state", "faucet.json")
+
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	require.NoError(t, st.AddAddress(Denylist, addressA, "exchange deposit address"))
+	added, err := st.ImportAddresses(Allowlist, []common.Address{addressA, addressB, addressB}, "beta")
+	require.NoError(t, err)
+	assert.Equal(t, 2, added)
+
+	reopened, err := Open(path)
+	require.NoError(t, err)
+
+	assert.True(t, reopened.HasAddress(Denylist, addressA))
+	assert.False(t, reopened.HasAddress(Denylist, addressB))
+
+	entries := reopened.ListAddresses(Allowlist)
+	require.Len(t, entries, 2)
+	assert.Equal(t, addressA.Hex(), entries[0].Address)
+	assert.Equal(t, "beta", entries[0].Reason)
+
+	removed, err := reopened.RemoveAddress(Allowlist, addressA)
+	require.NoError(t, err)
+	assert.True(t, removed)
+
+	removed, err = reopened.RemoveAddress(Allowlist, addressA)
+	require.NoError(t, err)
+	assert.False(t, removed)
+
+	reopened, err = Open(path)
+	require.NoError(t, err)
+	assert.False(t, reopened.HasAddress(Allowlist, addressA))
+	assert.True(t, reopened.HasAddress(Allowlist, addressB))
+}
+
+func TestDeferredWrites(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.json")
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	limits := BudgetLimits{Daily: decimal.NewFromInt(100)}
+	now := time.Now()
+	spent := func() string {
+		reopened, err := Open(path)
+		require.NoError(t, err)
+		return reopened.BudgetStatus("usdc", limits, now)[Daily].Spent.String()
+	}
+
+	// A burst of counter updates is written once, after flushDelay
+	for range 3 {
+		require.NoError(t, st.ReserveBudget("usdc", decimal.NewFromInt(10), limits, now))
+	}
+	assert.Equal(t, "0", spent())
+	require.Eventually(t, func() bool { return spent() == "30" }, 3*flushDelay, 10*time.Millisecond)
+
+	require.NoError(t, st.ReserveBudget("usdc", decimal.NewFromInt(10), limits, now))
+	require.NoError(t, st.Flush())
+	assert.Equal(t, "40", spent())
+
+	// Writes that can't wait include pending counter updates
+	require.NoError(t, st.ReserveBudget("usdc", decimal.NewFromInt(10), limits, now))
+	require.NoError(t, st.AddAddress(Denylist, addressA, ""))
+	assert.Equal(t, "50", spent())
+}
+
+func TestRollbackOnWriteFailure(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "faucet.json")
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	require.NoError(t, st.AddAddress(Denylist, addressA, "original"))
+	key, _, err := st.CreateAPIKey(APIKey{Name: "partner"})
+	require.NoError(t, err)
+	batch, codes, err := st.CreateClaimBatch(ClaimBatch{Asset: "usdc", Amount: decimal.NewFromInt(1), MaxUses: 2, ExpiresAt: time.Now().Add(time.Hour)}, 1)
+	require.NoError(t, err)
+	_, err = st.RedeemClaimCode(codes[0], addressA.Hex(), time.Now())
+	require.NoError(t, err)
+	airdrop, err := st.CreateAirdrop("usdc", []AirdropRow{{Address: addressA.Hex(), Amount: decimal.NewFromInt(1)}})
+	require.NoError(t, err)
+
+	// The store's directory is now a file, so every write fails
+	blocker := filepath.Join(dir, "blocker")
+	require.NoError(t, os.WriteFile(blocker, nil, 0o600))
+	st.path = filepath.Join(blocker, "faucet.json")
+
+	assert.Error(t, st.AddAddress(Denylist, addressA, "changed"))
+	assert.Error(t, st.AddAddress(Denylist, addressB, ""))
+	assert.Equal(t, []AddressEntry{{Address: addressA.Hex(), Reason: "original", AddedAt: st.ListAddresses(Denylist)[0].AddedAt}}, st.ListAddresses(Denylist))
+
+	_, err = st.ImportAddresses(Allowlist, []common.Address{addressA, addressB}, "")
+	assert.Error(t, err)
+	assert.Empty(t, st.ListAddresses(Allowlist))
+
+	_, err = st.RemoveAddress(Denylist, addressA)
+	assert.Error(t, err)
+	assert.True(t, st.HasAddress(Denylist, addressA))
+
+	_, err = st.DeleteAPIKey(key.ID)
+	assert.Error(t, err)
+	_, ok := st.GetAPIKey(key.ID)
+	assert.True(t, ok)
+
+	_, err = st.DeleteClaimBatch(batch.ID)
+	assert.Error(t, err)
+	assert.Len(t, st.ListClaimBatches(), 1)
+	assert.Equal(t, 1, st.ClaimBatchUsage(batch.ID).Redemptions)
+
+	assert.Error(t, st.ReleaseClaimCode(codes[0], addressA.Hex()))
+	assert.Equal(t, 1, st.ClaimBatchUsage(batch.ID).Redemptions)
+
+	assert.Error(t, st.MarkAirdropRowSending(airdrop.ID, 0))
+	loaded, _ := st.GetAirdrop(airdrop.ID)
+	assert.Equal(t, AirdropRowPending, loaded.Rows[0].Status)
+
+	// A sent row is never rolled back, as a resume would send it again;
+	// the write is retried until it succeeds
+	sent := AirdropRow{Address: addressA.Hex(), Amount: decimal.NewFromInt(1), Status: AirdropRowSent, TxID: "42"}
+	assert.Error(t, st.UpdateAirdropRow(airdrop.ID, 0, sent))
+	loaded, _ = st.GetAirdrop(airdrop.ID)
+	assert.Equal(t, sent, loaded.Rows[0])
+	assert.Empty(t, loaded.Remaining())
+
+	st.mu.Lock()
+	st.path = path
+	st.mu.Unlock()
+	require.Eventually(t, func() bool {
+		reopened, err := Open(path)
+		require.NoError(t, err)
+		persisted, _ := reopened.GetAirdrop(airdrop.ID)
+		return persisted.Rows[0].Status == AirdropRowSent
+	}, 5*time.Second, 50*time.Millisecond)
+}
+
+func TestOpenRejectsCorruptStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.json")
+	require.NoError(t, os.WriteFile(path, []byte("{not json"), 0o600))
+
+	_, err := Open(path)
+	require.Error(t, err)
+}
+
+func TestReadAddressFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "denylist.txt")
+
+	t.Run("parses addresses and skips comments", func(t *testing.T) {
+		content := "# known exchange deposit addresses\n\n" +
+			"0x742d35cc6634c0532925a3b8c17d18fbe3b78890\n" +
+			"  0x9fc51BEE23Fb53569c46CcF013400f0E19524bd2  # hot wallet\n"
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+		addresses, err := ReadAddressFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, []common.Address{addressA, addressB}, addresses)
+	})
+
+	t.Run("reports invalid lines", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(path, []byte("0x1234\n"), 0o600))
+
+		_, err := ReadAddressFile(path)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), ":1:")
+	})
+}
+
+func TestBudget(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.json")
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	limits := BudgetLimits{
+		Hourly: decimal.NewFromInt(25),
+		Daily:  decimal.NewFromInt(35),
+	}
+	tip := decimal.NewFromInt(10)
+	now := time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)
+
+	require.NoError(t, st.ReserveBudget("USDC", tip, limits, now))
+	require.NoError(t, st.ReserveBudget("usdc", tip, limits, now.Add(time.Minute)))
+
+	t.Run("rejects amounts exceeding the hourly budget", func(t *testing.T) {
+		err := st.ReserveBudget("usdc", tip, limits, now.Add(2*time.Minute))
+
+		var exhausted *BudgetExhaustedError
+		require.ErrorAs(t, err, &exhausted)
+		assert.Equal(t, Hourly, exhausted.Period)
+		assert.Equal(t, "5", exhausted.Remaining.String())
+		assert.Equal(t, time.Date(2026, 3, 14, 11, 0, 0, 0, time.UTC), exhausted.ResetsAt)
+	})
+
+	t.Run("budgets are tracked per asset", func(t *testing.T) {
+		assert.NoError(t, st.ReserveBudget("weth", tip, limits, now))
+	})
+
+	t.Run("next hour counts against the daily budget", func(t *testing.T) {
+		next := now.Add(time.Hour)
+		require.NoError(t, st.ReserveBudget("usdc", tip, limits, next))
+
+		err := st.ReserveBudget("usdc", tip, limits, next)
+		var exhausted *BudgetExhaustedError
+		require.ErrorAs(t, err, &exhausted)
+		assert.Equal(t, Daily, exhausted.Period)
+		assert.Equal(t, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), exhausted.ResetsAt)
+		assert.Contains(t, err.Error(), "daily budget for usdc exhausted, resets at 2026-03-15T00:00:00Z")
+	})
+
+	t.Run("released amounts can be dispensed again", func(t *testing.T) {
+		next := now.Add(time.Hour)
+		st.ReleaseBudget("usdc", tip, next)
+		assert.NoError(t, st.ReserveBudget("usdc", tip, limits, next))
+	})
+
+	t.Run("usage persists and resets with the window", func(t *testing.T) {
+		require.NoError(t, st.Flush())
+		reopened, err := Open(path)
+		require.NoError(t, err)
+
+		status := reopened.BudgetStatus("usdc", limits, now.Add(time.Hour))
+		assert.Equal(t, "30", status[Daily].Spent.String())
+		assert.Equal(t, "5", status[Daily].Remaining.String())
+		assert.Equal(t, "10", status[Hourly].Spent.String())
+
+		status = reopened.BudgetStatus("usdc", limits, now.AddDate(0, 0, 1))
+		assert.Equal(t, "35", status[Daily].Remaining.String())
+		assert.Equal(t, "25", status[Hourly].Remaining.String())
+	})
+}
+
+func TestAPIKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.json")
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	tip := decimal.NewFromInt(100)
+	key, token, err := st.CreateAPIKey(APIKey{Name: "sdk-tests", DailyQuota: 2, TipAmount: &tip})
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(token, APIKeyPrefix+key.ID+"_"))
+	assert.NotContains(t, key.SecretHash, strings.TrimPrefix(token, APIKeyPrefix+key.ID+"_"))
+
+	t.Run("authenticates tokens", func(t *testing.T) {
+		authenticated, err := st.AuthenticateAPIKey(token)
+		require.NoError(t, err)
+		assert.Equal(t, "sdk-tests", authenticated.Name)
+
+		for _, invalid := range []string{"", "fk_" + key.ID, "fk_" + key.ID + "_wrong", "fk_unknown_secret", strings.TrimPrefix(token, APIKeyPrefix)} {
+			_, err := st.AuthenticateAPIKey(invalid)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey, invalid)
+		}
+	})
+
+	now := time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)
+
+	t.Run("enforces the daily quota", func(t *testing.T) {
+		require.NoError(t, st.ReserveAPIKeyRequest(key.ID, now))
+		require.NoError(t, st.ReserveAPIKeyRequest(key.ID, now))
+
+		err := st.ReserveAPIKeyRequest(key.ID, now)
+		var quotaErr *APIKeyQuotaError
+		require.ErrorAs(t, err, &quotaErr)
+		assert.Equal(t, time.Date(2026, 3, 15, 0, 0, 0, 0, time.UTC), quotaErr.ResetsAt)
+
+		st.ReleaseAPIKeyRequest(key.ID, now)
+		require.NoError(t, st.ReserveAPIKeyRequest(key.ID, now))
+
+		assert.NoError(t, st.ReserveAPIKeyRequest(key.ID, now.AddDate(0, 0, 1)), "the quota resets daily")
+	})
+
+	t.Run("records usage persistently", func(t *testing.T) {
+		require.NoError(t, st.RecordAPIKeyTransfer(key.ID, "USDC", tip, now))
+		require.NoError(t, st.RecordAPIKeyTransfer(key.ID, "usdc", tip, now))
+		require.NoError(t, st.Flush())
+
+		reopened, err := Open(path)
+		require.NoError(t, err)
+		stored, ok := reopened.GetAPIKey(key.ID)
+		require.True(t, ok)
+		assert.Equal(t, 2, stored.Usage.TotalRequests)
+		assert.Equal(t, "200", stored.Usage.Dispensed["usdc"].String())
+		assert.Equal(t, "100", stored.TipAmount.String())
+		assert.Equal(t, 1, stored.RequestsToday(now.AddDate(0, 0, 1)))
+	})
+
+	t.Run("revokes keys", func(t *testing.T) {
+		deleted, err := st.DeleteAPIKey(key.ID)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		_, err = st.AuthenticateAPIKey(token)
+		assert.ErrorIs(t, err, ErrInvalidAPIKey)
+		assert.ErrorIs(t, st.ReserveAPIKeyRequest(key.ID, now), ErrAPIKeyNotFound)
+		assert.Empty(t, st.ListAPIKeys())
+	})
+}
+
+func TestAirdrops(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.json")
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	airdrop, err := st.CreateAirdrop("usdc", []AirdropRow{
+		{Address: "0x742D35CC6634c0532925a3B8c17D18fBe3b78890", Amount: decimal.NewFromInt(5)},
+		{Address: "0x8ba1f109551bD432803012645Ac136ddd64DBA72", Amount: decimal.NewFromInt(7)},
+		{Address: "0x0000000000000000000000000000000000000001", Amount: decimal.NewFromInt(1)},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []int{0, 1, 2}, airdrop.Remaining())
+
+	require.NoError(t, st.UpdateAirdropRow(airdrop.ID, 0, AirdropRow{Address: airdrop.Rows[0].Address, Amount: airdrop.Rows[0].Amount, Status: AirdropRowSent, TxID: "42"}))
+	require.NoError(t, st.UpdateAirdropRow(airdrop.ID, 1, AirdropRow{Address: airdrop.Rows[1].Address, Amount: airdrop.Rows[1].Amount, Status: AirdropRowUnknown, Error: "request timeout"}))
+	assert.ErrorIs(t, st.UpdateAirdropRow("missing", 0, AirdropRow{}), ErrAirdropNotFound)
+	assert.Error(t, st.UpdateAirdropRow(airdrop.ID, 3, AirdropRow{}))
+
+	// The progress survives a restart
+	reopened, err := Open(path)
+	require.NoError(t, err)
+
+	loaded, ok := reopened.GetAirdrop(airdrop.ID)
+	require.True(t, ok)
+	assert.Equal(t, "usdc", loaded.Asset)
+	assert.Equal(t, AirdropRowSent, loaded.Rows[0].Status)
+	assert.Equal(t, "42", loaded.Rows[0].TxID)
+	assert.True(t, loaded.Rows[1].Amount.Equal(decimal.NewFromInt(7)))
+	assert.Equal(t, []int{2}, loaded.Remaining())
+
+	require.Len(t, reopened.ListAirdrops(), 1)
+	_, ok = reopened.GetAirdrop("missing")
+	assert.False(t, ok)
+}
+
+func TestClaimCodes(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "faucet.json")
+	st, err := Open(path)
+	require.NoError(t, err)
+
+	now := time.Date(2026, 3, 14, 10, 15, 0, 0, time.UTC)
+	batch, codes, err := st.CreateClaimBatch(ClaimBatch{
+		Name:      "ETHDenver",
+		Asset:     "usdc",
+		Amount:    decimal.NewFromInt(50),
+		MaxUses:   1,
+		ExpiresAt: now.Add(24 * time.Hour),
+	}, 3)
+	require.NoError(t, err)
+	require.Len(t, codes, 3)
+	assert.Equal(t, 3, batch.Codes)
+	assert.Regexp(t, `^[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}-[2-9A-HJ-NP-Z]{4}$`, codes[0])
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), NormalizeClaimCode(codes[0]), "codes are stored hashed")
+
+	t.Run("redeems once per code", func(t *testing.T) {
+		// Users may type codes without dashes or in lowercase
+		redeemed, err := st.RedeemClaimCode(strings.ToLower(strings.ReplaceAll(codes[0], "-", "")), addressA.Hex(), now)
+		require.NoError(t, err)
+		assert.Equal(t, "usdc", redeemed.Asset)
+		assert.True(t, redeemed.Amount.Equal(decimal.NewFromInt(50)))
+
+		_, err = st.RedeemClaimCode(codes[0], addressA.Hex(), now)
+		assert.ErrorIs(t, err, ErrClaimCodeAlreadyRedeemed)
+
+		_, err = st.RedeemClaimCode(codes[0], addressB.Hex(), now)
+		assert.ErrorIs(t, err, ErrClaimCodeUsedUp)
+
+		assert.Equal(t, ClaimBatchUsage{Redemptions: 1, CodesUsedUp: 1}, st.ClaimBatchUsage(batch.ID))
+	})
+
+	t.Run("released codes can be redeemed again", func(t *testing.T) {
+		_, err := st.RedeemClaimCode(codes[1], addressA.Hex(), now)
+		require.NoError(t, err)
+		require.NoError(t, st.ReleaseClaimCode(codes[1], addressA.Hex()))
+
+		_, err = st.RedeemClaimCode(codes[1], addressB.Hex(), now)
+		assert.NoError(t, err)
+	})
+
+	t.Run("rejects unknown and expired codes", func(t *testing.T) {
+		_, err := st.RedeemClaimCode("AAAA-BBBB-CCCC", addressA.Hex(), now)
+		assert.ErrorIs(t, err, ErrClaimCodeInvalid)
+
+		_, err = st.RedeemClaimCode(codes[2], addressA.Hex(), now.Add(24*time.Hour))
+		assert.ErrorIs(t, err, ErrClaimCodeExpired)
+	})
+
+	t.Run("survives a restart", func(t *testing.T) {
+		reopened, err := Open(path)
+		require.NoError(t, err)
+
+		_, err = reopened.RedeemClaimCode(codes[0], addressB.Hex(), now)
+		assert.ErrorIs(t, err, ErrClaimCodeUsedUp)
+		require.Len(t, reopened.ListClaimBatches(), 1)
+	})
+
+	t.Run("deleting a batch revokes its codes", func(t *testing.T) {
+		deleted, err := st.DeleteClaimBatch(batch.ID)
+		require.NoError(t, err)
+		assert.True(t, deleted)
+
+		_, err = st.RedeemClaimCode(codes[2], addressA.Hex(), now)
+		assert.ErrorIs(t, err, ErrClaimCodeInvalid)
+
+		deleted, err = st.DeleteClaimBatch(batch.ID)
+		require.NoError(t, err)
+		assert.False(t, deleted)
+	})
+}
+
+func TestClaimCodeConcurrentRedemption(t *testing.T) {
+	st, err := Open("")
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, codes, err := st.CreateClaimBatch(ClaimBatch{Asset: "usdc", Amount: decimal.NewFromInt(1), MaxUses: 5, ExpiresAt: now.Add(time.Hour)}, 1)
+	require.NoError(t, err)
+
+	var (
+		wg        sync.WaitGroup
+		mu        sync.Mutex
+		successes int
+	)
+	for i := range 50 {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			address := common.BigToAddress(big.NewInt(int64(i + 1))).Hex()
+			if _, err := st.RedeemClaimCode(codes[0], address, now); err == nil {
+				mu.Lock()
+				successes++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.Equal(t, 5, successes)
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"encoding/json"
+	"maps"
+	"slices"
+	"sort"
+	"time"
+)
+
+// WebhookDelivery is a queued event delivery to one webhook endpoint.
+type WebhookDelivery struct {
+	ID            string          `json:"id"`
+	URL           string          `json:"url"`
+	EventType     string          `json:"eventType"`
+	Payload       json.RawMessage `json:"payload"`
+	Attempts      int             `json:"attempts"`
+	NextAttemptAt time.Time       `json:"nextAttemptAt"`
+	LastError     string          `json:"lastError,omitempty"`
+	CreatedAt     time.Time       `json:"createdAt"`
+}
+
+// EnqueueWebhooks adds deliveries to the persistent queue. Events are
+// emitted on every token request, so like the usage counters they are
+// written with a delay rather than rewriting the store each time.
+func (s *Store) EnqueueWebhooks(deliveries []WebhookDelivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, delivery := range deliveries {
+		s.state.Webhooks[delivery.ID] = delivery
+	}
+
+	s.persistLater()
+}
+
+// RemoveWebhooksExcept drops queued deliveries to any URL not in urls and
+// returns how many were dropped.
+func (s *Store) RemoveWebhooksExcept(urls []string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	removed := make(map[string]WebhookDelivery)
+	for id, delivery := range s.state.Webhooks {
+		if !slices.Contains(urls, delivery.URL) {
+			removed[id] = delivery
+			delete(s.state.Webhooks, id)
+		}
+	}
+
+	if len(removed) == 0 {
+		return 0, nil
+	}
+
+	if err := s.persist(); err != nil {
+		maps.Copy(s.state.Webhooks, removed)
+		return 0, err
+	}
+	return len(removed), nil
+}
+
+// DueWebhooks returns up to limit deliveries whose next attempt is due at
+// now, oldest first.
+func (s *Store) DueWebhooks(now time.Time, limit int) []WebhookDelivery {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var due []WebhookDelivery
+	for _, delivery := range s.state.Webhooks {
+		if !delivery.NextAttemptAt.After(now) {
+			due = append(due, delivery)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		if !due[i].NextAttemptAt.Equal(due[j].NextAttemptAt) {
+			return due[i].NextAttemptAt.Before(due[j].NextAttemptAt)
+		}
+		return due[i].CreatedAt.Before(due[j].CreatedAt)
+	})
+
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due
+}
+
+// NextWebhookAttempt returns the earliest scheduled attempt, or false when
+// the queue is empty.
+func (s *Store) NextWebhookAttempt() (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var next time.Time
+	for _, delivery := range s.state.Webhooks {
+		if next.IsZero() || delivery.NextAttemptAt.Before(next) {
+			next = delivery.NextAttemptAt
+		}
+	}
+
+	return next, !next.IsZero()
+}
+
+// PendingWebhooks returns the number of queued deliveries.
+func (s *Store) PendingWebhooks() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return len(s.state.Webhooks)
+}
+
+// RemoveWebhook drops a delivery from the queue after it succeeded or was
+// given up on. The removal is written with a delay: a delivery resurrected
+// by a crash is merely sent again.
+func (s *Store) RemoveWebhook(id string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.state.Webhooks[id]; !ok {
+		return
+	}
+
+	delete(s.state.Webhooks, id)
+	s.persistLater()
+}
+
+// RescheduleWebhook records a failed attempt and when to try again. Like
+// RemoveWebhook, it is written with a delay.
+func (s *Store) RescheduleWebhook(id string, nextAttemptAt time.Time, lastError string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delivery, ok := s.state.Webhooks[id]
+	if !ok {
+		return
+	}
+
+	delivery.Attempts++
+	delivery.NextAttemptAt = nextAttemptAt
+	delivery.LastError = lastError
+	s.state.Webhooks[id] = delivery
+
+	s.persistLater()
+}
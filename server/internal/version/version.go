@@ -0,0 +1,33 @@
+package version
+
+import "runtime/debug"
+
+// Set at link time, e.g.
+//
+//	go build -ldflags "-X faucet-server/internal/version.Version=v1.2.0 -X faucet-server/internal/version.Commit=$(git rev-parse --short HEAD)"
+var (
+	Version   = "dev"
+	Commit    = ""
+	BuildTime = ""
+)
+
+func init() {
+	if Commit != "" {
+		return
+	}
+
+	// Fall back to the VCS information embedded by the go tool
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return
+	}
+
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			Commit = setting.Value
+			if len(Commit) > 12 {
+				Commit = Commit[:12]
+			}
+		}
+	}
+}
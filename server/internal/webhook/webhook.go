@@ -0,0 +1,302 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+)
+
+const (
+	deliveryTimeout = 10 * time.Second
+	batchSize       = 50
+
+	// passTimeout bounds a delivery pass, so a slow endpoint delays the
+	// deliveries to the others by at most this long. Deliveries it cuts off
+	// are retried like failures.
+	passTimeout = 30 * time.Second
+)
+
+// Event types emitted by the faucet.
+const (
+	EventRequestAccepted   = "request.accepted"
+	EventTransferSucceeded = "transfer.succeeded"
+	EventTransferFailed    = "transfer.failed"
+	EventFaucetPaused      = "faucet.paused"
+)
+
+// Headers set on every delivery.
+const (
+	HeaderEvent     = "X-Faucet-Event"
+	HeaderDelivery  = "X-Faucet-Delivery"
+	HeaderSignature = "X-Faucet-Signature"
+)
+
+// Event is the JSON body posted to webhook endpoints.
+type Event struct {
+	ID        string      `json:"id"`
+	Type      string      `json:"type"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Data      interface{} `json:"data"`
+}
+
+// Options tune retries of failed deliveries.
+type Options struct {
+	// MaxAttempts is the number of attempts before a delivery is dropped.
+	MaxAttempts int
+	// InitialBackoff is the delay after the first failure; it doubles with
+	// every further failure up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+}
+
+// Dispatcher queues events in the store and delivers them to every
+// configured endpoint, retrying failures with exponential backoff.
+type Dispatcher struct {
+	store      *store.Store
+	urls       []string
+	secret     []byte
+	options    Options
+	httpClient *http.Client
+
+	// Wakes Run when a new event is queued
+	wake chan struct{}
+}
+
+func NewDispatcher(st *store.Store, urls []string, secret string, options Options) *Dispatcher {
+	return &Dispatcher{
+		store:      st,
+		urls:       urls,
+		secret:     []byte(secret),
+		options:    options,
+		httpClient: &http.Client{Timeout: deliveryTimeout},
+		wake:       make(chan struct{}, 1),
+	}
+}
+
+// Emit queues an event for delivery to every endpoint. Delivery happens in
+// the background, so Emit never blocks on the receivers.
+func (d *Dispatcher) Emit(eventType string, data interface{}) error {
+	now := time.Now().UTC()
+	event := Event{
+		ID:        randomID(),
+		Type:      eventType,
+		CreatedAt: now,
+		Data:      data,
+	}
+
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+	}
+
+	deliveries := make([]store.WebhookDelivery, 0, len(d.urls))
+	for _, url := range d.urls {
+		deliveries = append(deliveries, store.WebhookDelivery{
+			ID:            randomID(),
+			URL:           url,
+			EventType:     eventType,
+			Payload:       payload,
+			NextAttemptAt: now,
+			CreatedAt:     now,
+		})
+	}
+
+	d.store.EnqueueWebhooks(deliveries)
+
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run delivers queued events until ctx is cancelled. Deliveries left in the
+// queue are resumed on the next start, except those to endpoints that are no
+// longer configured.
+func (d *Dispatcher) Run(ctx context.Context) {
+	if dropped, err := d.store.RemoveWebhooksExcept(d.urls); err != nil {
+		logger.Errorf("Failed to drop webhooks to removed endpoints: %v", err)
+	} else if dropped > 0 {
+		logger.Infof("Dropped %d queued webhooks to endpoints no longer configured", dropped)
+	}
+
+	for {
+		d.deliverDue(ctx)
+
+		// With nothing queued, only Emit wakes the dispatcher
+		var timer *time.Timer
+		var due <-chan time.Time
+		if next, ok := d.store.NextWebhookAttempt(); ok {
+			timer = time.NewTimer(max(time.Until(next), 0))
+			due = timer.C
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-d.wake:
+		case <-due:
+		}
+		if timer != nil {
+			timer.Stop()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// deliverDue delivers the due deliveries, to different endpoints
+// concurrently. Each endpoint receives its due deliveries oldest first, but
+// a retried delivery arrives after newer events that succeeded meanwhile, so
+// receivers can't rely on the order.
+func (d *Dispatcher) deliverDue(ctx context.Context) {
+	passCtx, cancel := context.WithTimeout(ctx, passTimeout)
+	defer cancel()
+
+	byURL := make(map[string][]store.WebhookDelivery)
+	for _, delivery := range d.store.DueWebhooks(time.Now(), batchSize) {
+		byURL[delivery.URL] = append(byURL[delivery.URL], delivery)
+	}
+
+	var wg sync.WaitGroup
+	for _, deliveries := range byURL {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.deliverAll(ctx, passCtx, deliveries)
+		}()
+	}
+	wg.Wait()
+}
+
+// deliverAll delivers to one endpoint until passCtx ends. Deliveries
+// interrupted by ctx, i.e. by shutdown, are left as they are.
+func (d *Dispatcher) deliverAll(ctx, passCtx context.Context, deliveries []store.WebhookDelivery) {
+	for _, delivery := range deliveries {
+		if passCtx.Err() != nil {
+			return
+		}
+
+		err := d.deliver(passCtx, delivery)
+		if ctx.Err() != nil {
+			return
+		}
+		if err == nil {
+			d.store.RemoveWebhook(delivery.ID)
+			continue
+		}
+
+		attempts := delivery.Attempts + 1
+		if attempts >= d.options.MaxAttempts {
+			logger.Errorf("Giving up on %s webhook to %s after %d attempts: %v", delivery.EventType, delivery.URL, attempts, err)
+			d.store.RemoveWebhook(delivery.ID)
+			continue
+		}
+
+		next := time.Now().Add(d.backoff(attempts))
+		logger.Warnf("Delivery of %s webhook to %s failed (attempt %d), retrying at %s: %v",
+			delivery.EventType, delivery.URL, attempts, next.Format(time.RFC3339), err)
+		d.store.RescheduleWebhook(delivery.ID, next, err.Error())
+	}
+}
+
+// backoff returns the delay after the given number of failed attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.options.InitialBackoff
+	for i := 1; i < attempts && delay < d.options.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.options.MaxBackoff)
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery store.WebhookDelivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID)
+	req.Header.Set(HeaderSignature, Sign(d.secret, time.Now(), delivery.Payload))
+
+	resp, err := d.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 1<<16))
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("endpoint returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
+// Sign returns the signature header value "t=<unix>,v1=<hex>" where v1 is
+// the HMAC-SHA256 of "<unix>.<body>" keyed with secret.
+func Sign(secret []byte, timestamp time.Time, body []byte) string {
+	unix := strconv.FormatInt(timestamp.Unix(), 10)
+	return "t=" + unix + ",v1=" + hex.EncodeToString(signature(secret, unix, body))
+}
+
+// VerifySignature checks a signature header produced by Sign and rejects
+// timestamps older than tolerance, which limits replays.
+func VerifySignature(secret []byte, header string, body []byte, tolerance time.Duration) error {
+	var unix, provided string
+	for _, part := range strings.Split(header, ",") {
+		key, value, _ := strings.Cut(part, "=")
+		switch key {
+		case "t":
+			unix = value
+		case "v1":
+			provided = value
+		}
+	}
+
+	seconds, err := strconv.ParseInt(unix, 10, 64)
+	if err != nil || provided == "" {
+		return fmt.Errorf("malformed signature header")
+	}
+
+	if age := time.Since(time.Unix(seconds, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("signature timestamp outside tolerance")
+	}
+
+	decoded, err := hex.DecodeString(provided)
+	if err != nil || !hmac.Equal(decoded, signature(secret, unix, body)) {
+		return fmt.Errorf("signature mismatch")
+	}
+
+	return nil
+}
+
+func signature(secret []byte, unix string, body []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(unix))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
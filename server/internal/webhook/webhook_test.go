@@ -0,0 +1,224 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"faucet-server/internal/logger"
+	"faucet-server/internal/store"
+)
+
+const testSecret = "webhook-secret"
+
+type received struct {
+	event     Event
+	eventType string
+	delivery  string
+}
+
+// newReceiver starts an endpoint that fails the first failures requests and
+// verifies the signature of every request it accepts.
+func newReceiver(t *testing.T, failures int32) (*httptest.Server, <-chan received) {
+	t.Helper()
+
+	deliveries := make(chan received, 10)
+	var calls atomic.Int32
+	endpoint := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		assert.NoError(t, VerifySignature([]byte(testSecret), r.Header.Get(HeaderSignature), body, time.Minute))
+
+		var event Event
+		assert.NoError(t, json.Unmarshal(body, &event))
+		deliveries <- received{event: event, eventType: r.Header.Get(HeaderEvent), delivery: r.Header.Get(HeaderDelivery)}
+	}))
+	t.Cleanup(endpoint.Close)
+
+	return endpoint, deliveries
+}
+
+func runDispatcher(t *testing.T, dispatcher *Dispatcher) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		dispatcher.Run(ctx)
+		close(done)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+}
+
+func awaitDelivery(t *testing.T, deliveries <-chan received) received {
+	t.Helper()
+
+	select {
+	case delivery := <-deliveries:
+		return delivery
+	case <-time.After(5 * time.Second):
+		t.Fatal("event was not delivered")
+		return received{}
+	}
+}
+
+func TestMain(m *testing.M) {
+	logger.Initialize("error")
+	m.Run()
+}
+
+func TestDispatcher(t *testing.T) {
+	options := Options{MaxAttempts: 5, InitialBackoff: 10 * time.Millisecond, MaxBackoff: 50 * time.Millisecond}
+
+	t.Run("delivers signed events", func(t *testing.T) {
+		st, err := store.Open("")
+		require.NoError(t, err)
+		endpoint, deliveries := newReceiver(t, 0)
+
+		dispatcher := NewDispatcher(st, []string{endpoint.URL}, testSecret, options)
+		runDispatcher(t, dispatcher)
+
+		require.NoError(t, dispatcher.Emit(EventTransferSucceeded, map[string]string{"txId": "42"}))
+
+		delivery := awaitDelivery(t, deliveries)
+		assert.Equal(t, EventTransferSucceeded, delivery.eventType)
+		assert.Equal(t, EventTransferSucceeded, delivery.event.Type)
+		assert.NotEmpty(t, delivery.event.ID)
+		assert.NotEmpty(t, delivery.delivery)
+		assert.Equal(t, map[string]interface{}{"txId": "42"}, delivery.event.Data)
+
+		require.Eventually(t, func() bool { return st.PendingWebhooks() == 0 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("retries failed deliveries with backoff", func(t *testing.T) {
+		st, err := store.Open("")
+		require.NoError(t, err)
+		endpoint, deliveries := newReceiver(t, 2)
+
+		dispatcher := NewDispatcher(st, []string{endpoint.URL}, testSecret, options)
+		runDispatcher(t, dispatcher)
+
+		require.NoError(t, dispatcher.Emit(EventFaucetPaused, nil))
+
+		delivery := awaitDelivery(t, deliveries)
+		assert.Equal(t, EventFaucetPaused, delivery.event.Type)
+	})
+
+	t.Run("drops deliveries after max attempts", func(t *testing.T) {
+		st, err := store.Open("")
+		require.NoError(t, err)
+		endpoint, _ := newReceiver(t, 100)
+
+		dispatcher := NewDispatcher(st, []string{endpoint.URL}, testSecret, Options{MaxAttempts: 2, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond})
+		runDispatcher(t, dispatcher)
+
+		require.NoError(t, dispatcher.Emit(EventTransferFailed, nil))
+		require.Eventually(t, func() bool { return st.PendingWebhooks() == 0 }, 5*time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("drops deliveries to removed endpoints", func(t *testing.T) {
+		st, err := store.Open("")
+		require.NoError(t, err)
+		removed, removedDeliveries := newReceiver(t, 0)
+		kept, keptDeliveries := newReceiver(t, 0)
+
+		// Queued while both endpoints were configured
+		require.NoError(t, NewDispatcher(st, []string{removed.URL, kept.URL}, testSecret, options).Emit(EventFaucetPaused, nil))
+		require.Equal(t, 2, st.PendingWebhooks())
+
+		runDispatcher(t, NewDispatcher(st, []string{kept.URL}, testSecret, options))
+
+		delivery := awaitDelivery(t, keptDeliveries)
+		assert.Equal(t, EventFaucetPaused, delivery.event.Type)
+		require.Eventually(t, func() bool { return st.PendingWebhooks() == 0 }, time.Second, 5*time.Millisecond)
+		assert.Empty(t, removedDeliveries)
+	})
+
+	t.Run("slow endpoints do not delay the others", func(t *testing.T) {
+		st, err := store.Open("")
+		require.NoError(t, err)
+		fast, deliveries := newReceiver(t, 0)
+
+		release := make(chan struct{})
+		slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		t.Cleanup(slow.Close)
+		t.Cleanup(func() { close(release) })
+
+		dispatcher := NewDispatcher(st, []string{slow.URL, fast.URL}, testSecret, options)
+		runDispatcher(t, dispatcher)
+
+		require.NoError(t, dispatcher.Emit(EventRequestAccepted, nil))
+
+		select {
+		case delivery := <-deliveries:
+			assert.Equal(t, EventRequestAccepted, delivery.event.Type)
+		case <-time.After(time.Second):
+			t.Fatal("delivery to the fast endpoint waited for the slow one")
+		}
+	})
+
+	t.Run("resumes queued deliveries after restart", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "faucet.json")
+		st, err := store.Open(path)
+		require.NoError(t, err)
+		endpoint, deliveries := newReceiver(t, 0)
+
+		// Queue without running the dispatcher, as if the process stopped
+		require.NoError(t, NewDispatcher(st, []string{endpoint.URL}, testSecret, options).Emit(EventRequestAccepted, nil))
+		require.NoError(t, st.Flush())
+
+		reopened, err := store.Open(path)
+		require.NoError(t, err)
+		assert.Equal(t, 1, reopened.PendingWebhooks())
+
+		runDispatcher(t, NewDispatcher(reopened, []string{endpoint.URL}, testSecret, options))
+
+		delivery := awaitDelivery(t, deliveries)
+		assert.Equal(t, EventRequestAccepted, delivery.event.Type)
+	})
+}
+
+func TestBackoff(t *testing.T) {
+	dispatcher := NewDispatcher(nil, nil, testSecret, Options{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second})
+
+	assert.Equal(t, time.Second, dispatcher.backoff(1))
+	assert.Equal(t, 2*time.Second, dispatcher.backoff(2))
+	assert.Equal(t, 8*time.Second, dispatcher.backoff(4))
+	assert.Equal(t, 10*time.Second, dispatcher.backoff(5))
+	assert.Equal(t, 10*time.Second, dispatcher.backoff(50))
+}
+
+func TestVerifySignature(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	header := Sign([]byte(testSecret), time.Now(), body)
+
+	assert.NoError(t, VerifySignature([]byte(testSecret), header, body, time.Minute))
+	assert.Error(t, VerifySignature([]byte("other-secret"), header, body, time.Minute))
+	assert.Error(t, VerifySignature([]byte(testSecret), header, []byte(`{"id":"2"}`), time.Minute))
+	assert.Error(t, VerifySignature([]byte(testSecret), "garbage", body, time.Minute))
+
+	stale := Sign([]byte(testSecret), time.Now().Add(-time.Hour), body)
+	assert.Error(t, VerifySignature([]byte(testSecret), stale, body, time.Minute))
+}